@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gonum/blas"
+	"github.com/gonum/blas/blas64"
+	"github.com/gonum/blas/native"
+)
+
+// blasImpl maps BLAS implementation names to their actual implementations.
+// cgo implementation is only available if the program is built with cblas build tag.
+var blasImpl = map[string]blas.Float64{
+	"native": native.Implementation{},
+}
+
+// useBLAS sets the BLAS implementation used by all matrix operations.
+// It fails with error if the requested implementation is not available.
+func useBLAS(name string) error {
+	impl, ok := blasImpl[name]
+	if !ok {
+		return fmt.Errorf("Unsupported BLAS implementation: %s\n", name)
+	}
+	blas64.Use(impl)
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build cblas
+// +build cblas
+
+package main
+
+import "github.com/gonum/blas/cgo"
+
+// cgo BLAS implementation links against the system CBLAS library i.e. OpenBLAS or Accelerate
+func init() {
+	blasImpl["cgo"] = cgo.Implementation{}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/viz"
+)
+
+// runBoundary classifies a grid spanning the feature space of a data set with two features
+// by saved neural network model and writes the decision regions either as PNG image with
+// the data set samples overlaid or as CSV records if the output file has .csv extension
+func runBoundary(args []string) error {
+	fs := flag.NewFlagSet("boundary", flag.ExitOnError)
+	modelPath := fs.String("model", "", "Path to a saved neural net model")
+	dataPath := fs.String("data", "", "Path to data set with two features")
+	labeled := fs.Bool("labeled", false, "Is the data set labeled")
+	outPath := fs.String("out", "", "Path to PNG image or CSV file decision regions are written to")
+	steps := fs.Int("steps", 100, "Number of grid points along each feature")
+	cell := fs.Int("cell", 4, "Size of a single grid point in pixels")
+	fs.Parse(args)
+	// path to model is mandatory
+	if *modelPath == "" {
+		return errors.New("You must specify path to model file")
+	}
+	// path to data is mandatory
+	if *dataPath == "" {
+		return errors.New("You must specify path to data set")
+	}
+	// path to output is mandatory
+	if *outPath == "" {
+		return errors.New("You must specify output path")
+	}
+	net, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	ds, err := dataset.NewDataSet(*dataPath, *labeled)
+	if err != nil {
+		return err
+	}
+	b, err := viz.NewBoundary(ds.Features(), *steps, classifier(net))
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(*outPath) == ".csv" {
+		err = b.WriteCSV(f)
+	} else {
+		var labels []float64
+		if l := ds.Labels(); l != nil {
+			labels = make([]float64, l.(*mat64.Vector).Len())
+			mat64.Col(labels, 0, l)
+		}
+		var img *image.RGBA
+		if img, err = b.Image(*cell, ds.Features(), labels); err == nil {
+			err = png.Encode(f, img)
+		}
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// classifier returns classifier which preprocesses samples the same way as the network training
+// samples and returns their data set labels predicted by the network
+func classifier(net *neural.Network) viz.Classifier {
+	return func(mx *mat64.Dense) ([]float64, error) {
+		features, err := scaleFeatures(net, mx, false)
+		if err != nil {
+			return nil, err
+		}
+		labelsVec, _, err := net.Predict(features)
+		if err != nil {
+			return nil, err
+		}
+		labels := make([]float64, labelsVec.Len())
+		for i := range labels {
+			labels[i] = labelsVec.At(i, 0)
+			if lm := net.LabelMap(); lm != nil {
+				if labels[i], err = lm.Decode(labels[i]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return labels, nil
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+)
+
+// runCluster trains self-organizing map defined in manifest on a data set and writes
+// the cluster of each sample in CSV format: the index of the closest map unit along with
+// its grid row and column. Quantization error of the trained map is written to stderr.
+func runCluster(args []string) error {
+	fs := flag.NewFlagSet("cluster", flag.ExitOnError)
+	manPath := fs.String("manifest", "", "Path to a self-organizing map manifest file")
+	dataPath := fs.String("data", "", "Path to data set to cluster")
+	labeled := fs.Bool("labeled", false, "Is the data set labeled: labels are ignored")
+	scale := fs.Bool("scale", false, "Require data scaling")
+	seed := fs.Int64("seed", 55, "Seed of random numbers used in map initialization and training")
+	outPath := fs.String("out", "", "Path to output file (default: stdout)")
+	fs.Parse(args)
+	// path to manifest is mandatory
+	if *manPath == "" {
+		return errors.New("You must specify path to manifest file")
+	}
+	// path to data is mandatory
+	if *dataPath == "" {
+		return errors.New("You must specify path to data set")
+	}
+	c, err := config.New(*manPath)
+	if err != nil {
+		return err
+	}
+	if c.SOM == nil {
+		return errors.New("Manifest does not define self-organizing map")
+	}
+	ds, err := dataset.NewDataSet(*dataPath, *labeled)
+	if err != nil {
+		return err
+	}
+	features := ds.Features()
+	if *scale {
+		features = dataset.Scale(features)
+	}
+	som, err := neural.NewSOMWithRand(c.SOM, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		return err
+	}
+	if err := som.Train(features); err != nil {
+		return err
+	}
+	qErr, err := som.QuantError(features)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Quantization error: %f\n", qErr)
+	units, err := som.Assign(features)
+	if err != nil {
+		return err
+	}
+	// write clusters to stdout unless output file is specified
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"unit", "row", "col"}); err != nil {
+		return err
+	}
+	_, cols := som.Dims()
+	for _, u := range units {
+		record := []string{strconv.Itoa(u), strconv.Itoa(u / cols), strconv.Itoa(u % cols)}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+)
+
+// commands maps subcommand names to their implementations.
+// Each command is passed the cli arguments which follow the command name.
+var commands = map[string]func([]string) error{
+	"predict":  runPredict,
+	"evaluate": runEvaluate,
+	"sweep":    runSweep,
+	"inspect":  runInspect,
+	"convert":  runConvert,
+	"info":     runInfo,
+	"cluster":  runCluster,
+	"export":   runExport,
+	"weights":  runWeights,
+	"boundary": runBoundary,
+	"embed":    runEmbed,
+}
+
+// runCommand runs the subcommand specified as the first cli argument.
+// It returns false if no subcommand has been specified.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+	if err := cmd(args[1:]); err != nil {
+		fmt.Printf("Error running %s: %s\n", args[0], err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// loadModel loads neural network saved in the file stored in path
+func loadModel(path string) (*neural.Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return neural.Load(f)
+}
+
+// saveModel saves neural network to the file stored in path
+func saveModel(path string, net *neural.Network) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := net.Save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// scaleFeatures preprocesses features with the pipeline and scales them with the scaler the network
+// has been trained with. If the network has no scaler, features are scaled with their own statistics
+// if scale is true.
+func scaleFeatures(net *neural.Network, features mat64.Matrix, scale bool) (mat64.Matrix, error) {
+	if p := net.Pipeline(); p != nil {
+		var err error
+		if features, err = p.Transform(features); err != nil {
+			return nil, err
+		}
+	}
+	if s := net.Scaler(); s != nil {
+		return s.Scale(features)
+	}
+	if scale {
+		return dataset.Scale(features), nil
+	}
+	return features, nil
+}
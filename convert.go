@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+)
+
+// runConvert converts data set between supported formats. Input and output formats are
+// inferred from the file extensions. Labels of the converted data set are stored in its
+// last column regardless of the label column of the input data set. Unlabeled time series can be
+// converted into sliding window samples labeled by the value of target column in the future.
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	inPath := fs.String("in", "", "Path to input data set")
+	outPath := fs.String("out", "", "Path to output data set")
+	labeled := fs.Bool("labeled", false, "Is the input data set labeled")
+	labelCol := fs.Int("label-col", 0, "Label column of the input data set starting at 1 (default: last column)")
+	scale := fs.Bool("scale", false, "Require data scaling")
+	window := fs.Int("window", 0, "Number of time steps in sliding window samples of time series (default: no windowing)")
+	horizon := fs.Int("horizon", 1, "Number of time steps between the last window step and the forecast label")
+	targetCol := fs.Int("target-col", 0, "Time series column forecast by window samples starting at 1 (default: last column)")
+	fs.Parse(args)
+	// path to input data is mandatory
+	if *inPath == "" {
+		return errors.New("You must specify path to input data set")
+	}
+	// path to output data is mandatory
+	if *outPath == "" {
+		return errors.New("You must specify path to output data set")
+	}
+	// label column can only be selected in labeled data set
+	if *labelCol != 0 && !*labeled {
+		return errors.New("You must specify labeled data set to select label column")
+	}
+	// time series are labeled by windowing
+	if *window != 0 && *labeled {
+		return errors.New("You must specify unlabeled time series to convert it to window samples")
+	}
+	// LibSVM data sets always contain labels
+	if ext := filepath.Ext(*outPath); (ext == ".libsvm" || ext == ".svm") && !*labeled && *window == 0 {
+		return errors.New("You must specify labeled data set to convert it to LibSVM format")
+	}
+	ds, err := dataset.NewDataSet(*inPath, *labeled)
+	if err != nil {
+		return err
+	}
+	dataMx := ds.Data()
+	rows, cols := dataMx.Dims()
+	if *labelCol < 0 || *labelCol > cols {
+		return fmt.Errorf("Incorrect label column: %d", *labelCol)
+	}
+	// move the selected label column to the last column
+	if *labelCol != 0 && *labelCol != cols {
+		mx := mat64.NewDense(rows, cols, nil)
+		for i := 0; i < rows; i++ {
+			for j, k := 0, 0; j < cols; j++ {
+				if j == *labelCol-1 {
+					mx.Set(i, cols-1, dataMx.At(i, j))
+					continue
+				}
+				mx.Set(i, k, dataMx.At(i, j))
+				k++
+			}
+		}
+		dataMx = mx
+	}
+	// convert time series into window samples labeled in the last column
+	outLabeled := *labeled
+	if *window != 0 {
+		target := *targetCol
+		if target == 0 {
+			target = cols
+		}
+		if dataMx, err = dataset.Window(dataMx, *window, *horizon, target-1); err != nil {
+			return err
+		}
+		rows, cols = dataMx.Dims()
+		outLabeled = true
+	}
+	// scale features but leave labels intact
+	if *scale {
+		features := dataMx
+		if outLabeled {
+			features = dataMx.(*mat64.Dense).View(0, 0, rows, cols-1)
+		}
+		scaled := dataset.Scale(features)
+		mx := new(mat64.Dense)
+		mx.Clone(dataMx)
+		_, featCols := scaled.Dims()
+		mx.View(0, 0, rows, featCols).(*mat64.Dense).Copy(scaled)
+		dataMx = mx
+	}
+	return dataset.WriteFile(*outPath, dataMx)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/crossval"
+)
+
+// crossValidate runs k-fold cross-validation of the network defined in configuration:
+// every fold is tested on a network trained from scratch on the remaining folds.
+// If scaling is requested, features are scaled by scaler fitted on the remaining folds.
+// It returns summary which contains the accuracy of every fold and their mean and standard deviation.
+func crossValidate(c *config.Config, inMx *mat64.Dense, labels *mat64.Vector) (*summary, error) {
+	fold := 0
+	train := func(trainInMx *mat64.Dense, trainLabels *mat64.Vector,
+		testInMx *mat64.Dense, testLabels *mat64.Vector) (float64, error) {
+		fold++
+		// scaler is fitted on the training folds only
+		if scale {
+			var err error
+			if _, trainInMx, testInMx, err = scaleSplit(trainInMx, testInMx); err != nil {
+				return 0.0, err
+			}
+		}
+		net, err := neural.NewNetwork(c.Network)
+		if err != nil {
+			return 0.0, err
+		}
+		// display training progress unless quiet output is requested
+		m := new(neural.Monitor)
+		if !quiet {
+			m.Callbacks = append(m.Callbacks, prog.update)
+		}
+		prog.start(c.Training)
+		_, err = net.TrainMonitored(c.Training, trainInMx, trainLabels, m)
+		prog.done()
+		if err != nil {
+			return 0.0, err
+		}
+		accuracy, err := net.Validate(testInMx, testLabels)
+		if err != nil {
+			return 0.0, err
+		}
+		log.Infof("Fold %d/%d accuracy: %f", fold, cv, accuracy)
+		return accuracy, nil
+	}
+	res, err := crossval.KFold(inMx, labels, cv, stratify, train)
+	if err != nil {
+		return nil, err
+	}
+	return &summary{
+		Accuracy:       res.Mean,
+		AccuracyStdDev: res.StdDev,
+		Folds:          res.Accuracy,
+	}, nil
+}
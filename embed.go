@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+)
+
+// runEmbed writes activations of a layer of saved neural network model for all samples of a data set
+// along with their labels to vectors.tsv and metadata.tsv files in the output directory, so that the
+// learnt representations can be explored in TensorBoard embedding projector
+func runEmbed(args []string) error {
+	fs := flag.NewFlagSet("embed", flag.ExitOnError)
+	modelPath := fs.String("model", "", "Path to a saved neural net model")
+	dataPath := fs.String("data", "", "Path to data set with samples to embed")
+	labeled := fs.Bool("labeled", false, "Is the data set labeled")
+	layer := fs.Int("layer", 0, "Index of the layer activations are exported from: 1 is the first hidden layer (default: last hidden layer)")
+	outDir := fs.String("out", "", "Path to a directory vectors.tsv and metadata.tsv files are written to")
+	fs.Parse(args)
+	// path to model is mandatory
+	if *modelPath == "" {
+		return errors.New("You must specify path to model file")
+	}
+	// path to data is mandatory
+	if *dataPath == "" {
+		return errors.New("You must specify path to data set")
+	}
+	// path to output is mandatory
+	if *outDir == "" {
+		return errors.New("You must specify output directory")
+	}
+	net, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	ds, err := dataset.NewDataSet(*dataPath, *labeled)
+	if err != nil {
+		return err
+	}
+	// the last hidden layer is the layer before the OUTPUT layer
+	if *layer == 0 {
+		*layer = len(net.Layers()) - 2
+	}
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		return err
+	}
+	vectors, err := os.Create(filepath.Join(*outDir, "vectors.tsv"))
+	if err != nil {
+		return err
+	}
+	defer vectors.Close()
+	metadata, err := os.Create(filepath.Join(*outDir, "metadata.tsv"))
+	if err != nil {
+		return err
+	}
+	defer metadata.Close()
+	if err := neural.WriteEmbeddings(vectors, metadata, net, ds, *layer); err != nil {
+		return err
+	}
+	if err := vectors.Close(); err != nil {
+		return err
+	}
+	return metadata.Close()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/eval"
+)
+
+// runEvaluate loads saved neural network model, evaluates it on a labeled data set
+// and writes accuracy, per class metrics and confusion matrix of the classification.
+func runEvaluate(args []string) error {
+	fs := flag.NewFlagSet("evaluate", flag.ExitOnError)
+	modelPath := fs.String("model", "", "Path to a saved neural net model")
+	dataPath := fs.String("data", "", "Path to labeled data set to evaluate the model on")
+	scale := fs.Bool("scale", false, "Require data scaling unless the model contains scaler")
+	format := fs.String("format", "text", "Report format: text or json")
+	outPath := fs.String("out", "", "Path to output file (default: stdout)")
+	fs.Parse(args)
+	// path to model is mandatory
+	if *modelPath == "" {
+		return errors.New("You must specify path to model file")
+	}
+	// path to data is mandatory
+	if *dataPath == "" {
+		return errors.New("You must specify path to data set")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("Unsupported report format: %s", *format)
+	}
+	net, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	// evaluation data set must be labeled
+	ds, err := dataset.NewDataSet(*dataPath, true)
+	if err != nil {
+		return err
+	}
+	// scale features with the saved scaler or if scaling is required
+	features, err := scaleFeatures(net, ds.Features(), *scale)
+	if err != nil {
+		return err
+	}
+	labels := ds.Labels().(*mat64.Vector)
+	// map data set labels to network labels
+	if lm := net.LabelMap(); lm != nil {
+		if labels, err = lm.Encode(labels); err != nil {
+			return err
+		}
+	}
+	report, err := eval.ClassificationReport(net, features.(*mat64.Dense), labels)
+	if err != nil {
+		return err
+	}
+	// write report to stdout unless output file is specified
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if *format == "json" {
+		return report.WriteJSON(out)
+	}
+	return report.WriteText(out)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runExport exports saved neural network model to TensorFlow formats: either SavedModel
+// directory which can be served by TensorFlow Serving or frozen GraphDef file
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	modelPath := fs.String("model", "", "Path to a saved neural net model")
+	outPath := fs.String("out", "", "Path to SavedModel directory or GraphDef file")
+	format := fs.String("format", "savedmodel", "Export format: savedmodel or graphdef")
+	fs.Parse(args)
+	// path to model is mandatory
+	if *modelPath == "" {
+		return errors.New("You must specify path to model file")
+	}
+	// path to output is mandatory
+	if *outPath == "" {
+		return errors.New("You must specify output path")
+	}
+	net, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "savedmodel":
+		return net.ExportSavedModel(*outPath)
+	case "graphdef":
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		if err := net.ExportGraphDef(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	return fmt.Errorf("Unsupported export format: %s", *format)
+}
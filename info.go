@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/milosgajdos83/go-neural/neural"
+)
+
+// runInfo loads saved neural network model and prints its architecture summary,
+// the number of its parameters and training metadata recorded in the model file.
+func runInfo(args []string) error {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	modelPath := fs.String("model", "", "Path to a saved neural net model")
+	fs.Parse(args)
+	// path to model is mandatory
+	if *modelPath == "" {
+		return errors.New("You must specify path to model file")
+	}
+	net, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("ID: %s\nKind: %s\n\n", net.ID(), strings.ToLower(net.Kind().String()))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "layer\tid\tkind\tsize\tactivation\tparams\t")
+	layers := net.Layers()
+	params := 0
+	for i, layer := range layers {
+		var size, layerParams int
+		activation := "-"
+		// INPUT layer size is derived from the weights of the first HIDDEN layer
+		if layer.Kind() == neural.INPUT {
+			_, cols := layers[1].WeightsView().Dims()
+			size = cols - 1
+		} else {
+			rows, cols := layer.WeightsView().Dims()
+			size, layerParams = rows, rows*cols
+			activation = layer.Activation()
+		}
+		params += layerParams
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%d\t\n",
+			i, layer.ID(), strings.ToLower(layer.Kind().String()), size, activation, layerParams)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nParameters: %d\n", params)
+	meta := net.Metadata()
+	if meta == (neural.Metadata{}) {
+		fmt.Println("Training metadata: none")
+		return nil
+	}
+	fmt.Printf("Trained: %s\n", meta.Trained.Format(time.RFC3339))
+	fmt.Printf("Iterations: %d\n", meta.Iterations)
+	fmt.Printf("Cost: %f\n", meta.Cost)
+	fmt.Printf("Accuracy: %f\n", meta.Accuracy)
+	return nil
+}
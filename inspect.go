@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+)
+
+// runInspect prints data set dimensions, per feature statistics and class distribution
+func runInspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	dataPath := fs.String("data", "", "Path to data set to inspect")
+	labeled := fs.Bool("labeled", false, "Is the data set labeled")
+	fs.Parse(args)
+	// path to data is mandatory
+	if *dataPath == "" {
+		return errors.New("You must specify path to data set")
+	}
+	ds, err := dataset.NewDataSet(*dataPath, *labeled)
+	if err != nil {
+		return err
+	}
+	d := ds.Describe()
+	fmt.Printf("Samples: %d\nFeatures: %d\n\n", d.Samples, len(d.Features))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "feature\tmean\tstddev\tmin\tmax\tmissing\t")
+	for i, f := range d.Features {
+		fmt.Fprintf(tw, "%d\t%.4f\t%.4f\t%.4f\t%.4f\t%d\t\n",
+			i+1, f.Mean, f.StdDev, f.Min, f.Max, f.Missing)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if d.Classes == nil {
+		return nil
+	}
+	fmt.Printf("\nClasses: %d\n\n", len(d.Classes))
+	fmt.Fprintln(tw, "label\tcount\tpercent\t")
+	for _, c := range d.Classes {
+		fmt.Fprintf(tw, "%g\t%d\t%.2f\t\n", c.Label, c.Count, float64(c.Count)/float64(d.Samples)*100)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/milosgajdos83/go-neural/neural"
+)
+
+// defaultCheckpointDir is a directory interrupted training is saved to if no checkpoint
+// directory has been specified
+const defaultCheckpointDir = "checkpoint"
+
+// errInterrupted is returned by training callback when the training has been interrupted
+var errInterrupted = errors.New("Training interrupted")
+
+// interrupter stops the training after the current iteration when interrupt signal is received
+type interrupter struct {
+	// stop is closed when interrupt signal is received
+	stop chan struct{}
+	// last contains the metrics of the last training iteration
+	last neural.Metrics
+}
+
+// newInterrupter creates new interrupter which catches the first interrupt signal.
+// Following interrupt signals are not caught: they terminate the program immediately.
+func newInterrupter() *interrupter {
+	in := &interrupter{stop: make(chan struct{})}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		signal.Stop(sig)
+		log.Infof("Interrupt received: stopping training after current iteration, interrupt again to abort")
+		close(in.stop)
+	}()
+	return in
+}
+
+// check stops the training if interrupt signal has been received.
+// It implements neural.Callback function.
+func (in *interrupter) check(m *neural.Metrics) error {
+	in.last = *m
+	select {
+	case <-in.stop:
+		return errInterrupted
+	default:
+		return nil
+	}
+}
+
+// resumeCommand returns the command which resumes the training from checkpoint directory
+func resumeCommand(dir string) string {
+	args := os.Args
+	if checkpointDir == "" {
+		args = append(args, "-checkpoint-dir", dir)
+	}
+	if !resume {
+		args = append(args, "-resume")
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if strings.ContainsAny(arg, " \t\"'") {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -4,12 +4,17 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/gonum/matrix/mat64"
 	"github.com/milosgajdos83/go-neural/neural"
 	"github.com/milosgajdos83/go-neural/pkg/config"
 	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/logger"
 )
 
 var (
@@ -21,13 +26,82 @@ var (
 	scale bool
 	// manifest contains neural net config
 	manifest string
+	// blasName is the name of BLAS implementation
+	blasName string
+	// checkpointDir is a directory training checkpoints are saved to
+	checkpointDir string
+	// checkpointEvery is the number of iterations between two checkpoints
+	checkpointEvery int
+	// resume training from the last checkpoint
+	resume bool
+	// verbose logs cost traces
+	verbose bool
+	// quiet suppresses all output but errors and results
+	quiet bool
+	// logFile is a path to the file log messages are written to
+	logFile string
+	// log logs training messages
+	log *logger.Leveled
+	// split is the fraction of samples used for training, the rest is used for testing
+	split float64
+	// stratify preserves the proportions of labels when splitting data set
+	stratify bool
+	// groupCol is the feature column containing group keys of samples kept together when splitting data set
+	groupCol int
+	// seed seeds random number generator
+	seed int64
+	// metricsFile is a path to the file training metrics are written to
+	metricsFile string
+	// plotFile is a path to the image training cost curves are plotted to
+	plotFile string
+	// output is the output format of training results
+	output string
+	// modelOut is a path to the file the trained network is saved to
+	modelOut string
+	// cv is the number of cross-validation folds
+	cv int
+	// outliers is the method of detecting outlier samples removed before training
+	outliers string
+	// outlierThreshold is the threshold of outlier detection method
+	outlierThreshold float64
+	// prog displays training progress
+	prog = newProgress(os.Stdout)
 )
 
+// outlierThresholds maps outlier detection methods to their default thresholds
+var outlierThresholds = map[string]float64{
+	"zscore": 3.0,
+	"iqr":    1.5,
+}
+
+// checkpointFile is the name of the file checkpoints are saved to in checkpoint directory
+const checkpointFile = "checkpoint.json"
+
 func init() {
 	flag.StringVar(&data, "data", "", "Path to training data set")
 	flag.BoolVar(&labeled, "labeled", false, "Is the data set labeled")
 	flag.BoolVar(&scale, "scale", false, "Require data scaling")
 	flag.StringVar(&manifest, "manifest", "", "Path to a neural net manifest file")
+	flag.StringVar(&blasName, "blas", "native", "BLAS implementation: native or cgo (requires cblas build tag)")
+	flag.StringVar(&checkpointDir, "checkpoint-dir", "", "Path to a directory training checkpoints are saved to")
+	flag.IntVar(&checkpointEvery, "checkpoint-every", 1, "Number of training iterations between checkpoints")
+	flag.BoolVar(&resume, "resume", false, "Resume training from the last checkpoint saved in checkpoint directory")
+	flag.BoolVar(&verbose, "v", false, "Verbose output: log cost traces")
+	flag.BoolVar(&quiet, "quiet", false, "Quiet output: only log errors and print results")
+	flag.Float64Var(&split, "split", 0.0, "Fraction of samples used for training, the rest is used for testing (default: train and test on all samples)")
+	flag.BoolVar(&stratify, "stratify", false, "Preserve the proportions of labels when splitting data set")
+	flag.IntVar(&groupCol, "group-col", 0, "Column starting at 1 containing group keys of samples kept on the same side of split: the column is not used as a feature")
+	flag.Int64Var(&seed, "seed", 55, "Seed of random numbers used in data set splitting and weights initialization")
+	flag.StringVar(&metricsFile, "metrics-file", "", "Path to a file training metrics are written to: CSV if it has .csv extension, JSON Lines otherwise")
+	flag.StringVar(&plotFile, "plot", "", "Path to PNG or SVG image training and validation cost are plotted to (requires plot build tag)")
+	flag.StringVar(&output, "output", "text", "Output format of training results: text, json or csv")
+	flag.StringVar(&modelOut, "model-out", "", "Path to the file the trained network is saved to")
+	flag.IntVar(&cv, "cv", 0, "Number of k-fold cross-validation folds (default: no cross-validation)")
+	flag.StringVar(&outliers, "outliers", "", "Remove outlier samples before training: zscore or iqr (default: keep all samples)")
+	flag.Float64Var(&outlierThreshold, "outlier-threshold", 0.0, "Threshold of outlier detection: 3 standard deviations for zscore, 1.5 interquartile ranges for iqr by default")
+	flag.StringVar(&cpuProfile, "cpuprofile", "", "Path to the file CPU profile is written to")
+	flag.StringVar(&memProfile, "memprofile", "", "Path to the file memory profile is written to")
+	flag.StringVar(&logFile, "log-file", "", "Path to a file log messages are written to (default: stderr)")
 }
 
 func parseCliFlags() error {
@@ -41,65 +115,400 @@ func parseCliFlags() error {
 	if manifest == "" {
 		return errors.New("You must specify path to manifest file")
 	}
+	// verbose and quiet output are mutually exclusive
+	if verbose && quiet {
+		return errors.New("You can't request both verbose and quiet output")
+	}
+	// output format must be supported
+	if _, ok := outputs[output]; !ok {
+		return fmt.Errorf("Unsupported output format: %s", output)
+	}
+	// split ratio must be a fraction
+	if split < 0.0 || split >= 1.0 {
+		return fmt.Errorf("Split must be in [0, 1) interval: %f", split)
+	}
+	// cross-validation requires at least 2 folds
+	if cv < 0 || cv == 1 {
+		return fmt.Errorf("Number of cross-validation folds must be at least 2: %d", cv)
+	}
+	// cross-validation trains and tests on all folds of the data set
+	if cv > 0 && (split > 0.0 || resume || checkpointDir != "" || modelOut != "" || plotFile != "") {
+		return errors.New("You can't combine cross-validation with split, checkpoints, model output or plot")
+	}
+	// plot format is derived from the file extension
+	if plotFile != "" && !neural.CanPlot(plotFormat(plotFile)) {
+		return fmt.Errorf("Unsupported plot format: %s (plots require plot build tag)", filepath.Ext(plotFile))
+	}
+	// stratification only makes sense when splitting the data set
+	if stratify && split == 0.0 && cv == 0 {
+		return errors.New("You must specify split ratio or cross-validation folds to stratify the data set")
+	}
+	// outlier detection method must be supported
+	if outliers != "" {
+		if _, ok := outlierThresholds[outliers]; !ok {
+			return fmt.Errorf("Unsupported outlier detection method: %s", outliers)
+		}
+		if outlierThreshold == 0.0 {
+			outlierThreshold = outlierThresholds[outliers]
+		}
+	}
+	if outlierThreshold < 0.0 {
+		return fmt.Errorf("Outlier threshold must be positive: %f", outlierThreshold)
+	}
+	// group keys can only be used when splitting data set
+	if groupCol < 0 {
+		return fmt.Errorf("Incorrect group column: %d", groupCol)
+	}
+	if groupCol > 0 && (split == 0.0 || stratify) {
+		return errors.New("You must specify split ratio without stratification to split data set by groups")
+	}
+	// training can only be resumed from checkpoint directory
+	if resume && checkpointDir == "" {
+		return errors.New("You must specify checkpoint directory to resume training")
+	}
 	return nil
 }
 
+// createNetwork creates new neural network per configuration passed in as parameter.
+// If the training is resumed, the network is loaded from the last saved checkpoint instead.
+// New network is created if no checkpoint has been saved yet.
+func createNetwork(c *config.NetConfig) (*neural.Network, error) {
+	if resume {
+		net, err := loadModel(filepath.Join(checkpointDir, checkpointFile))
+		if err == nil {
+			log.Infof("Resuming training from checkpoint in %s", checkpointDir)
+			return net, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		log.Infof("No checkpoint found in %s, starting new training", checkpointDir)
+	}
+	return neural.NewNetwork(c)
+}
+
+// plotFormat returns the format of the plot stored in path derived from its extension
+func plotFormat(path string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// writePlot plots training history to the file stored in path
+func writePlot(path string, h neural.History) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := h.Plot(f, plotFormat(path)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// scaleSplit fits scaler on training samples only so that test samples don't leak into the
+// training and scales both training and test samples by it. Test samples may be the training ones.
+func scaleSplit(trainInMx, testInMx *mat64.Dense) (*dataset.Scaler, *mat64.Dense, *mat64.Dense, error) {
+	scaler := dataset.NewScaler(trainInMx)
+	trainMx, err := scaler.Scale(trainInMx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	testMx := trainMx
+	if testInMx != trainInMx {
+		if testMx, err = scaler.Scale(testInMx); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return scaler, trainMx.(*mat64.Dense), testMx.(*mat64.Dense), nil
+}
+
+// removeColumn removes column col from features matrix and returns the remaining features
+// followed by the removed column values. It fails with error if col is not a column of mx.
+func removeColumn(mx *mat64.Dense, col int) (*mat64.Dense, []float64, error) {
+	rows, cols := mx.Dims()
+	if col < 0 || col >= cols || cols == 1 {
+		return nil, nil, fmt.Errorf("Incorrect column: %d", col+1)
+	}
+	out := mat64.NewDense(rows, cols-1, nil)
+	values := make([]float64, rows)
+	for i := 0; i < rows; i++ {
+		row := mx.RawRowView(i)
+		values[i] = row[col]
+		copy(out.RawRowView(i), row[:col])
+		copy(out.RawRowView(i)[col:], row[col+1:])
+	}
+	return out, values, nil
+}
+
+// newLogger creates logger per verbosity cli flags which writes to stderr or to log file
+func newLogger() (*logger.Leveled, error) {
+	level := logger.Info
+	switch {
+	case verbose:
+		level = logger.Debug
+	case quiet:
+		level = logger.Quiet
+	}
+	// log messages must not be written in the middle of progress line
+	var w io.Writer = lineWriter{p: prog, w: os.Stderr}
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+	return logger.New(w, level), nil
+}
+
 func main() {
+	// run subcommand if requested
+	if runCommand(os.Args[1:]) {
+		return
+	}
 	// parse cli parameters
 	if err := parseCliFlags(); err != nil {
 		fmt.Printf("Error parsing cli flags: %s\n", err)
 		os.Exit(1)
 	}
+	// start profiling if requested
+	if err := startProfiling(); err != nil {
+		fmt.Printf("Error starting profiling: %s\n", err)
+		os.Exit(1)
+	}
+	// set up logging of training messages
+	var err error
+	log, err = newLogger()
+	if err != nil {
+		fmt.Printf("Error creating logger: %s\n", err)
+		exit(1)
+	}
+	neural.SetLogger(log)
+	dataset.SetLogger(log)
+	// structured output must not be mixed with training progress
+	if output != "text" {
+		prog.w = os.Stderr
+	}
+	// set BLAS implementation used by matrix operations
+	if err := useBLAS(blasName); err != nil {
+		fmt.Printf("Error setting BLAS implementation: %s\n", err)
+		exit(1)
+	}
 	// Read in configuration file
 	config, err := config.New(manifest)
 	if err != nil {
 		fmt.Printf("Error reading manifest file: %s\n", err)
-		os.Exit(1)
+		exit(1)
 	}
+	// self-organizing maps don't classify
+	if config.SOM != nil {
+		fmt.Println("Self-organizing maps can only be trained via cluster command")
+		exit(1)
+	}
+	// all the randomness is derived from the seed so the training can be reproduced
+	rand.Seed(seed)
 	// load new data set from provided file
 	ds, err := dataset.NewDataSet(data, labeled)
 	if err != nil {
 		fmt.Printf("Unable to load Data Set: %s\n", err)
-		os.Exit(1)
+		exit(1)
 	}
-	// extract features from data set
+	// extract features and labels from data set
 	features := ds.Features()
-	// if we require features scaling, scale data
-	if scale {
-		features = dataset.Scale(features)
-	}
-	// extract data labels
 	labels := ds.Labels()
 	if labels == nil {
 		fmt.Println("Data set does not contain any labels")
-		os.Exit(1)
+		exit(1)
+	}
+	// group keys are not features
+	var groups []float64
+	if groupCol > 0 {
+		if features, groups, err = removeColumn(features.(*mat64.Dense), groupCol-1); err != nil {
+			fmt.Printf("Unable to read group keys: %s\n", err)
+			exit(1)
+		}
+	}
+	// remove outlier samples before the scaler is fitted so they don't skew it
+	if outliers != "" {
+		inMx, vec, removed, err := dataset.RemoveOutliers(features.(*mat64.Dense), labels.(*mat64.Vector),
+			outliers, outlierThreshold)
+		if err != nil {
+			fmt.Printf("Unable to remove outliers: %s\n", err)
+			exit(1)
+		}
+		for _, o := range removed {
+			log.Infof("Removed outlier sample %d: features %v", o.Row+1, o.Features)
+		}
+		log.Infof("Removed %d outlier samples, %d samples remain", len(removed), vec.Len())
+		// group keys of removed samples must be removed, too
+		if groups != nil {
+			kept := groups[:0]
+			for i, k := 0, 0; i < len(groups); i++ {
+				if k < len(removed) && removed[k].Row == i {
+					k++
+					continue
+				}
+				kept = append(kept, groups[i])
+			}
+			groups = kept
+		}
+		features, labels = inMx, vec
+	}
+	// network labels start at 1: label map is saved along with the network
+	labelMap := dataset.NewLabelMap(labels.(*mat64.Vector))
+	netLabels, err := labelMap.Encode(labels.(*mat64.Vector))
+	if err != nil {
+		fmt.Printf("Unable to encode labels: %s\n", err)
+		exit(1)
 	}
-	// Create new FEEDFWD network
-	net, err := neural.NewNetwork(config.Network)
+	// cross-validate the network instead of training it if requested
+	if cv > 0 {
+		res, err := crossValidate(config, features.(*mat64.Dense), netLabels)
+		if err != nil {
+			fmt.Printf("Error cross-validating network: %s\n", err)
+			exit(1)
+		}
+		if err := outputs[output](os.Stdout, res); err != nil {
+			fmt.Printf("Could not write cross-validation results: %s\n", err)
+			exit(1)
+		}
+		exit(0)
+	}
+	// train and test on all samples unless split is requested
+	trainInMx, trainLabels := features.(*mat64.Dense), netLabels
+	testInMx, testLabels := trainInMx, trainLabels
+	if split > 0.0 {
+		if groups != nil {
+			trainInMx, trainLabels, testInMx, testLabels, err = dataset.GroupSplit(trainInMx, trainLabels, groups, split)
+		} else {
+			trainInMx, trainLabels, testInMx, testLabels, err = dataset.Split(trainInMx, trainLabels, split, stratify)
+		}
+		if err != nil {
+			fmt.Printf("Unable to split Data Set: %s\n", err)
+			exit(1)
+		}
+	}
+	// if we require features scaling, scale data: scaler is saved along with the network
+	var scaler *dataset.Scaler
+	if scale {
+		if scaler, trainInMx, testInMx, err = scaleSplit(trainInMx, testInMx); err != nil {
+			fmt.Printf("Unable to scale Data Set: %s\n", err)
+			exit(1)
+		}
+	}
+	// Create new FEEDFWD network or load it from the last checkpoint
+	net, err := createNetwork(config.Network)
 	if err != nil {
 		fmt.Printf("Error creating neural network: %s\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+	net.SetScaler(scaler)
+	net.SetLabelMap(labelMap)
+	// display training progress unless quiet output is requested
+	m := new(neural.Monitor)
+	// evaluate the network on held-out samples during training
+	if split > 0.0 {
+		m.ValInMx, m.ValLabels = testInMx, testLabels
+	}
+	if !quiet {
+		m.Callbacks = append(m.Callbacks, prog.update)
+	}
+	// save training checkpoints if requested
+	if checkpointDir != "" {
+		if err := os.MkdirAll(checkpointDir, 0755); err != nil {
+			fmt.Printf("Error creating checkpoint directory: %s\n", err)
+			exit(1)
+		}
+		ckptPath := filepath.Join(checkpointDir, checkpointFile)
+		m.Callbacks = append(m.Callbacks, neural.Checkpoint(net, ckptPath, checkpointEvery))
+	}
+	// write training metrics to file if requested
+	if metricsFile != "" {
+		ml, err := newMetricsLog(metricsFile)
+		if err != nil {
+			fmt.Printf("Error creating metrics file: %s\n", err)
+			exit(1)
+		}
+		openMetrics = ml
+		m.Callbacks = append(m.Callbacks, ml.record)
 	}
+	// stop the training gracefully on interrupt: must run after all other callbacks
+	in := newInterrupter()
+	m.Callbacks = append(m.Callbacks, in.check)
 	// Run neural network training
-	err = net.Train(config.Training, features.(*mat64.Dense), labels.(*mat64.Vector))
+	prog.start(config.Training)
+	history, err := net.TrainMonitored(config.Training, trainInMx, trainLabels, m)
+	prog.done()
+	if err == errInterrupted {
+		// save the network trained so far so the training can be resumed
+		dir := checkpointDir
+		if dir == "" {
+			dir = defaultCheckpointDir
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Printf("Error creating checkpoint directory: %s\n", err)
+			exit(1)
+		}
+		ckpt := neural.Checkpoint(net, filepath.Join(dir, checkpointFile), 0)
+		if err := ckpt(&in.last); err != nil {
+			fmt.Printf("Error saving checkpoint: %s\n", err)
+			exit(1)
+		}
+		fmt.Printf("Training interrupted after %d iterations. Resume it with:\n%s\n",
+			in.last.Iter, resumeCommand(dir))
+		// 130 is the conventional exit code of programs terminated by interrupt
+		exit(130)
+	}
 	if err != nil {
 		fmt.Printf("Error training network: %s\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+	// plot training and validation cost curves if requested
+	if plotFile != "" {
+		if err := writePlot(plotFile, history); err != nil {
+			fmt.Printf("Could not plot training cost: %s\n", err)
+			exit(1)
+		}
 	}
-	// check the success rate i.e. successful number of classifications
-	success, err := net.Validate(features.(*mat64.Dense), labels.(*mat64.Vector))
+	res := new(summary)
+	if len(history) > 0 {
+		res.Iterations = history[len(history)-1].Iter
+		res.Cost = history[len(history)-1].Cost
+	}
+	// check the success rate i.e. successful number of classifications of test samples
+	res.Accuracy, err = net.Validate(testInMx, testLabels)
 	if err != nil {
 		fmt.Printf("Could not calculate success rate: %s\n", err)
-		os.Exit(1)
+		exit(1)
 	}
-	fmt.Printf("\nNeural net accuracy: %f\n", success)
-	// Example of sample classification: in this case it's 1st data sample
-	sample := (features.(*mat64.Dense)).RowView(0).T()
+	// Example of sample classification: in this case it's 1st test sample
+	sample := testInMx.RowView(0).T()
 	classMx, err := net.Classify(sample)
 	if err != nil {
 		fmt.Printf("Could not classify sample: %s\n", err)
+		exit(1)
+	}
+	res.Classification = mat64.Row(nil, 0, classMx)
+	// save the trained network along with its accuracy if requested
+	if modelOut != "" {
+		meta := net.Metadata()
+		meta.Accuracy = res.Accuracy
+		net.SetMetadata(meta)
+		if err := saveModel(modelOut, net); err != nil {
+			fmt.Printf("Could not save trained network: %s\n", err)
+			exit(1)
+		}
+	}
+	if err := outputs[output](os.Stdout, res); err != nil {
+		fmt.Printf("Could not write training results: %s\n", err)
+		exit(1)
+	}
+	if err := closeMetrics(); err != nil {
+		fmt.Printf("Error closing metrics file: %s\n", err)
+		exit(1)
+	}
+	if err := stopProfiling(); err != nil {
+		fmt.Printf("Error writing profiles: %s\n", err)
 		os.Exit(1)
 	}
-	fa := mat64.Formatted(classMx.T(), mat64.Prefix(""))
-	fmt.Printf("\nClassification result:\n% v\n\n", fa)
 }
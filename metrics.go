@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/milosgajdos83/go-neural/neural"
+)
+
+// metricsLog writes training metrics recorded in every training iteration to a file.
+// Metrics are written in CSV format if the file has .csv extension, otherwise they are
+// written in JSON Lines format i.e. one JSON object per line.
+type metricsLog struct {
+	f   *os.File
+	csv *csv.Writer
+	enc *json.Encoder
+}
+
+// newMetricsLog creates new metrics log which writes metrics to the file stored in path.
+// The file is truncated if it already exists.
+func newMetricsLog(path string) (*metricsLog, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &metricsLog{f: f}
+	if filepath.Ext(path) != ".csv" {
+		l.enc = json.NewEncoder(f)
+		return l, nil
+	}
+	l.csv = csv.NewWriter(f)
+	header := []string{"iter", "cost", "grad_norm", "validated", "val_cost", "val_accuracy"}
+	if err := l.csv.Write(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// record writes training metrics to the log file.
+// It implements neural.Callback function.
+func (l *metricsLog) record(m *neural.Metrics) error {
+	if l.enc != nil {
+		return l.enc.Encode(m)
+	}
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	record := []string{
+		strconv.Itoa(m.Iter),
+		formatFloat(m.Cost),
+		formatFloat(m.GradNorm),
+		strconv.FormatBool(m.Validated),
+		formatFloat(m.ValCost),
+		formatFloat(m.ValAccuracy),
+	}
+	if err := l.csv.Write(record); err != nil {
+		return err
+	}
+	// flush every record so the metrics can be inspected while training
+	l.csv.Flush()
+	return l.csv.Error()
+}
+
+// Close closes the log file
+func (l *metricsLog) Close() error {
+	return l.f.Close()
+}
+
+// openMetrics is the metrics log written by the running training, if any.
+// It is closed by closeMetrics because exit does not run deferred calls.
+var openMetrics *metricsLog
+
+// closeMetrics closes the open metrics log; it is safe to call it multiple times
+func closeMetrics() error {
+	if openMetrics == nil {
+		return nil
+	}
+	err := openMetrics.Close()
+	openMetrics = nil
+	return err
+}
@@ -0,0 +1,95 @@
+package neural
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+)
+
+// Builder builds feedforward neural network layer by layer without the need to create
+// network configuration first. Layers are specified via chained method calls:
+//
+//	net, err := neural.NewFeedForward().Input(4).Hidden(25, "sigmoid").Output(3, "softmax").Build()
+//
+// Layer configuration is validated when the network is built.
+type Builder struct {
+	arch *config.NetArch
+	rnd  *rand.Rand
+}
+
+// NewFeedForward creates new Builder of feedforward neural network
+func NewFeedForward() *Builder {
+	return &Builder{arch: &config.NetArch{}}
+}
+
+// Input sets the number of network inputs
+func (b *Builder) Input(size int) *Builder {
+	b.arch.Input = &config.LayerConfig{
+		Kind: "input",
+		Size: size,
+	}
+	return b
+}
+
+// Hidden appends HIDDEN layer with given number of neurons and neuron activation function
+func (b *Builder) Hidden(size int, activation string) *Builder {
+	b.arch.Hidden = append(b.arch.Hidden, &config.LayerConfig{
+		Kind:   "hidden",
+		Size:   size,
+		NeurFn: &config.NeuronConfig{Activation: activation},
+	})
+	return b
+}
+
+// Highway appends highway HIDDEN layer with given neuron activation function.
+// Highway layer has the same number of neurons as the layer it follows.
+func (b *Builder) Highway(activation string) *Builder {
+	size := 0
+	if len(b.arch.Hidden) > 0 {
+		size = b.arch.Hidden[len(b.arch.Hidden)-1].Size
+	} else if b.arch.Input != nil {
+		size = b.arch.Input.Size
+	}
+	b.arch.Hidden = append(b.arch.Hidden, &config.LayerConfig{
+		Kind:    "hidden",
+		Size:    size,
+		NeurFn:  &config.NeuronConfig{Activation: activation},
+		Highway: true,
+	})
+	return b
+}
+
+// Output sets the number of network outputs and their neuron activation function
+func (b *Builder) Output(size int, activation string) *Builder {
+	b.arch.Output = &config.LayerConfig{
+		Kind:   "output",
+		Size:   size,
+		NeurFn: &config.NeuronConfig{Activation: activation},
+	}
+	return b
+}
+
+// Rand sets the source of random numbers used to initialize the network.
+// The default math/rand source is used unless the source is set.
+func (b *Builder) Rand(rnd *rand.Rand) *Builder {
+	b.rnd = rnd
+	return b
+}
+
+// Build creates new network with the specified layers.
+// It fails with error if either INPUT or OUTPUT layer has not been specified
+// or if any of the network layers failed to be created.
+func (b *Builder) Build() (*Network, error) {
+	if b.arch.Input == nil {
+		return nil, fmt.Errorf("Network INPUT layer not specified\n")
+	}
+	if b.arch.Output == nil {
+		return nil, fmt.Errorf("Network OUTPUT layer not specified\n")
+	}
+	c := &config.NetConfig{
+		Kind: "feedfwd",
+		Arch: b.arch,
+	}
+	return NewNetworkWithRand(c, b.rnd)
+}
@@ -0,0 +1,51 @@
+package neural
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	assert := assert.New(t)
+	// correct network
+	n, err := NewFeedForward().Input(4).Hidden(25, "sigmoid").Hidden(10, "relu").Output(3, "softmax").Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.Equal(FEEDFWD, n.Kind())
+	layers := n.Layers()
+	assert.Len(layers, 4)
+	expKinds := []LayerKind{INPUT, HIDDEN, HIDDEN, OUTPUT}
+	expActivations := []string{"", "sigmoid", "relu", "softmax"}
+	expDims := [][2]int{{25, 5}, {10, 26}, {3, 11}}
+	for i, layer := range layers {
+		assert.Equal(expKinds[i], layer.Kind())
+		assert.Equal(expActivations[i], layer.Activation())
+		if i > 0 {
+			rows, cols := layer.Weights().Dims()
+			assert.Equal(expDims[i-1], [2]int{rows, cols})
+		}
+	}
+	// the same source of random numbers builds the same network
+	n1, err := NewFeedForward().Input(4).Output(3, "softmax").Rand(rand.New(rand.NewSource(7))).Build()
+	assert.NoError(err)
+	n2, err := NewFeedForward().Input(4).Output(3, "softmax").Rand(rand.New(rand.NewSource(7))).Build()
+	assert.NoError(err)
+	assert.True(mat64.Equal(n1.Layers()[1].Weights(), n2.Layers()[1].Weights()))
+	// missing layers
+	n, err = NewFeedForward().Hidden(25, "sigmoid").Output(3, "softmax").Build()
+	assert.Nil(n)
+	assert.Error(err)
+	n, err = NewFeedForward().Input(4).Hidden(25, "sigmoid").Build()
+	assert.Nil(n)
+	assert.Error(err)
+	// incorrect layers
+	n, err = NewFeedForward().Input(4).Hidden(0, "sigmoid").Output(3, "softmax").Build()
+	assert.Nil(n)
+	assert.Error(err)
+	n, err = NewFeedForward().Input(4).Output(3, "foobar").Build()
+	assert.Nil(n)
+	assert.Error(err)
+}
@@ -1,10 +1,15 @@
 package neural
 
 import (
+	"math"
+
 	"github.com/gonum/matrix/mat64"
 	"github.com/milosgajdos83/go-neural/pkg/matrix"
 )
 
+// probEps keeps output probabilities away from 0 and 1 so that their logarithms are finite
+const probEps = 1e-15
+
 // Cost is neural network training cost
 type Cost interface {
 	// CostFunc defines neural network cost function for given input, output and labels.
@@ -15,30 +20,36 @@ type Cost interface {
 	Delta(mat64.Matrix, mat64.Matrix) mat64.Matrix
 }
 
+// trainingCost is a cost which can store the output error in a preallocated matrix
+type trainingCost interface {
+	Cost
+	// deltaTo calculates error in the last network layer and stores it in the supplied
+	// destination matrix which must have the same dimensions as the output
+	deltaTo(*mat64.Dense, mat64.Matrix, mat64.Matrix)
+}
+
 // CrossEntropy implements Cost interface
 type CrossEntropy struct{}
 
 // CostFunc implements cross entropy cost function.
 // C = -(sum(sum((out_k .* log(out) + (1 - out_k) .* log(1 - out)), 2)))/samples
+// It does not modify the supplied matrices.
 func (c CrossEntropy) CostFunc(inMx, outMx, labelsMx mat64.Matrix) float64 {
 	// safe switch type as matrix.MakeLabelsMx returns *mat64.Dense
 	lMx := labelsMx.(*mat64.Dense)
 	oMx := outMx.(*mat64.Dense)
-	// out_k .* log(out)
-	costMxA := new(mat64.Dense)
-	costMxA.Apply(matrix.LogMx, oMx)
-	costMxA.MulElem(lMx, costMxA)
-	// (1 - out_k) .* log(1 - out)
-	costMxB := new(mat64.Dense)
-	lMx.Apply(matrix.SubtrMx(1.0), lMx)
-	oMx.Apply(matrix.SubtrMx(1.0), oMx)
-	oMx.Apply(matrix.LogMx, oMx)
-	costMxB.MulElem(labelsMx, oMx)
-	// Cost matrix
-	costMxB.Add(costMxA, costMxB)
+	rows, _ := oMx.Dims()
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		lRow := lMx.RawRowView(i)
+		for j, out := range oMx.RawRowView(i) {
+			out = matrix.Clip(out, probEps, 1-probEps)
+			sum += lRow[j]*math.Log(out) + (1-lRow[j])*math.Log(1-out)
+		}
+	}
 	// calculate the cost
 	samples, _ := inMx.Dims()
-	cost := -(mat64.Sum(costMxB) / float64(samples))
+	cost := -(sum / float64(samples))
 	return cost
 }
 
@@ -46,26 +57,37 @@ func (c CrossEntropy) CostFunc(inMx, outMx, labelsMx mat64.Matrix) float64 {
 // D = (out_k - out)
 func (c CrossEntropy) Delta(outMx, expMx mat64.Matrix) mat64.Matrix {
 	deltaMx := new(mat64.Dense)
-	deltaMx.Sub(outMx, expMx)
+	c.deltaTo(deltaMx, outMx, expMx)
 	return deltaMx
 }
 
+// deltaTo calculates the error of the last layer and stores it in dstMx
+func (c CrossEntropy) deltaTo(dstMx *mat64.Dense, outMx, expMx mat64.Matrix) {
+	dstMx.Sub(outMx, expMx)
+}
+
 // LogLikelihood implements Cost interface
 type LogLikelihood struct{}
 
 // CostFunc implements log-likelihood cost function.
 // C = -sum(sum(out_k.*log(out)))
+// It does not modify the supplied matrices.
 func (c LogLikelihood) CostFunc(inMx, outMx, labelsMx mat64.Matrix) float64 {
 	// safe switch type as matrix.MakeLabelsMx returns *mat64.Dense
 	lMx := labelsMx.(*mat64.Dense)
 	oMx := outMx.(*mat64.Dense)
-	// out_k .* log(out)
-	costMx := new(mat64.Dense)
-	costMx.Apply(matrix.LogMx, oMx)
-	costMx.MulElem(lMx, costMx)
+	rows, _ := oMx.Dims()
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		lRow := lMx.RawRowView(i)
+		for j, out := range oMx.RawRowView(i) {
+			out = matrix.Clip(out, probEps, 1-probEps)
+			sum += lRow[j] * math.Log(out)
+		}
+	}
 	// calculate the cost
 	samples, _ := inMx.Dims()
-	cost := (-mat64.Sum(costMx) / float64(samples))
+	cost := (-sum / float64(samples))
 	return cost
 }
 
@@ -73,6 +95,11 @@ func (c LogLikelihood) CostFunc(inMx, outMx, labelsMx mat64.Matrix) float64 {
 // D = (out_k - out)
 func (c LogLikelihood) Delta(outMx, expMx mat64.Matrix) mat64.Matrix {
 	deltaMx := new(mat64.Dense)
-	deltaMx.Sub(outMx, expMx)
+	c.deltaTo(deltaMx, outMx, expMx)
 	return deltaMx
 }
+
+// deltaTo calculates the error of the last layer and stores it in dstMx
+func (c LogLikelihood) deltaTo(dstMx *mat64.Dense, outMx, expMx mat64.Matrix) {
+	dstMx.Sub(outMx, expMx)
+}
@@ -0,0 +1,24 @@
+package neural
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+)
+
+// createDBNetwork creates deep belief network or fails with error. Deep belief network has
+// the same layers as feedforward network, but its hidden layers form a stack of restricted
+// Boltzmann machines which must be pretrained before the network can be fine-tuned.
+func createDBNetwork(arch *config.NetArch, rnd *rand.Rand) (*Network, error) {
+	// deep belief network needs at least one machine to pretrain
+	if arch != nil && len(arch.Hidden) == 0 {
+		return nil, fmt.Errorf("%s network requires at least one HIDDEN layer\n", DBN)
+	}
+	net, err := createFeedFwdNetwork(arch, rnd)
+	if err != nil {
+		return nil, err
+	}
+	net.kind = DBN
+	return net, nil
+}
@@ -0,0 +1,82 @@
+package neural
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// WriteEmbeddings writes activations of network layer for all samples of data set ds in TSV format
+// of TensorBoard embedding projector, so that the representations learnt by the network can be
+// visualized. vectors receives a row of tab separated activations per sample. metadata receives
+// the predicted label of every sample: if ds is labeled, it also receives the data set label and
+// the rows are preceded by label and predicted header as the projector requires for multiple
+// columns. layer is the network layer index: 1 is the first hidden layer. Samples are preprocessed
+// by the network pipeline and scaled by the network scaler if the network contains them.
+// It fails with error if the layer is not a network layer or if the forward propagation fails.
+func WriteEmbeddings(vectors, metadata io.Writer, n *Network, ds *dataset.DataSet, layer int) error {
+	if ds == nil {
+		return fmt.Errorf("Incorrect data set supplied: %v\n", ds)
+	}
+	classes, err := classLabels(n)
+	if err != nil {
+		return err
+	}
+	if layer < 1 || layer > len(n.Layers())-1 {
+		return fmt.Errorf("Incorrect layer: %d\n", layer)
+	}
+	features, err := preprocess(n, ds.Features())
+	if err != nil {
+		return err
+	}
+	actMx, err := n.ForwardProp(features, layer)
+	if err != nil {
+		return err
+	}
+	// the output is propagated from the layer activations
+	out, last := actMx, len(n.Layers())-1
+	if layer < last {
+		if out, err = n.doForwardProp(actMx, layer+1, last); err != nil {
+			return err
+		}
+	}
+	predLabels, err := matrix.LabelsFromMx(out)
+	if err != nil {
+		return err
+	}
+	vw, mw := csv.NewWriter(vectors), csv.NewWriter(metadata)
+	vw.Comma, mw.Comma = '\t', '\t'
+	labels := ds.Labels()
+	if labels != nil {
+		if err := mw.Write([]string{"label", "predicted"}); err != nil {
+			return err
+		}
+	}
+	samples, units := actMx.Dims()
+	row := make([]string, units)
+	for i := 0; i < samples; i++ {
+		for j := range row {
+			row[j] = strconv.FormatFloat(actMx.At(i, j), 'g', -1, 64)
+		}
+		if err := vw.Write(row); err != nil {
+			return err
+		}
+		meta := []string{strconv.FormatFloat(classes[int(predLabels.At(i, 0))-1], 'f', -1, 64)}
+		if labels != nil {
+			meta = append([]string{strconv.FormatFloat(labels.At(i, 0), 'f', -1, 64)}, meta...)
+		}
+		if err := mw.Write(meta); err != nil {
+			return err
+		}
+	}
+	vw.Flush()
+	mw.Flush()
+	if err := vw.Error(); err != nil {
+		return err
+	}
+	return mw.Error()
+}
@@ -0,0 +1,77 @@
+package neural
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteEmbeddings(t *testing.T) {
+	assert := assert.New(t)
+	n, err := NewFeedForward().Input(4).Hidden(6, "relu").Output(3, "softmax").
+		Rand(rand.New(rand.NewSource(1))).Build()
+	assert.NoError(err)
+	n.SetLabelMap(dataset.LabelMap{10, 20, 30})
+	data := "5.1,3.5,1.4,0.1,20\n" +
+		"4.9,3.0,1.4,0.2,10\n" +
+		"4.7,3.2,1.3,0.3,30\n"
+	dataPath := filepath.Join(os.TempDir(), "embeddings.csv")
+	assert.NoError(ioutil.WriteFile(dataPath, []byte(data), 0666))
+	defer os.Remove(dataPath)
+	ds, err := dataset.NewDataSet(dataPath, true)
+	assert.NoError(err)
+	var vectors, metadata bytes.Buffer
+	assert.NoError(WriteEmbeddings(&vectors, &metadata, n, ds, 1))
+	// vectors contain hidden layer activations of every sample
+	actMx, err := n.ForwardProp(ds.Features(), 1)
+	assert.NoError(err)
+	rows := strings.Split(strings.TrimSpace(vectors.String()), "\n")
+	assert.Len(rows, 3)
+	for i, row := range rows {
+		fields := strings.Split(row, "\t")
+		assert.Len(fields, 6)
+		for j, field := range fields {
+			v, err := strconv.ParseFloat(field, 64)
+			assert.NoError(err)
+			assert.Equal(actMx.At(i, j), v)
+		}
+	}
+	// metadata contain header followed by data set and predicted labels
+	predLabels, _, err := n.Predict(ds.Features())
+	assert.NoError(err)
+	meta := strings.Split(strings.TrimSpace(metadata.String()), "\n")
+	assert.Len(meta, 4)
+	assert.Equal("label\tpredicted", meta[0])
+	for i, line := range meta[1:] {
+		fields := strings.Split(line, "\t")
+		assert.Equal(strconv.FormatFloat(ds.Labels().At(i, 0), 'f', -1, 64), fields[0])
+		assert.Equal(strconv.Itoa(10*int(predLabels.At(i, 0))), fields[1])
+	}
+	// unlabeled data set metadata contain only predicted labels without header
+	data = "5.1,3.5,1.4,0.1\n" +
+		"4.9,3.0,1.4,0.2\n" +
+		"4.7,3.2,1.3,0.3\n"
+	assert.NoError(ioutil.WriteFile(dataPath, []byte(data), 0666))
+	ds, err = dataset.NewDataSet(dataPath, false)
+	assert.NoError(err)
+	vectors.Reset()
+	metadata.Reset()
+	assert.NoError(WriteEmbeddings(&vectors, &metadata, n, ds, 2))
+	meta = strings.Split(strings.TrimSpace(metadata.String()), "\n")
+	assert.Len(meta, 3)
+	assert.Len(strings.Split(meta[0], "\t"), 1)
+	assert.Len(strings.Split(strings.Split(vectors.String(), "\n")[0], "\t"), 3)
+	// incorrect parameters
+	assert.Error(WriteEmbeddings(&vectors, &metadata, n, nil, 1))
+	assert.Error(WriteEmbeddings(&vectors, &metadata, n, ds, 0))
+	assert.Error(WriteEmbeddings(&vectors, &metadata, n, ds, 3))
+	assert.Error(WriteEmbeddings(&vectors, &metadata, nil, ds, 1))
+}
@@ -0,0 +1,174 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+)
+
+// evolutionKind is the name of evolutionary training
+const evolutionKind = "evolution"
+
+// validateEvolutionConfig validates evolutionary training configuration
+func validateEvolutionConfig(c *config.EvolutionConfig) error {
+	// evolution parameters must be supplied
+	if c == nil {
+		return fmt.Errorf("Incorrect evolution configuration supplied: %v\n", c)
+	}
+	// incorrect population size supplied
+	if c.Population < 2 {
+		return fmt.Errorf("Incorrect population size: %d\n", c.Population)
+	}
+	// incorrect number of generations supplied
+	if c.Generations <= 0 {
+		return fmt.Errorf("Incorrect number of generations: %d\n", c.Generations)
+	}
+	// incorrect elite size supplied
+	if c.Elite < 0 || c.Elite >= c.Population {
+		return fmt.Errorf("Incorrect elite size: %d\n", c.Elite)
+	}
+	// incorrect tournament size supplied
+	if c.Tournament <= 0 || c.Tournament > c.Population {
+		return fmt.Errorf("Incorrect tournament size: %d\n", c.Tournament)
+	}
+	// incorrect mutation parameters supplied
+	if c.MutationRate < 0 || c.MutationRate > 1 {
+		return fmt.Errorf("Incorrect mutation rate: %f\n", c.MutationRate)
+	}
+	if c.MutationScale < 0 {
+		return fmt.Errorf("Incorrect mutation scale: %f\n", c.MutationScale)
+	}
+	return nil
+}
+
+// individual is a network weights vector evolved by evolutionary training
+type individual struct {
+	weights []float64
+	cost    float64
+}
+
+// byCost sorts individuals by cost in ascending order; NaN costs are sorted last
+type byCost []*individual
+
+func (p byCost) Len() int      { return len(p) }
+func (p byCost) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p byCost) Less(i, j int) bool {
+	return p[i].cost < p[j].cost || (!math.IsNaN(p[i].cost) && math.IsNaN(p[j].cost))
+}
+
+// tournament returns the individual with the lowest cost out of size randomly chosen individuals
+// of population sorted by cost
+func tournament(population []*individual, size int, rnd *rand.Rand) *individual {
+	best := rnd.Intn(len(population))
+	for i := 1; i < size; i++ {
+		// population is sorted, so lower index means lower cost
+		if j := rnd.Intn(len(population)); j < best {
+			best = j
+		}
+	}
+	return population[best]
+}
+
+// trainEvolution trains the network by evolving a population of network weight vectors.
+// Only the cost function is evaluated, the cost gradient is never calculated, so the cost
+// does not need to be differentiable. The first generation contains the current network
+// weights and their random mutations. Training metrics of the best weights found so far
+// are recorded by rec once per generation. Random numbers are drawn from the source of random
+// numbers the network was created with.
+func (n *Network) trainEvolution(c *config.TrainConfig, inMx *mat64.Dense, labelsVec *mat64.Vector,
+	rec *recorder) (History, error) {
+	e := c.Evolution
+	layers := n.Layers()
+	initWeights := netWeights(layers[1:])
+	ws := new(workspace)
+	rnd := randOrDefault(n.rnd)
+	// evaluate calculates the cost of the individual
+	evaluate := func(ind *individual) error {
+		cost, err := n.getCost(c, ws, ind.weights, inMx, labelsVec)
+		if err != nil {
+			return fmt.Errorf("Training failed: %v\n", err)
+		}
+		ind.cost = cost
+		return nil
+	}
+	// the next generation is bred in a separate population to avoid reallocation
+	population := make([]*individual, e.Population)
+	next := make([]*individual, e.Population)
+	for i := range population {
+		population[i] = &individual{weights: make([]float64, len(initWeights))}
+		next[i] = &individual{weights: make([]float64, len(initWeights))}
+		copy(population[i].weights, initWeights)
+		if i > 0 {
+			for j := range population[i].weights {
+				population[i].weights[j] += rnd.NormFloat64() * e.MutationScale
+			}
+		}
+		if err := evaluate(population[i]); err != nil {
+			return nil, err
+		}
+	}
+	sort.Stable(byCost(population))
+	if err := rec.Init(); err != nil {
+		return nil, err
+	}
+	gen := 0
+	for gen < e.Generations {
+		// the best individuals survive unchanged
+		for i := 0; i < e.Elite; i++ {
+			copy(next[i].weights, population[i].weights)
+			next[i].cost = population[i].cost
+		}
+		// the rest of the generation is bred by uniform crossover and mutation
+		for i := e.Elite; i < e.Population; i++ {
+			mom := tournament(population, e.Tournament, rnd)
+			dad := tournament(population, e.Tournament, rnd)
+			for j := range next[i].weights {
+				next[i].weights[j] = mom.weights[j]
+				if rnd.Intn(2) == 1 {
+					next[i].weights[j] = dad.weights[j]
+				}
+				if rnd.Float64() < e.MutationRate {
+					next[i].weights[j] += rnd.NormFloat64() * e.MutationScale
+				}
+			}
+			if err := evaluate(next[i]); err != nil {
+				return rec.history, err
+			}
+		}
+		population, next = next, population
+		sort.Stable(byCost(population))
+		gen++
+		best := population[0]
+		if c.CheckFinite && (math.IsNaN(best.cost) || math.IsInf(best.cost, 0)) {
+			return rec.history, fmt.Errorf("Non-finite cost: %f\n", best.cost)
+		}
+		netLogger.Debugf("Generation %d cost: %f", gen, best.cost)
+		loc := &optimize.Location{X: best.weights, F: best.cost}
+		stats := &optimize.Stats{MajorIterations: gen}
+		if err := rec.Record(loc, optimize.MajorIteration, stats); err != nil {
+			return rec.history, err
+		}
+	}
+	// population weights are reused, so the network gets a copy of the best weights
+	best := make([]float64, len(initWeights))
+	copy(best, population[0].weights)
+	if err := setNetWeights(layers[1:], best); err != nil {
+		return rec.history, err
+	}
+	netLogger.Infof("Evolved %d generations", gen)
+	n.meta = Metadata{
+		Trained:    time.Now(),
+		Iterations: gen,
+		Cost:       population[0].cost,
+	}
+	if err := n.setAvgWeights(c, rec.swa, inMx, labelsVec); err != nil {
+		return rec.history, err
+	}
+	return rec.history, nil
+}
@@ -0,0 +1,86 @@
+package neural
+
+import (
+	"math"
+	"math/rand"
+	"os"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByCost(t *testing.T) {
+	assert := assert.New(t)
+	population := []*individual{{cost: 2.0}, {cost: math.NaN()}, {cost: 0.5}, {cost: 1.0}}
+	sort.Stable(byCost(population))
+	assert.Equal(0.5, population[0].cost)
+	assert.Equal(1.0, population[1].cost)
+	assert.Equal(2.0, population[2].cost)
+	assert.True(math.IsNaN(population[3].cost))
+}
+
+func TestTrainEvolution(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	evol := config.EvolutionConfig{
+		Population:    20,
+		Generations:   15,
+		Elite:         2,
+		Tournament:    3,
+		MutationRate:  0.2,
+		MutationScale: 0.5,
+	}
+	// incorrect evolution parameters
+	for _, set := range []func(*config.EvolutionConfig){
+		func(c *config.EvolutionConfig) { c.Population = 1 },
+		func(c *config.EvolutionConfig) { c.Generations = 0 },
+		func(c *config.EvolutionConfig) { c.Elite = 20 },
+		func(c *config.EvolutionConfig) { c.Tournament = 0 },
+		func(c *config.EvolutionConfig) { c.MutationRate = 1.5 },
+		func(c *config.EvolutionConfig) { c.MutationScale = -1.0 },
+	} {
+		badEvol := evol
+		set(&badEvol)
+		_, err := n.TrainWithOptions(inMx, labelsVec, WithEvolution(badEvol))
+		assert.Error(err)
+	}
+	// evolution config must be supplied
+	assert.Error(ValidateTrainConfig(&config.TrainConfig{Kind: "evolution", Cost: "xentropy"}))
+	// the best cost never increases thanks to elitism
+	initCost, err := n.getCost(conf.Training, new(workspace), nil, inMx, labelsVec)
+	assert.NoError(err)
+	history, err := n.TrainWithOptions(inMx, labelsVec, WithConfig(conf.Training), WithEvolution(evol))
+	assert.NoError(err)
+	assert.Len(history, 15)
+	prevCost := initCost
+	for i, metrics := range history {
+		assert.Equal(i+1, metrics.Iter)
+		assert.True(metrics.Cost <= prevCost)
+		prevCost = metrics.Cost
+	}
+	// the network is set to the best weights found
+	cost, err := n.getCost(conf.Training, new(workspace), nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.InDelta(history[len(history)-1].Cost, cost, 1e-12)
+	assert.Equal(15, n.Metadata().Iterations)
+	// networks evolved with the same source of random numbers are equal
+	n1, err := NewNetworkWithRand(conf.Network, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	_, err = n1.TrainWithOptions(inMx, labelsVec, WithConfig(conf.Training), WithEvolution(evol))
+	assert.NoError(err)
+	n2, err := NewNetworkWithRand(conf.Network, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	_, err = n2.TrainWithOptions(inMx, labelsVec, WithConfig(conf.Training), WithEvolution(evol))
+	assert.NoError(err)
+	assert.Equal(netWeights(n1.Layers()[1:]), netWeights(n2.Layers()[1:]))
+}
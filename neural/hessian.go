@@ -0,0 +1,35 @@
+package neural
+
+import "github.com/gonum/matrix/mat64"
+
+// hessStep is the step of finite differences used to approximate cost Hessian
+const hessStep = 1e-5
+
+// numHessian approximates the Hessian of the cost at x by central differences of the cost
+// gradient calculated by grad and stores the result in hess. The approximation requires
+// 2*len(x) gradient evaluations so it is only practical for small networks.
+func numHessian(hess mat64.MutableSymmetric, x []float64, grad func(grad, x []float64)) {
+	n := len(x)
+	xh := make([]float64, n)
+	copy(xh, x)
+	gradPlus := make([]float64, n)
+	gradMinus := make([]float64, n)
+	cols := mat64.NewDense(n, n, nil)
+	for j := 0; j < n; j++ {
+		orig := xh[j]
+		xh[j] = orig + hessStep
+		grad(gradPlus, xh)
+		xh[j] = orig - hessStep
+		grad(gradMinus, xh)
+		xh[j] = orig
+		for i := 0; i < n; i++ {
+			cols.Set(i, j, (gradPlus[i]-gradMinus[i])/(2*hessStep))
+		}
+	}
+	// finite differences are not exactly symmetric
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			hess.SetSym(i, j, (cols.At(i, j)+cols.At(j, i))/2)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package neural
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumHessian(t *testing.T) {
+	assert := assert.New(t)
+	// f(x, y) = x^2*y + 3*y^2
+	grad := func(grad, x []float64) {
+		grad[0] = 2 * x[0] * x[1]
+		grad[1] = x[0]*x[0] + 6*x[1]
+	}
+	x := []float64{2.0, -1.0}
+	hess := mat64.NewSymDense(2, nil)
+	numHessian(hess, x, grad)
+	expected := mat64.NewSymDense(2, []float64{-2.0, 4.0, 4.0, 6.0})
+	assert.True(mat64.EqualApprox(expected, hess, 1e-6))
+	// location is not modified
+	assert.Equal([]float64{2.0, -1.0}, x)
+}
+
+func TestTrainNewton(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	trainConf := *conf.Training
+	trainConf.Optimize = &config.OptimConfig{
+		Method:     "newton",
+		Iterations: 5,
+	}
+	history, err := n.TrainMonitored(&trainConf, inMx, labelsVec, nil)
+	assert.NoError(err)
+	assert.NotEmpty(history)
+	assert.True(history[len(history)-1].Cost < history[0].Cost)
+	// Newton method supports line search too
+	trainConf.Optimize.LineSearch = "morethuente"
+	assert.NoError(ValidateTrainConfig(&trainConf))
+}
@@ -0,0 +1,181 @@
+package neural
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+)
+
+// ImportOctave sets network weights to the weights matrices stored in files passed in as parameters.
+// Each file holds weights matrix of one network layer and the files must be supplied in
+// the same order as the network layers, starting with the first HIDDEN layer. This allows to
+// load Theta matrices dumped by Octave/MATLAB via "save -ascii" command: every weights matrix
+// must contain bias weights in its first column. Files with .csv extension are read as CSV files,
+// all the other files are read as whitespace separated ASCII files.
+// ImportOctave fails with error if the number of files does not match the number of network
+// layers with weights, if any of the files can't be read or if any of the weights matrices
+// has different dimensions than the weights matrix of the corresponding network layer.
+func (n *Network) ImportOctave(paths ...string) error {
+	layers := n.Layers()
+	// INPUT layer has no weights
+	if len(paths) != len(layers)-1 {
+		return fmt.Errorf("Expected %d weights files, got: %d\n", len(layers)-1, len(paths))
+	}
+	// read all matrices first so we don't end up with partially updated network
+	weights := make([]*mat64.Dense, len(paths))
+	for i, path := range paths {
+		mx, err := loadWeightsFile(path)
+		if err != nil {
+			return err
+		}
+		// weights dimensions must match
+		r, c := mx.Dims()
+		lr, lc := layers[i+1].weights.Dims()
+		if r != lr || c != lc {
+			return fmt.Errorf("Dimension mismatch. Layer: %d x %d File %s: %d x %d\n",
+				lr, lc, path, r, c)
+		}
+		weights[i] = mx
+	}
+	for i, layer := range layers[1:] {
+		if err := layer.SetWeights(weights[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadWeightsFile loads weights matrix from the file stored in path
+func loadWeightsFile(path string) (*mat64.Dense, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// pick loader based on file extension
+	load := dataset.LoadASCII
+	if filepath.Ext(path) == ".csv" {
+		load = dataset.LoadCSV
+	}
+	return load(f)
+}
+
+// sklearnActivation maps scikit-learn activation names to network activation functions.
+// scikit-learn relu is not leaky, so it maps to plainrelu rather than relu.
+var sklearnActivation = map[string]string{
+	"logistic": "sigmoid",
+	"tanh":     "tanh",
+	"relu":     "plainrelu",
+	"softmax":  "softmax",
+}
+
+// sklearnMLP holds scikit-learn MLPClassifier attributes dumped to JSON
+type sklearnMLP struct {
+	// Activation is the activation of hidden layers
+	Activation string `json:"activation"`
+	// OutActivation is the activation of the output layer
+	OutActivation string `json:"out_activation"`
+	// Coefs contains weights matrices: one inputs x outputs matrix per layer
+	Coefs [][][]float64 `json:"coefs"`
+	// Intercepts contains bias vectors: one per layer
+	Intercepts [][]float64 `json:"intercepts"`
+	// Classes contains class labels sorted in ascending order
+	Classes []float64 `json:"classes,omitempty"`
+}
+
+// ImportSklearn creates feedforward network equivalent to scikit-learn MLPClassifier whose attributes
+// are read from r in JSON format. The JSON object must contain activation, out_activation,
+// coefs and intercepts keys holding the values of the classifier attributes of the same names
+// (out_activation holds out_activation_ etc.) and it may contain classes key which holds numeric
+// class labels used as the network label map. The attributes can be dumped in Python as follows:
+//
+//	json.dump({"activation": clf.activation, "out_activation": clf.out_activation_,
+//	    "coefs": [c.tolist() for c in clf.coefs_], "intercepts": [i.tolist() for i in clf.intercepts_],
+//	    "classes": clf.classes_.tolist()}, f)
+//
+// Only logistic, tanh and relu hidden layers are supported. Binary classifiers with a single logistic output
+// are converted to two softmax outputs which produce the same class probabilities.
+// It fails with error if the JSON can't be decoded or if it does not describe a valid network.
+func ImportSklearn(r io.Reader) (*Network, error) {
+	m := new(sklearnMLP)
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	if len(m.Coefs) == 0 || len(m.Coefs) != len(m.Intercepts) {
+		return nil, fmt.Errorf("Incorrect number of layers. Coefs: %d, Intercepts: %d\n",
+			len(m.Coefs), len(m.Intercepts))
+	}
+	hiddenAct, ok := sklearnActivation[m.Activation]
+	if !ok || hiddenAct == "softmax" {
+		return nil, fmt.Errorf("Unsupported hidden activation: %s\n", m.Activation)
+	}
+	outAct, ok := sklearnActivation[m.OutActivation]
+	if !ok || (outAct != "sigmoid" && outAct != "softmax") {
+		return nil, fmt.Errorf("Unsupported output activation: %s\n", m.OutActivation)
+	}
+	// weights matrices are transposed and prepended with bias column
+	weights := make([]*mat64.Dense, len(m.Coefs))
+	in := len(m.Coefs[0])
+	arch := &config.NetArch{Input: &config.LayerConfig{Kind: "input", Size: in}}
+	for i, coefs := range m.Coefs {
+		if len(coefs) != in || len(coefs) == 0 {
+			return nil, fmt.Errorf("Incorrect layer %d inputs: %d, expected: %d\n", i+1, len(coefs), in)
+		}
+		out := len(m.Intercepts[i])
+		w := mat64.NewDense(out, in+1, nil)
+		for k := 0; k < out; k++ {
+			w.Set(k, 0, m.Intercepts[i][k])
+		}
+		for j, row := range coefs {
+			if len(row) != out {
+				return nil, fmt.Errorf("Incorrect layer %d outputs: %d, expected: %d\n", i+1, len(row), out)
+			}
+			for k, coef := range row {
+				w.Set(k, j+1, coef)
+			}
+		}
+		lc := &config.LayerConfig{Kind: "hidden", Size: out, NeurFn: &config.NeuronConfig{Activation: hiddenAct}}
+		if i == len(m.Coefs)-1 {
+			lc.Kind = "output"
+			lc.NeurFn.Activation = outAct
+			arch.Output = lc
+		} else {
+			arch.Hidden = append(arch.Hidden, lc)
+		}
+		weights[i] = w
+		in = out
+	}
+	// softmax of (0, z) is (1 - sigmoid(z), sigmoid(z))
+	if outAct == "sigmoid" && in == 1 {
+		w := weights[len(weights)-1]
+		_, cols := w.Dims()
+		binary := mat64.NewDense(2, cols, nil)
+		binary.SetRow(1, w.RawRowView(0))
+		weights[len(weights)-1] = binary
+		arch.Output.Size = 2
+		arch.Output.NeurFn.Activation = "softmax"
+	}
+	n, err := NewNetwork(&config.NetConfig{Kind: "feedfwd", Arch: arch})
+	if err != nil {
+		return nil, err
+	}
+	for i, layer := range n.Layers()[1:] {
+		if err := layer.SetWeights(weights[i]); err != nil {
+			return nil, err
+		}
+	}
+	if len(m.Classes) > 0 {
+		outs, _ := weights[len(weights)-1].Dims()
+		if len(m.Classes) != outs {
+			return nil, fmt.Errorf("Incorrect number of classes: %d, outputs: %d\n", len(m.Classes), outs)
+		}
+		n.SetLabelMap(dataset.LabelMap(m.Classes))
+	}
+	return n, nil
+}
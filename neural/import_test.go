@@ -0,0 +1,141 @@
+package neural
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportOctave(t *testing.T) {
+	assert := assert.New(t)
+	// create new network: 4 inputs, 5 hidden, 5 output neurons
+	tmpPath := path.Join(os.TempDir(), fileName)
+	c, err := config.New(tmpPath)
+	assert.NotNil(c)
+	assert.NoError(err)
+	n, err := NewNetwork(c.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// Theta1 stored in Octave ASCII format
+	theta1 := "# name: Theta1\n" +
+		"1 2 3 4 5\n" +
+		"6 7 8 9 10\n" +
+		"11 12 13 14 15\n" +
+		"16 17 18 19 20\n" +
+		"21 22 23 24 25\n"
+	theta1Path := filepath.Join(os.TempDir(), "theta1.txt")
+	assert.NoError(ioutil.WriteFile(theta1Path, []byte(theta1), 0666))
+	defer os.Remove(theta1Path)
+	// Theta2 stored in CSV format
+	theta2 := "1,2,3,4,5,6\n" +
+		"1,2,3,4,5,6\n" +
+		"1,2,3,4,5,6\n" +
+		"1,2,3,4,5,6\n" +
+		"1,2,3,4,5,6\n"
+	theta2Path := filepath.Join(os.TempDir(), "theta2.csv")
+	assert.NoError(ioutil.WriteFile(theta2Path, []byte(theta2), 0666))
+	defer os.Remove(theta2Path)
+	// import weights
+	err = n.ImportOctave(theta1Path, theta2Path)
+	assert.NoError(err)
+	layers := n.Layers()
+	assert.Equal(7.0, layers[1].Weights().At(1, 1))
+	assert.Equal(6.0, layers[2].Weights().At(4, 5))
+	// incorrect number of files
+	err = n.ImportOctave(theta1Path)
+	assert.Error(err)
+	// nonexistent file
+	err = n.ImportOctave(theta1Path, "nonexistent.txt")
+	assert.Error(err)
+	// dimension mismatch leaves the network untouched
+	origWeights := mat64.DenseCopyOf(layers[1].Weights())
+	err = n.ImportOctave(theta1Path, theta1Path)
+	assert.Error(err)
+	assert.True(mat64.Equal(origWeights, layers[1].Weights()))
+}
+
+func TestImportSklearn(t *testing.T) {
+	assert := assert.New(t)
+	// 2 inputs, 2 logistic hidden neurons, 3 softmax outputs
+	mlp := `{"activation": "logistic", "out_activation": "softmax",
+		"coefs": [[[1, -1], [2, 0.5]], [[1, 0, -1], [0.5, 2, 0]]],
+		"intercepts": [[0.1, -0.2], [0, 0.3, -0.3]],
+		"classes": [2, 5, 7]}`
+	n, err := ImportSklearn(strings.NewReader(mlp))
+	assert.NotNil(n)
+	assert.NoError(err)
+	layers := n.Layers()
+	assert.Len(layers, 3)
+	assert.Equal("sigmoid", layers[1].Activation())
+	assert.Equal("softmax", layers[2].Activation())
+	assert.Equal(dataset.LabelMap{2, 5, 7}, n.LabelMap())
+	// weights are transposed and prepended with bias
+	assert.True(mat64.Equal(mat64.NewDense(2, 3, []float64{0.1, 1, 2, -0.2, -1, 0.5}), layers[1].Weights()))
+	assert.True(mat64.Equal(mat64.NewDense(3, 3, []float64{0, 1, 0.5, 0.3, 0, 2, -0.3, -1, 0}), layers[2].Weights()))
+	// forward propagation matches scikit-learn
+	x := []float64{0.5, -1.0}
+	h := []float64{
+		matrix.Sigmoid(0.1 + 1*x[0] + 2*x[1]),
+		matrix.Sigmoid(-0.2 - 1*x[0] + 0.5*x[1]),
+	}
+	z := []float64{0 + h[0] + 0.5*h[1], 0.3 + 2*h[1], -0.3 - h[0]}
+	sum := math.Exp(z[0]) + math.Exp(z[1]) + math.Exp(z[2])
+	// Classify returns probabilities in percents
+	out, err := n.Classify(mat64.NewDense(1, 2, x))
+	assert.NoError(err)
+	for k := range z {
+		assert.InDelta(100*math.Exp(z[k])/sum, out.At(0, k), 1e-9)
+	}
+	// binary classifier is converted to two softmax outputs
+	binary := `{"activation": "tanh", "out_activation": "logistic",
+		"coefs": [[[1], [2]], [[-1.5]]], "intercepts": [[0.5], [0.2]]}`
+	n, err = ImportSklearn(strings.NewReader(binary))
+	assert.NotNil(n)
+	assert.NoError(err)
+	out, err = n.Classify(mat64.NewDense(1, 2, x))
+	assert.NoError(err)
+	p := matrix.Sigmoid(0.2 - 1.5*math.Tanh(0.5+x[0]+2*x[1]))
+	assert.InDelta(100*(1-p), out.At(0, 0), 1e-9)
+	assert.InDelta(100*p, out.At(0, 1), 1e-9)
+	// relu hidden layers are not leaky
+	relu := `{"activation": "relu", "out_activation": "softmax",
+		"coefs": [[[1, -1], [2, 0.5]], [[1, 0], [0.5, 2]]], "intercepts": [[0.1, -0.2], [0, 0.3]]}`
+	n, err = ImportSklearn(strings.NewReader(relu))
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.Equal("plainrelu", n.Layers()[1].Activation())
+	out, err = n.Classify(mat64.NewDense(1, 2, x))
+	assert.NoError(err)
+	h = []float64{math.Max(0.1+1*x[0]+2*x[1], 0), math.Max(-0.2-1*x[0]+0.5*x[1], 0)}
+	z = []float64{0 + h[0] + 0.5*h[1], 0.3 + 2*h[1]}
+	sum = math.Exp(z[0]) + math.Exp(z[1])
+	for k := range z {
+		assert.InDelta(100*math.Exp(z[k])/sum, out.At(0, k), 1e-9)
+	}
+	// invalid classifiers
+	invalid := []string{
+		`{"activation": "logistic"`,
+		`{"activation": "identity", "out_activation": "softmax", "coefs": [[[1]]], "intercepts": [[0]]}`,
+		`{"activation": "logistic", "out_activation": "relu", "coefs": [[[1]]], "intercepts": [[0]]}`,
+		`{"activation": "logistic", "out_activation": "identity", "coefs": [[[1]]], "intercepts": [[0]]}`,
+		`{"activation": "logistic", "out_activation": "softmax", "coefs": [[[1]]], "intercepts": []}`,
+		`{"activation": "logistic", "out_activation": "softmax", "coefs": [[[1, 2]], [[1]]], "intercepts": [[0, 0], [0]]}`,
+		`{"activation": "logistic", "out_activation": "softmax", "coefs": [[[1, 2]]], "intercepts": [[0]]}`,
+		`{"activation": "logistic", "out_activation": "softmax", "coefs": [[[1, 2]]], "intercepts": [[0, 0]], "classes": [1]}`,
+	}
+	for _, mlp := range invalid {
+		n, err := ImportSklearn(strings.NewReader(mlp))
+		assert.Nil(n)
+		assert.Error(err)
+	}
+}
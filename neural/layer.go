@@ -2,7 +2,11 @@ package neural
 
 import (
 	"fmt"
+	"math"
+	"math/rand"
 
+	"github.com/gonum/blas"
+	"github.com/gonum/blas/blas64"
 	"github.com/gonum/matrix/mat64"
 	"github.com/milosgajdos83/go-neural/pkg/config"
 	"github.com/milosgajdos83/go-neural/pkg/helpers"
@@ -18,6 +22,9 @@ const (
 	OUTPUT
 )
 
+// highwayGateBias is the initial bias of highway layer gates
+const highwayGateBias = -1.0
+
 // ActivFunc defines a neuron activation function
 type ActivFunc func(int, int, float64) float64
 
@@ -39,6 +46,49 @@ var activations = map[string]map[string]ActivFunc{
 		"act":  matrix.ReluMx,
 		"grad": matrix.ReluGradMx,
 	},
+	"plainrelu": {
+		"act":  matrix.PlainReluMx,
+		"grad": matrix.PlainReluGradMx,
+	},
+	"softsign": {
+		"act":  matrix.SoftsignMx,
+		"grad": matrix.SoftsignGradMx,
+	},
+}
+
+// sliceActivations maps activation function names to their implementations which
+// process whole matrix rows at once and are used during network training
+var sliceActivations = map[string]map[string]matrix.SliceFunc{
+	"sigmoid": {
+		"act":  matrix.SigmoidSlice,
+		"grad": matrix.SigmoidGradSlice,
+	},
+	"softmax": {
+		"act":  matrix.ExpSlice,
+		"grad": matrix.SigmoidGradSlice,
+	},
+	"tanh": {
+		"act":  matrix.TanhSlice,
+		"grad": matrix.TanhGradSlice,
+	},
+	"relu": {
+		"act":  matrix.ReluSlice,
+		"grad": matrix.ReluGradSlice,
+	},
+	"plainrelu": {
+		"act":  matrix.PlainReluSlice,
+		"grad": matrix.PlainReluGradSlice,
+	},
+	"softsign": {
+		"act":  matrix.SoftsignSlice,
+		"grad": matrix.SoftsignGradSlice,
+	},
+}
+
+// weightInits maps weight initialization names to the distributions of initial layer weights
+var weightInits = map[string]matrix.Dist{
+	"xavier": matrix.Xavier,
+	"he":     matrix.He,
 }
 
 // layerKind maps string representations to LayerKind
@@ -72,22 +122,49 @@ type Layer struct {
 	id string
 	// kind is layer kind: input, hidden or output
 	kind LayerKind
-	// weights matrix holds layer neuron weights per row
+	// weights matrix holds layer neuron weights per row. Highway layer weights contain
+	// transform weights in the first half of rows and gate weights in the second half.
 	weights *mat64.Dense
+	// highway is true if the layer is a highway layer
+	highway bool
+	// sparse holds pruned weights in sparse format used in forward propagation
+	sparse *sparseMx
 	// deltas matrix holds output deltas used for backprop
 	deltas *mat64.Dense
 	// act is neuron activation function
 	act ActivFunc
 	// actGrad is derivation of neuron activation function
 	actGrad ActivFunc
+	// actSlice is neuron activation function applied to whole matrix rows
+	actSlice matrix.SliceFunc
+	// gradSlice is derivation of neuron activation function applied to whole matrix rows
+	gradSlice matrix.SliceFunc
 	// meta contains layer metadata: currently only info about OUT ActFn
 	meta string
 }
 
 // NewLayer creates a new neural network layer and returns it.
-// Layer weights are initialized to uniformly distributed random values (-1,1)
-// NewLayer fails with error if the neural network supplied as a parameter does not exist.
+// Layer weights are initialized to random values drawn from the default math/rand source.
+// Their distribution is selected by the layer configuration: Xavier uniform distribution is used
+// by default, He normal distribution suits relu layers better.
+// NewLayer fails with error if the layer configuration is invalid.
 func NewLayer(c *config.LayerConfig, layerIn int) (*Layer, error) {
+	return NewLayerWithRand(c, layerIn, nil)
+}
+
+// NewLayerWithRand creates a new neural network layer the same way as NewLayer does, but it draws
+// layer id and initial weights from rnd. If rnd is nil, the default math/rand source is used.
+// Layer id is only random if the layer configuration does not contain any id.
+func NewLayerWithRand(c *config.LayerConfig, layerIn int, rnd *rand.Rand) (*Layer, error) {
+	id := c.ID
+	if id == "" {
+		id = helpers.PseudoRandStringWithRand(rnd, 10)
+	}
+	return newLayer(c, layerIn, rnd, id)
+}
+
+// newLayer creates a new neural network layer with given id and weights drawn from rnd
+func newLayer(c *config.LayerConfig, layerIn int, rnd *rand.Rand, id string) (*Layer, error) {
 	// layer in must be positive integer
 	if layerIn <= 0 {
 		return nil, fmt.Errorf("Layer input must be positive integer: %d\n", layerIn)
@@ -101,7 +178,7 @@ func NewLayer(c *config.LayerConfig, layerIn int) (*Layer, error) {
 		return nil, fmt.Errorf("Invalid layer kind requested: %s", c.Kind)
 	}
 	layer := &Layer{}
-	layer.id = helpers.PseudoRandString(10)
+	layer.id = id
 	layer.kind = layerKind[c.Kind]
 	// INPUT layer has neither weights matrix nor activation funcs
 	if layer.kind != INPUT {
@@ -111,24 +188,59 @@ func NewLayer(c *config.LayerConfig, layerIn int) (*Layer, error) {
 			return nil, fmt.Errorf("Unsupported activation function: %s\n",
 				c.NeurFn.Activation)
 		}
+		sliceFunc := sliceActivations[c.NeurFn.Activation]
 		// set activation functions
 		layer.act = activFunc["act"]
-		// if tanh - needs to be rescaled if used in OUTPUT layer
+		layer.actSlice = sliceFunc["act"]
+		// if tanh or softsign - needs to be rescaled if used in OUTPUT layer
 		if c.NeurFn.Activation == "tanh" {
 			if layer.kind == OUTPUT {
 				layer.act = matrix.TanhOutMx
+				layer.actSlice = matrix.TanhOutSlice
+			}
+		}
+		if c.NeurFn.Activation == "softsign" {
+			if layer.kind == OUTPUT {
+				layer.act = matrix.SoftsignOutMx
+				layer.actSlice = matrix.SoftsignOutSlice
 			}
 		}
 
 		layer.actGrad = activFunc["grad"]
+		layer.gradSlice = sliceFunc["grad"]
 		layer.meta = c.NeurFn.Activation
 		layerOut := c.Size
-		// initialize weights to random values
+		// highway layer has both transform and gate weights
+		if c.Highway {
+			if layer.kind != HIDDEN || c.NeurFn.Activation == "softmax" {
+				return nil, fmt.Errorf("Unsupported highway layer: %s %s\n", layer.kind, c.NeurFn.Activation)
+			}
+			if layerIn != c.Size {
+				return nil, fmt.Errorf("Highway layer size %d does not match its input: %d\n", c.Size, layerIn)
+			}
+			layer.highway = true
+			layerOut = 2 * c.Size
+		}
+		// initialize weights to random values drawn from the requested distribution
+		init := c.Init
+		if init == "" {
+			init = "xavier"
+		}
+		dist, ok := weightInits[init]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported weight initialization: %s\n", c.Init)
+		}
 		var err error
-		layer.weights, err = matrix.MakeRandMx(layerOut, layerIn+1, 0.0, 1.0)
+		layer.weights, err = matrix.MakeRandMxFrom(dist, matrix.DistParams{}, layerOut, layerIn+1, rnd)
 		if err != nil {
 			return nil, err
 		}
+		// negative gate bias makes the layer carry its input at the beginning of the training
+		if layer.highway {
+			for i := c.Size; i < layerOut; i++ {
+				layer.weights.Set(i, 0, highwayGateBias)
+			}
+		}
 		// initializes deltas to zero values
 		layer.deltas = mat64.NewDense(layerOut, layerIn+1, nil)
 	}
@@ -145,12 +257,62 @@ func (l Layer) Kind() LayerKind {
 	return l.kind
 }
 
-// Weights returns layer's eights matrix
+// Highway returns true if the layer is a highway layer
+func (l Layer) Highway() bool {
+	return l.highway
+}
+
+// size returns the number of layer outputs
+func (l Layer) size() int {
+	rows, _ := l.weights.Dims()
+	if l.highway {
+		return rows / 2
+	}
+	return rows
+}
+
+// Activation returns the name of layer neuron activation function.
+// INPUT layer has no activation function: it returns empty string.
+func (l Layer) Activation() string {
+	return l.meta
+}
+
+// Weights returns a copy of layer weights matrix. Modifying the returned matrix
+// does not change the layer weights: use SetWeights to change them.
+// INPUT layer has no weights matrix: it returns nil.
 func (l *Layer) Weights() *mat64.Dense {
-	return l.weights
+	if l.weights == nil {
+		return nil
+	}
+	return mat64.DenseCopyOf(l.weights)
+}
+
+// WeightsView returns read-only view of layer weights matrix which does not copy the weights.
+// The view always reflects the current layer weights, so it must not be read while the layer
+// weights are being changed e.g. during training. INPUT layer has no weights matrix: it returns nil.
+func (l *Layer) WeightsView() mat64.Matrix {
+	if l.weights == nil {
+		return nil
+	}
+	return weightsView{l}
 }
 
-// SetWeights allows to set neural network layer weights.
+// weightsView is a read-only view of layer weights matrix
+type weightsView struct {
+	l *Layer
+}
+
+// Dims returns the dimensions of the weights matrix
+func (v weightsView) Dims() (int, int) { return v.l.weights.Dims() }
+
+// At returns the weight at row i, column j
+func (v weightsView) At(i, j int) float64 { return v.l.weights.At(i, j) }
+
+// T returns the transpose of the weights matrix
+func (v weightsView) T() mat64.Matrix { return mat64.Transpose{Matrix: v} }
+
+// SetWeights sets neural network layer weights to a copy of the supplied weights matrix:
+// modifying the supplied matrix later does not change the layer weights.
 // It fails with error if either the supplied weights have different dimensions
 // than the existing layer weights or if the passed in weights matrix is nil
 // or if the layer is an INPUT layer: INPUT layer has no weights matrix.
@@ -170,18 +332,57 @@ func (l *Layer) SetWeights(w *mat64.Dense) error {
 		return fmt.Errorf("Dimension mismatch. Current: %d x %d Supplied: %d x %d\n",
 			lr, lc, wr, wc)
 	}
-	l.weights = w
+	l.weights = mat64.DenseCopyOf(w)
+	// sparse weights are no longer valid
+	l.sparse = nil
 	// We must re-allocate deltas too
 	deltas := mat64.NewDense(wr, wc, nil)
 	l.deltas = deltas
 	return nil
 }
 
-// Deltas returns layer's output deltas matrix
+// Deltas returns a copy of layer's output deltas matrix
 // Deltas matrix is initialized to zeros and is only non-zero if the back propagation
-// algorithm has been run.
+// algorithm has been run. INPUT layer has no deltas matrix: it returns nil.
 func (l *Layer) Deltas() *mat64.Dense {
-	return l.deltas
+	if l.deltas == nil {
+		return nil
+	}
+	return mat64.DenseCopyOf(l.deltas)
+}
+
+// Prune sets all layer weights whose absolute value is smaller than threshold to zero
+// and switches the layer to sparse weights representation which speeds up forward propagation
+// of heavily pruned layers. Bias weights are never pruned. Sparse representation is dropped
+// when the layer weights are changed. Prune returns the number of pruned weights.
+// It fails with error if the layer is an INPUT layer or if the threshold is negative.
+func (l *Layer) Prune(threshold float64) (int, error) {
+	// INPUT layer has no weights
+	if l.kind == INPUT {
+		return 0, fmt.Errorf("Can't prune weights of %s layer\n", l.kind)
+	}
+	if threshold < 0 {
+		return 0, fmt.Errorf("Incorrect pruning threshold: %f\n", threshold)
+	}
+	pruned := 0
+	rows, _ := l.weights.Dims()
+	for i := 0; i < rows; i++ {
+		// skip bias weights
+		row := l.weights.RawRowView(i)[1:]
+		for j := range row {
+			if row[j] != 0.0 && math.Abs(row[j]) < threshold {
+				row[j] = 0.0
+				pruned++
+			}
+		}
+	}
+	l.sparse = newSparseMx(l.weights)
+	return pruned, nil
+}
+
+// Sparse returns true if the layer uses sparse weights representation
+func (l *Layer) Sparse() bool {
+	return l.sparse != nil
 }
 
 // FwdOut calculates forward output of the network layer for given input.
@@ -195,28 +396,95 @@ func (l *Layer) FwdOut(inputMx mat64.Matrix) (mat64.Matrix, error) {
 	if l.kind == INPUT {
 		return inputMx, nil
 	}
+	_, out, err := l.fwdOut(inputMx)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// fwdOut calculates forward output of non-INPUT network layer for given input.
+// It returns both the layer activation inputs (i.e. pre-activation matrix) and the layer output.
+func (l *Layer) fwdOut(inputMx mat64.Matrix) (*mat64.Dense, *mat64.Dense, error) {
 	// input column dimensions + bias must match the weights column dimensions
 	inRows, inCols := inputMx.Dims()
 	_, wCols := l.weights.Dims()
 	if inCols+1 != wCols {
-		return nil, fmt.Errorf("Dimension mismatch. Weight: %d, Input: %d\n", wCols, inCols)
+		return nil, nil, fmt.Errorf("Dimension mismatch. Weight: %d, Input: %d\n", wCols, inCols)
 	}
 	// add bias to input
 	biasInMx := matrix.AddBias(inputMx)
+	wRows, _ := l.weights.Dims()
+	actInMx := mat64.NewDense(inRows, wRows, nil)
+	out := mat64.NewDense(inRows, l.size(), nil)
+	if err := l.activate(biasInMx, actInMx, out); err != nil {
+		return nil, nil, err
+	}
+	return actInMx, out, nil
+}
+
+// activate activates layer neurons for given input which already contains bias in its first column.
+// It stores the activation inputs in actInMx and the layer output in outMx. Both matrices must be
+// allocated so that neither of them needs to be reallocated. It returns error if the matrices
+// dimensions don't match the layer.
+func (l *Layer) activate(biasInMx, actInMx, outMx *mat64.Dense) error {
 	// calculate activation function inputs
-	out := new(mat64.Dense)
-	out.Mul(biasInMx, l.weights.T())
+	if l.sparse != nil {
+		l.sparse.mulT(actInMx, biasInMx)
+	} else {
+		// raw matrices avoid allocating transposed view of the weights
+		blas64.Gemm(blas.NoTrans, blas.Trans, 1.0, biasInMx.RawMatrix(),
+			l.weights.RawMatrix(), 0.0, actInMx.RawMatrix())
+	}
 	// activate layer neurons
-	out.Apply(l.act, out)
+	if l.highway {
+		l.highwayOut(biasInMx, actInMx, outMx)
+		return nil
+	}
 	if l.meta == "softmax" {
-		rowSums := matrix.RowSums(out)
-		for i := 0; i < inRows; i++ {
-			rowVec := out.RowView(i)
-			rowVec.ScaleVec(1/rowSums[i], rowVec)
-			out.SetRow(i, rowVec.RawVector().Data)
+		return matrix.ApplySoftmaxRows(outMx, actInMx)
+	}
+	return matrix.ApplySlice(l.actSlice, outMx, actInMx)
+}
+
+// highwayOut calculates highway layer output from its activation inputs: the output is a sum of
+// the transformed input T*H(x) and the carried input (1-T)*x where T is the output of the sigmoid gate
+func (l *Layer) highwayOut(biasInMx, actInMx, outMx *mat64.Dense) {
+	rows, size := outMx.Dims()
+	for i := 0; i < rows; i++ {
+		actIn := actInMx.RawRowView(i)
+		x := biasInMx.RawRowView(i)[1:]
+		out := outMx.RawRowView(i)
+		l.actSlice(out, actIn[:size])
+		for j := range out {
+			t := matrix.Sigmoid(actIn[size+j])
+			out[j] = t*out[j] + (1-t)*x[j]
+		}
+	}
+}
+
+// highwayErr calculates errors of highway layer activation inputs from the error of the layer output
+// stored in outErrMx. It stores transform errors in the first half of errMx columns and gate errors in
+// the second half and it overwrites outErrMx with the error carried to the layer input. Activation inputs
+// are not needed afterwards so they are overwritten by the transform gradient to avoid allocation.
+func (l *Layer) highwayErr(errMx, outErrMx, actInMx, inMx *mat64.Dense) {
+	rows, size := outErrMx.Dims()
+	for i := 0; i < rows; i++ {
+		actIn := actInMx.RawRowView(i)
+		x := inMx.RawRowView(i)
+		outErr := outErrMx.RawRowView(i)
+		actErr := errMx.RawRowView(i)
+		// transform errors temporarily hold the transform output
+		l.actSlice(actErr[:size], actIn[:size])
+		l.gradSlice(actIn[:size], actIn[:size])
+		for j := 0; j < size; j++ {
+			t := matrix.Sigmoid(actIn[size+j])
+			h := actErr[j]
+			actErr[j] = outErr[j] * t * actIn[j]
+			actErr[size+j] = outErr[j] * (h - x[j]) * t * (1 - t)
+			outErr[j] *= 1 - t
 		}
 	}
-	return out, nil
 }
 
 // ActFn returns layer activation function
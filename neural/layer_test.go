@@ -1,6 +1,8 @@
 package neural
 
 import (
+	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/gonum/matrix/mat64"
@@ -70,7 +72,46 @@ func TestNewLayer(t *testing.T) {
 		tstLayer, err := NewLayer(c, 10)
 		assert.NotNil(tstLayer)
 		assert.NoError(err)
+		if lKind == "input" {
+			assert.Equal("", tstLayer.Activation())
+		} else {
+			assert.Equal("tanh", tstLayer.Activation())
+		}
 	}
+	// softsign is rescaled to (0, 1) in OUTPUT layer
+	c.NeurFn.Activation = "softsign"
+	c.Kind = "output"
+	tstLayer, err = NewLayer(c, 10)
+	assert.NotNil(tstLayer)
+	assert.NoError(err)
+	assert.Equal("softsign", tstLayer.Activation())
+	inMx := mat64.NewDense(1, 11, []float64{1, -5, -5, -5, -5, -5, -5, -5, -5, -5, -5})
+	outMx := new(mat64.Dense)
+	outMx.Mul(inMx, tstLayer.Weights().T())
+	outMx.Apply(tstLayer.act, outMx)
+	for _, x := range outMx.RawRowView(0) {
+		assert.True(x > 0 && x < 1)
+	}
+	// Xavier weights are bounded by the layer dimensions
+	c.NeurFn.Activation = "relu"
+	c.Kind = "hidden"
+	xavier, err := NewLayerWithRand(c, 10, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	limit := math.Sqrt(6.0 / float64(10+11))
+	for i := 0; i < 10; i++ {
+		for _, w := range xavier.Weights().RawRowView(i) {
+			assert.True(math.Abs(w) <= limit)
+		}
+	}
+	// weight initialization can be selected
+	c.Init = "he"
+	he, err := NewLayerWithRand(c, 10, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	assert.False(mat64.Equal(xavier.Weights(), he.Weights()))
+	c.Init = "foo"
+	tstLayer, err = NewLayer(c, 10)
+	assert.Nil(tstLayer)
+	assert.Error(err)
 }
 
 func TestIDAndKind(t *testing.T) {
@@ -157,6 +198,25 @@ func TestSetWeights(t *testing.T) {
 	assert.Equal(twCols, wCols)
 	assert.Equal(tdRows, wRows)
 	assert.Equal(tdCols, wCols)
+	// supplied weights are copied
+	weights.Set(0, 0, 10.0)
+	assert.Equal(0.0, tstLayer.Weights().At(0, 0))
+	// returned weights and deltas are copies
+	tstLayer.Weights().Set(0, 0, 10.0)
+	tstLayer.Deltas().Set(0, 0, 10.0)
+	assert.Equal(0.0, tstLayer.Weights().At(0, 0))
+	assert.Equal(0.0, tstLayer.Deltas().At(0, 0))
+	// read-only view reflects the layer weights
+	view := tstLayer.WeightsView()
+	vRows, vCols := view.Dims()
+	assert.Equal(wRows, vRows)
+	assert.Equal(wCols, vCols)
+	_, ok := view.(*mat64.Dense)
+	assert.False(ok)
+	weights.Set(1, 2, 5.0)
+	assert.NoError(tstLayer.SetWeights(weights))
+	assert.Equal(5.0, view.At(1, 2))
+	assert.Equal(5.0, view.T().At(2, 1))
 }
 
 func TestFwdOut(t *testing.T) {
@@ -217,3 +277,59 @@ func TestFwdOut(t *testing.T) {
 	assert.NoError(err)
 	assert.True(mat64.EqualApprox(out, expOut, 0.001))
 }
+
+func TestPrune(t *testing.T) {
+	assert := assert.New(t)
+
+	// test configuration
+	c := &config.LayerConfig{
+		Kind: "input",
+		Size: 2,
+		NeurFn: &config.NeuronConfig{
+			Activation: "sigmoid",
+		},
+	}
+	// INPUT layer can't be pruned
+	inputLayer, err := NewLayer(c, 3)
+	assert.NotNil(inputLayer)
+	assert.NoError(err)
+	pruned, err := inputLayer.Prune(0.5)
+	assert.Equal(0, pruned)
+	assert.Error(err)
+	// HIDDEN layer
+	c.Kind = "hidden"
+	hiddenLayer, err := NewLayer(c, 3)
+	assert.NotNil(hiddenLayer)
+	assert.NoError(err)
+	weights := mat64.NewDense(2, 4, []float64{
+		0.1, 0.2, -0.3, 1.0,
+		-2.0, 0.4, -0.05, 0.6,
+	})
+	err = hiddenLayer.SetWeights(weights)
+	assert.NoError(err)
+	inMx := mat64.NewDense(2, 3, []float64{1.0, 2.0, 3.0, -1.0, 0.5, 2.0})
+	// negative threshold
+	pruned, err = hiddenLayer.Prune(-1.0)
+	assert.Equal(0, pruned)
+	assert.Error(err)
+	assert.False(hiddenLayer.Sparse())
+	// bias weights are not pruned
+	pruned, err = hiddenLayer.Prune(0.5)
+	assert.Equal(4, pruned)
+	assert.NoError(err)
+	assert.True(hiddenLayer.Sparse())
+	expWeights := mat64.NewDense(2, 4, []float64{
+		0.1, 0.0, 0.0, 1.0,
+		-2.0, 0.0, 0.0, 0.6,
+	})
+	assert.True(mat64.Equal(expWeights, hiddenLayer.Weights()))
+	// sparse output matches the dense output
+	sparseOut, err := hiddenLayer.FwdOut(inMx)
+	assert.NoError(err)
+	err = hiddenLayer.SetWeights(expWeights)
+	assert.NoError(err)
+	assert.False(hiddenLayer.Sparse())
+	denseOut, err := hiddenLayer.FwdOut(inMx)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(denseOut, sparseOut, 1e-12))
+}
@@ -0,0 +1,44 @@
+package neural
+
+import (
+	"fmt"
+
+	"github.com/gonum/optimize"
+)
+
+// lineSearch maps line search names to functions which create their implementations
+var lineSearch = map[string]func() optimize.Linesearcher{
+	"bisection":    func() optimize.Linesearcher { return &optimize.Bisection{} },
+	"backtracking": func() optimize.Linesearcher { return &optimize.Backtracking{} },
+	"morethuente":  func() optimize.Linesearcher { return &optimize.MoreThuente{} },
+}
+
+// withLineSearch returns a new instance of optimization method configured like method
+// which uses the line search registered under the supplied name.
+// It returns error if the line search is not supported or if method does not use line search.
+func withLineSearch(method optimize.Method, name string) (optimize.Method, error) {
+	newLs, ok := lineSearch[name]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported line search: %s\n", name)
+	}
+	ls := newLs()
+	switch m := method.(type) {
+	case *optimize.BFGS:
+		return &optimize.BFGS{Linesearcher: ls}, nil
+	case *optimize.LBFGS:
+		return &optimize.LBFGS{Linesearcher: ls, Store: m.Store}, nil
+	case *optimize.CG:
+		return &optimize.CG{
+			Linesearcher:           ls,
+			Variant:                m.Variant,
+			InitialStep:            m.InitialStep,
+			IterationRestartFactor: m.IterationRestartFactor,
+			AngleRestartThreshold:  m.AngleRestartThreshold,
+		}, nil
+	case *optimize.Newton:
+		return &optimize.Newton{Linesearcher: ls, Increase: m.Increase}, nil
+	case *optimize.GradientDescent:
+		return &optimize.GradientDescent{Linesearcher: ls, StepSizer: m.StepSizer}, nil
+	}
+	return nil, fmt.Errorf("Optimization method does not support line search: %T\n", method)
+}
@@ -0,0 +1,35 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/gonum/optimize"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLineSearch(t *testing.T) {
+	assert := assert.New(t)
+	// unsupported line search
+	method, err := withLineSearch(&optimize.BFGS{}, "foo")
+	assert.Nil(method)
+	assert.Error(err)
+	// method which does not use line search
+	method, err = withLineSearch(&optimize.NelderMead{}, "bisection")
+	assert.Nil(method)
+	assert.Error(err)
+	// new method instance uses requested line search
+	orig := &optimize.LBFGS{Store: 7}
+	method, err = withLineSearch(orig, "morethuente")
+	assert.NoError(err)
+	lbfgs, ok := method.(*optimize.LBFGS)
+	assert.True(ok)
+	assert.False(lbfgs == orig)
+	assert.Equal(7, lbfgs.Store)
+	assert.IsType(&optimize.MoreThuente{}, lbfgs.Linesearcher)
+	assert.Nil(orig.Linesearcher)
+	for _, name := range []string{"bisection", "backtracking", "morethuente"} {
+		method, err = withLineSearch(&optimize.BFGS{}, name)
+		assert.NotNil(method)
+		assert.NoError(err)
+	}
+}
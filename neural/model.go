@@ -0,0 +1,173 @@
+package neural
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// model is a serializable representation of neural network
+type model struct {
+	// ID is neural network id
+	ID string `json:"id,omitempty"`
+	// Kind is neural network kind
+	Kind string `json:"kind"`
+	// Layers contains network layers sorted from INPUT to OUTPUT layer
+	Layers []modelLayer `json:"layers"`
+	// Metadata contains network training metadata
+	Metadata *Metadata `json:"metadata,omitempty"`
+	// Scaler contains features scaler the network has been trained with
+	Scaler *dataset.Scaler `json:"scaler,omitempty"`
+	// Labels maps network labels to data set labels
+	Labels dataset.LabelMap `json:"labels,omitempty"`
+	// Pipeline contains features preprocessing pipeline the network has been trained with
+	Pipeline *dataset.Pipeline `json:"pipeline,omitempty"`
+}
+
+// modelLayer is a serializable representation of neural network layer
+type modelLayer struct {
+	// ID is layer id
+	ID string `json:"id,omitempty"`
+	// Kind is layer kind: input, hidden or output
+	Kind string `json:"kind"`
+	// Size is the number of layer neurons
+	Size int `json:"size"`
+	// Activation is neuron activation function
+	Activation string `json:"activation,omitempty"`
+	// Highway is true for highway layers
+	Highway bool `json:"highway,omitempty"`
+	// Weights contains layer weights
+	Weights *modelWeights `json:"weights,omitempty"`
+}
+
+// modelWeights is a serializable representation of layer weights matrix
+type modelWeights struct {
+	// Rows is the number of weights matrix rows
+	Rows int `json:"rows"`
+	// Cols is the number of weights matrix columns
+	Cols int `json:"cols"`
+	// Data contains weights matrix elements stored row by row
+	Data []float64 `json:"data"`
+}
+
+// Save writes neural network architecture and weights to w encoded in JSON format.
+// Saved network can be loaded back via Load function.
+// It fails with error if the network can't be encoded or written.
+func (n *Network) Save(w io.Writer) error {
+	layers := n.Layers()
+	if len(layers) < 2 {
+		return fmt.Errorf("Can't save network with %d layers\n", len(layers))
+	}
+	m := &model{
+		ID:       n.id,
+		Kind:     strings.ToLower(n.Kind().String()),
+		Layers:   make([]modelLayer, len(layers)),
+		Scaler:   n.scaler,
+		Labels:   n.labelMap,
+		Pipeline: n.pipeline,
+	}
+	for i, layer := range layers {
+		ml := modelLayer{
+			ID:   layer.id,
+			Kind: strings.ToLower(layer.Kind().String()),
+		}
+		// INPUT layer size is derived from the weights of the first HIDDEN layer
+		if layer.Kind() == INPUT {
+			_, cols := layers[1].weights.Dims()
+			ml.Size = cols - 1
+		} else {
+			rows, cols := layer.weights.Dims()
+			ml.Size = layer.size()
+			ml.Highway = layer.highway
+			ml.Activation = layer.meta
+			data, err := matrix.Flatten(layer.weights, matrix.RowMajor)
+			if err != nil {
+				return err
+			}
+			ml.Weights = &modelWeights{Rows: rows, Cols: cols, Data: data}
+		}
+		m.Layers[i] = ml
+	}
+	// untrained networks don't have any metadata
+	if n.meta != (Metadata{}) {
+		meta := n.meta
+		m.Metadata = &meta
+	}
+	return json.NewEncoder(w).Encode(m)
+}
+
+// Load reads neural network saved via Save from r and returns it.
+// It fails with error if the saved network can't be decoded or if it is not a valid network.
+func Load(r io.Reader) (*Network, error) {
+	m := new(model)
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	// network must contain at least INPUT and OUTPUT layers
+	if len(m.Layers) < 2 {
+		return nil, fmt.Errorf("Incorrect number of network layers: %d\n", len(m.Layers))
+	}
+	arch := &config.NetArch{}
+	for i, ml := range m.Layers {
+		lc := &config.LayerConfig{
+			ID:      ml.ID,
+			Kind:    ml.Kind,
+			Size:    ml.Size,
+			NeurFn:  &config.NeuronConfig{Activation: ml.Activation},
+			Highway: ml.Highway,
+		}
+		switch {
+		case i == 0:
+			if ml.Kind != "input" {
+				return nil, fmt.Errorf("Incorrect first layer kind: %s\n", ml.Kind)
+			}
+			arch.Input = lc
+		case i == len(m.Layers)-1:
+			if ml.Kind != "output" {
+				return nil, fmt.Errorf("Incorrect last layer kind: %s\n", ml.Kind)
+			}
+			arch.Output = lc
+		default:
+			if ml.Kind != "hidden" {
+				return nil, fmt.Errorf("Incorrect hidden layer kind: %s\n", ml.Kind)
+			}
+			arch.Hidden = append(arch.Hidden, lc)
+		}
+	}
+	net, err := NewNetwork(&config.NetConfig{ID: m.ID, Kind: m.Kind, Arch: arch})
+	if err != nil {
+		return nil, err
+	}
+	// set saved weights
+	for i, layer := range net.Layers()[1:] {
+		mw := m.Layers[i+1].Weights
+		if mw == nil || mw.Rows <= 0 || mw.Cols <= 0 || len(mw.Data) != mw.Rows*mw.Cols {
+			return nil, fmt.Errorf("Incorrect weights of layer %d\n", i+1)
+		}
+		if err := layer.SetWeights(mat64.NewDense(mw.Rows, mw.Cols, mw.Data)); err != nil {
+			return nil, err
+		}
+	}
+	if m.Metadata != nil {
+		net.meta = *m.Metadata
+	}
+	// scaler must scale all network input features
+	if m.Scaler != nil {
+		inSize := m.Layers[0].Size
+		if len(m.Scaler.Mean) != inSize || len(m.Scaler.StdDev) != inSize {
+			return nil, fmt.Errorf("Incorrect scaler size: %d\n", len(m.Scaler.Mean))
+		}
+	}
+	// label map must map all network labels
+	if m.Labels != nil && len(m.Labels) != m.Layers[len(m.Layers)-1].Size {
+		return nil, fmt.Errorf("Incorrect label map size: %d\n", len(m.Labels))
+	}
+	net.scaler, net.labelMap, net.pipeline = m.Scaler, m.Labels, m.Pipeline
+	return net, nil
+}
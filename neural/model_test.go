@@ -0,0 +1,156 @@
+package neural
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveLoad(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// save and load the network
+	var buf bytes.Buffer
+	err = n.Save(&buf)
+	assert.NoError(err)
+	loaded, err := Load(&buf)
+	assert.NotNil(loaded)
+	assert.NoError(err)
+	assert.Equal(n.Kind(), loaded.Kind())
+	assert.Equal(n.ID(), loaded.ID())
+	assert.Len(loaded.Layers(), len(n.Layers()))
+	for i, layer := range n.Layers()[1:] {
+		loadedLayer := loaded.Layers()[i+1]
+		assert.Equal(layer.Kind(), loadedLayer.Kind())
+		assert.Equal(layer.ID(), loadedLayer.ID())
+		assert.True(mat64.Equal(layer.Weights(), loadedLayer.Weights()))
+	}
+	// loaded network yields the same output
+	expOut, err := n.Classify(inMx)
+	assert.NoError(err)
+	out, err := loaded.Classify(inMx)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(expOut, out, 1e-12))
+	// untrained network has no metadata
+	assert.Equal(Metadata{}, loaded.Metadata())
+	// metadata are saved along with the network
+	meta := Metadata{
+		Trained:    time.Date(2016, 9, 1, 12, 0, 0, 0, time.UTC),
+		Iterations: 10,
+		Cost:       0.5,
+		Accuracy:   95.0,
+	}
+	n.SetMetadata(meta)
+	buf.Reset()
+	err = n.Save(&buf)
+	assert.NoError(err)
+	loaded, err = Load(&buf)
+	assert.NoError(err)
+	assert.True(meta.Trained.Equal(loaded.Metadata().Trained))
+	loadedMeta := loaded.Metadata()
+	loadedMeta.Trained = meta.Trained
+	assert.Equal(meta, loadedMeta)
+	// untrained network has neither scaler nor label map
+	assert.Nil(loaded.Scaler())
+	assert.Nil(loaded.LabelMap())
+	// scaler and label map are saved along with the network
+	_, cols := inMx.Dims()
+	scaler := dataset.NewScaler(inMx)
+	outSize, _ := n.Layers()[len(n.Layers())-1].Weights().Dims()
+	labelMap := make(dataset.LabelMap, outSize)
+	for i := range labelMap {
+		labelMap[i] = float64(i * 10)
+	}
+	n.SetScaler(scaler)
+	n.SetLabelMap(labelMap)
+	buf.Reset()
+	err = n.Save(&buf)
+	assert.NoError(err)
+	loaded, err = Load(&buf)
+	assert.NoError(err)
+	assert.Len(loaded.Scaler().Mean, cols)
+	assert.Equal(scaler, loaded.Scaler())
+	assert.Equal(labelMap, loaded.LabelMap())
+	assert.Nil(loaded.Pipeline())
+	// features preprocessing pipeline is saved along with the network
+	pipeline := dataset.NewPipeline(dataset.NewSelector(0, 1, 2, 3), new(dataset.Scaler))
+	assert.NoError(pipeline.Fit(inMx))
+	n.SetPipeline(pipeline)
+	buf.Reset()
+	assert.NoError(n.Save(&buf))
+	loaded, err = Load(&buf)
+	assert.NoError(err)
+	assert.NotNil(loaded.Pipeline())
+	expected, err := pipeline.Transform(inMx)
+	assert.NoError(err)
+	transformed, err := loaded.Pipeline().Transform(inMx)
+	assert.NoError(err)
+	assert.True(mat64.Equal(expected, transformed))
+}
+
+func TestLoadErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []string{
+		// invalid JSON
+		`{"kind": "feedfwd", "layers": [`,
+		// missing layers
+		`{"kind": "feedfwd", "layers": [{"kind": "input", "size": 2}]}`,
+		// unsupported network kind
+		`{"kind": "foo", "layers": [{"kind": "input", "size": 2},
+		{"kind": "output", "size": 1, "activation": "sigmoid",
+		"weights": {"rows": 1, "cols": 3, "data": [1, 2, 3]}}]}`,
+		// incorrect layer order
+		`{"kind": "feedfwd", "layers": [{"kind": "output", "size": 2},
+		{"kind": "input", "size": 1}]}`,
+		// missing weights
+		`{"kind": "feedfwd", "layers": [{"kind": "input", "size": 2},
+		{"kind": "output", "size": 1, "activation": "sigmoid"}]}`,
+		// weights dimension mismatch
+		`{"kind": "feedfwd", "layers": [{"kind": "input", "size": 2},
+		{"kind": "output", "size": 1, "activation": "sigmoid",
+		"weights": {"rows": 1, "cols": 2, "data": [1, 2]}}]}`,
+		// insufficient weights data
+		`{"kind": "feedfwd", "layers": [{"kind": "input", "size": 2},
+		{"kind": "output", "size": 1, "activation": "sigmoid",
+		"weights": {"rows": 1, "cols": 3, "data": [1, 2]}}]}`,
+		// scaler size mismatch
+		`{"kind": "feedfwd", "layers": [{"kind": "input", "size": 2},
+		{"kind": "output", "size": 1, "activation": "sigmoid",
+		"weights": {"rows": 1, "cols": 3, "data": [1, 2, 3]}}],
+		"scaler": {"mean": [0], "stddev": [1]}}`,
+		// label map size mismatch
+		`{"kind": "feedfwd", "layers": [{"kind": "input", "size": 2},
+		{"kind": "output", "size": 1, "activation": "sigmoid",
+		"weights": {"rows": 1, "cols": 3, "data": [1, 2, 3]}}],
+		"labels": [1, 2]}`,
+	}
+
+	for _, tc := range testCases {
+		n, err := Load(strings.NewReader(tc))
+		assert.Nil(n)
+		assert.Error(err)
+	}
+	// correct network
+	n, err := Load(strings.NewReader(`{"kind": "feedfwd", "layers": [{"kind": "input", "size": 2},
+		{"kind": "output", "size": 1, "activation": "sigmoid",
+		"weights": {"rows": 1, "cols": 3, "data": [1, 2, 3]}}]}`))
+	assert.NotNil(n)
+	assert.NoError(err)
+}
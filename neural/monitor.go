@@ -0,0 +1,238 @@
+package neural
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+)
+
+// Metrics contains neural network training metrics recorded in a particular training iteration
+type Metrics struct {
+	// Iter is training iteration: training epoch for epoch based optimization methods
+	Iter int `json:"iter"`
+	// Cost is the cost of the training data set
+	Cost float64 `json:"cost"`
+	// GradNorm is the euclidean norm of the cost gradient
+	GradNorm float64 `json:"grad_norm"`
+	// Validated is true if the network has been evaluated on validation data set
+	Validated bool `json:"validated"`
+	// ValCost is the cost of the validation data set
+	ValCost float64 `json:"val_cost"`
+	// ValAccuracy is the percentage of successfully classified validation samples
+	ValAccuracy float64 `json:"val_accuracy"`
+}
+
+// diverged returns true if the training cost or gradient norm are NaN or infinite
+func (m *Metrics) diverged() bool {
+	return math.IsNaN(m.Cost) || math.IsInf(m.Cost, 0) || math.IsNaN(m.GradNorm) || math.IsInf(m.GradNorm, 0)
+}
+
+// divergenceError returns error which reports training divergence recorded in metrics m
+// along with the changes of training configuration which usually prevent it
+func divergenceError(c *config.TrainConfig, m *Metrics) error {
+	hint := "scale the features or increase regularization"
+	if c.Optimize != nil && c.Optimize.Method == sgdMethod {
+		hint = "lower the learning rate, scale the features or increase regularization"
+	}
+	return fmt.Errorf("Training diverged in iteration %d: cost %f, gradient norm %f: %s\n",
+		m.Iter, m.Cost, m.GradNorm, hint)
+}
+
+// History contains training metrics recorded in each training iteration
+type History []Metrics
+
+// historyPlotters maps plot formats to functions which plot training history to a writer.
+// Plots are only available if the package is built with plot build tag.
+var historyPlotters = map[string]func(h History, w io.Writer) error{}
+
+// CanPlot returns true if training history can be plotted in the given format
+func CanPlot(format string) bool {
+	_, ok := historyPlotters[format]
+	return ok
+}
+
+// Plot plots the training cost of every iteration and the validation cost of validated
+// iterations to w in the given format: png or svg. Plots are only available if the package
+// is built with plot build tag which requires gonum/plot package.
+// It fails with error if the format is not available or if the plotting fails.
+func (h History) Plot(w io.Writer, format string) error {
+	plot, ok := historyPlotters[format]
+	if !ok {
+		return fmt.Errorf("Unsupported plot format: %s\n", format)
+	}
+	return plot(h, w)
+}
+
+// Callback is a function which is called with training metrics after each training iteration.
+// Network weights are set to the weights found in the particular iteration when it's called.
+// Training is stopped if the callback returns error.
+type Callback func(*Metrics) error
+
+// Checkpoint returns a callback which saves the network to the file stored in path every
+// given number of iterations. If every is not a positive integer, the network is saved in
+// every iteration. Checkpoint metadata record the training iteration, the training cost
+// and the validation accuracy if the network has been validated. Interrupted training
+// can be resumed by loading the saved network via Load function and training it again.
+// The file is replaced atomically so that it always contains a complete network even if
+// the training is interrupted while saving it.
+func Checkpoint(n *Network, path string, every int) Callback {
+	return func(m *Metrics) error {
+		if every > 0 && m.Iter%every != 0 {
+			return nil
+		}
+		// record the training progress in the checkpoint metadata
+		n.meta = Metadata{
+			Trained:    time.Now(),
+			Iterations: m.Iter,
+			Cost:       m.Cost,
+			Accuracy:   m.ValAccuracy,
+		}
+		f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+		if err != nil {
+			return err
+		}
+		if err := n.Save(f); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return err
+		}
+		if err := f.Close(); err != nil {
+			os.Remove(f.Name())
+			return err
+		}
+		return os.Rename(f.Name(), path)
+	}
+}
+
+// Monitor allows to monitor neural network training
+type Monitor struct {
+	// ValInMx is validation data set features matrix
+	ValInMx *mat64.Dense
+	// ValLabels is validation data set labels vector
+	ValLabels *mat64.Vector
+	// Every specifies how often the network is evaluated on the validation data set.
+	// If it's not a positive integer, the network is evaluated in every iteration.
+	Every int
+	// Callbacks are called with training metrics after every training iteration
+	Callbacks []Callback
+}
+
+// validate checks if the monitor configuration is valid
+func (m *Monitor) validate() error {
+	if (m.ValInMx == nil) != (m.ValLabels == nil) {
+		return fmt.Errorf("Incomplete validation set. In: %v, Out: %v\n", m.ValInMx, m.ValLabels)
+	}
+	if m.ValInMx != nil {
+		samples, _ := m.ValInMx.Dims()
+		if samples != m.ValLabels.Len() {
+			return fmt.Errorf("Validation samples count mismatch. In: %d, Out: %d\n",
+				samples, m.ValLabels.Len())
+		}
+	}
+	return nil
+}
+
+// recorder records neural network training history.
+// It implements optimize.Recorder interface.
+type recorder struct {
+	net     *Network
+	c       *config.TrainConfig
+	monitor *Monitor
+	history History
+	// ws is a workspace used to calculate the cost of the validation data set
+	ws workspace
+	// evalErr is the error of the last failed cost or gradient evaluation
+	evalErr error
+	// last contains the last cost and gradient norm evaluated by the optimization
+	last Metrics
+	// nonFinite contains the last NaN or infinite cost or gradient norm evaluated
+	// by the optimization; it's nil if all evaluations have been finite
+	nonFinite *Metrics
+	// swa averages weights of training iterations if stochastic weight averaging is enabled
+	swa *weightAverage
+}
+
+// Init initializes recorder
+func (r *recorder) Init() error {
+	r.history = nil
+	r.last = Metrics{}
+	r.nonFinite = nil
+	return nil
+}
+
+// evaluated records the cost or gradient norm evaluated by the optimization if it's not finite,
+// so that the optimization failure caused by it can be reported as training divergence
+func (r *recorder) evaluated(cost, gradNorm float64) {
+	r.last = Metrics{Iter: len(r.history), Cost: cost, GradNorm: gradNorm}
+	if r.last.diverged() {
+		m := r.last
+		r.nonFinite = &m
+	}
+}
+
+// Record records training metrics on every major optimization iteration.
+// It evaluates the network on validation data set if required and calls monitor callbacks.
+// It aborts the optimization if any of the cost or gradient evaluations failed or if the
+// training diverged i.e. the cost or gradient norm are NaN or infinite.
+func (r *recorder) Record(loc *optimize.Location, op optimize.Operation, stats *optimize.Stats) error {
+	if r.evalErr != nil {
+		return r.evalErr
+	}
+	if op != optimize.MajorIteration {
+		return nil
+	}
+	m := Metrics{
+		Iter: stats.MajorIterations,
+		Cost: loc.F,
+	}
+	if loc.Gradient != nil {
+		m.GradNorm = floats.Norm(loc.Gradient, 2)
+	}
+	// diverged weights are recorded, but they are never averaged or passed to callbacks
+	if m.diverged() {
+		r.history = append(r.history, m)
+		return divergenceError(r.c, &m)
+	}
+	// non-finite evaluations which the optimization recovered from are not reported
+	r.nonFinite = nil
+	if r.swa != nil {
+		r.swa.add(m.Iter, loc.X)
+	}
+	if r.monitor == nil {
+		r.history = append(r.history, m)
+		return nil
+	}
+	// set the network weights to the current location
+	if err := setNetWeights(r.net.Layers()[1:], loc.X); err != nil {
+		return err
+	}
+	if r.monitor.ValInMx != nil && (r.monitor.Every <= 0 || m.Iter%r.monitor.Every == 0) {
+		valCost, err := r.net.getCost(r.c, &r.ws, loc.X, r.monitor.ValInMx, r.monitor.ValLabels)
+		if err != nil {
+			return err
+		}
+		valAcc, err := r.net.Validate(r.monitor.ValInMx, r.monitor.ValLabels)
+		if err != nil {
+			return err
+		}
+		m.Validated = true
+		m.ValCost = valCost
+		m.ValAccuracy = valAcc
+	}
+	r.history = append(r.history, m)
+	for _, callback := range r.monitor.Callbacks {
+		if err := callback(&m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,28 +1,76 @@
 package neural
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
 
+	"github.com/gonum/blas"
+	"github.com/gonum/blas/blas64"
+	"github.com/gonum/floats"
 	"github.com/gonum/matrix/mat64"
 	"github.com/gonum/optimize"
 	"github.com/milosgajdos83/go-neural/pkg/config"
-	"github.com/milosgajdos83/go-neural/pkg/helpers"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/logger"
 	"github.com/milosgajdos83/go-neural/pkg/matrix"
 )
 
 const (
 	// FEEDFWD is a feed forward Neural Network
 	FEEDFWD NetworkKind = iota + 1
+	// DBN is a Deep Belief Network which has not been pretrained yet
+	DBN
 )
 
 // optim maps optimization algorithm names to their actual implementations
 var optim = map[string]optimize.Method{
-	"bfgs": &optimize.BFGS{},
+	"bfgs":   &optimize.BFGS{},
+	"newton": &optimize.Newton{},
+}
+
+// RegisterOptimMethod registers optimization method implementation under the given name
+// so that it can be requested in training configuration e.g. optimize.LBFGS or a custom
+// optimize.Method. Registering a method under an existing name replaces the original
+// implementation. It fails with error if the name is empty or if the method is nil.
+// RegisterOptimMethod is not safe to call concurrently with network training.
+func RegisterOptimMethod(name string, method optimize.Method) error {
+	if method == nil {
+		return fmt.Errorf("Optimization method can't be nil\n")
+	}
+	if name == sgdMethod {
+		return fmt.Errorf("Optimization method %s is built in\n", name)
+	}
+	for _, kind := range []string{"feedfwd", "dbn"} {
+		if err := config.AddOptimMethod(kind, name); err != nil {
+			return err
+		}
+	}
+	optim[name] = method
+	return nil
+}
+
+// netLogger logs neural network training messages
+var netLogger = logger.Discard
+
+// SetLogger sets the logger used to log neural network training messages.
+// Training progress is logged at Info level, cost traces are logged at Debug level.
+// Training messages are discarded by default or if nil logger is passed in.
+// SetLogger must not be called while any network is being trained.
+func SetLogger(l logger.Logger) {
+	if l == nil {
+		l = logger.Discard
+	}
+	netLogger = l
 }
 
 // kindMap maps strings to NetworkKind
 var netKind = map[string]NetworkKind{
 	"feedfwd": FEEDFWD,
+	"dbn":     DBN,
 }
 
 // NetworkKind defines a type of neural network
@@ -33,27 +81,73 @@ func (n NetworkKind) String() string {
 	switch n {
 	case FEEDFWD:
 		return "FEEDFWD"
+	case DBN:
+		return "DBN"
 	default:
 		return "UNKNOWN"
 	}
 }
 
 // network maps supported neural network types to their constructors
-var network = map[string]func(*config.NetArch) (*Network, error){
+var network = map[string]func(*config.NetArch, *rand.Rand) (*Network, error){
 	"feedfwd": createFeedFwdNetwork,
+	"dbn":     createDBNetwork,
 }
 
-// Network represents Neural Network
+// Network represents Neural Network.
+// Inference methods, i.e. ForwardProp, Classify, ClassifyBatch, ClassifyContext, Predict and all
+// the Validate methods, do not modify the network and never share their matrices between concurrent calls,
+// so they are safe for concurrent use by multiple goroutines. Methods which modify the network, such as
+// Train, Prune, ImportOctave or SetWeights of any of its layers, must not be called
+// concurrently with any other network method.
 type Network struct {
 	id     string
 	kind   NetworkKind
 	layers []*Layer
+	// inferPool pools activation caches used in inference
+	inferPool *sync.Pool
+	// meta contains training metadata
+	meta Metadata
+	// scaler scales features of the samples classified by the network
+	scaler *dataset.Scaler
+	// labelMap maps network labels to data set labels
+	labelMap dataset.LabelMap
+	// pipeline preprocesses features of the samples classified by the network
+	pipeline *dataset.Pipeline
+	// rnd is the source of random numbers used in training; nil means the default math/rand source
+	rnd *rand.Rand
+}
+
+// Metadata contains neural network training metadata
+type Metadata struct {
+	// Trained is the time the network training finished
+	Trained time.Time `json:"trained"`
+	// Iterations is the number of training iterations
+	Iterations int `json:"iterations"`
+	// Cost is the training cost of the trained network
+	Cost float64 `json:"cost"`
+	// Accuracy is the recorded accuracy of the trained network.
+	// Training does not record it: it is set by the network user via SetMetadata.
+	Accuracy float64 `json:"accuracy"`
 }
 
 // NewNetwork creates new Neural Network based on the passed in configuration parameters.
+// Initial layer weights are drawn from the default math/rand source.
+// Network and layer ids are taken from the configuration. Missing ids are derived from the network
+// architecture: network id consists of network kind and layer sizes e.g. feedfwd-400-25-10,
+// layer ids are input, hidden1, hidden2, ... and output.
 // It fails with error if either the requested network type is not supported or
 // if any of the neural network layers failed to be created.
 func NewNetwork(c *config.NetConfig) (*Network, error) {
+	return NewNetworkWithRand(c, nil)
+}
+
+// NewNetworkWithRand creates new Neural Network the same way as NewNetwork does, but it draws
+// initial layer weights from rnd so the network creation can be reproduced without seeding
+// the process-global math/rand source. The network keeps using rnd in randomized training, such as
+// sample shuffling, evolutionary training and pretraining. If rnd is nil, the default math/rand source is used.
+// rnd must not be used concurrently with the network creation or training.
+func NewNetworkWithRand(c *config.NetConfig, rnd *rand.Rand) (*Network, error) {
 	// supplied configuration cant be nil
 	if c == nil {
 		return nil, fmt.Errorf("Invalid network configuration: %v\n", c)
@@ -63,27 +157,69 @@ func NewNetwork(c *config.NetConfig) (*Network, error) {
 	if !ok {
 		return nil, fmt.Errorf("Unsupported neural network type: %s\n", c.Kind)
 	}
-	// create new network and return it
-	return createNet(c.Arch)
+	// create new network
+	net, err := createNet(c.Arch, rnd)
+	if err != nil {
+		return nil, err
+	}
+	net.rnd = rnd
+	net.id = c.ID
+	if net.id == "" {
+		net.id = archID(c.Kind, c.Arch)
+	}
+	// layer ids must be unique within network
+	ids := make(map[string]bool)
+	for _, layer := range net.layers {
+		if ids[layer.id] {
+			return nil, fmt.Errorf("Duplicate layer id: %s\n", layer.id)
+		}
+		ids[layer.id] = true
+	}
+	return net, nil
+}
+
+// randOrDefault returns rnd or a new source seeded from the default math/rand source if rnd is nil
+func randOrDefault(rnd *rand.Rand) *rand.Rand {
+	if rnd != nil {
+		return rnd
+	}
+	return rand.New(rand.NewSource(rand.Int63()))
+}
+
+// archID derives network id from network kind and architecture layer sizes
+func archID(kind string, arch *config.NetArch) string {
+	id := fmt.Sprintf("%s-%d", kind, arch.Input.Size)
+	for _, layerConfig := range arch.Hidden {
+		id += fmt.Sprintf("-%d", layerConfig.Size)
+	}
+	return id + fmt.Sprintf("-%d", arch.Output.Size)
+}
+
+// layerID returns layer id from configuration or the default id if the configuration has no id
+func layerID(c *config.LayerConfig, defaultID string) string {
+	if c.ID != "" {
+		return c.ID
+	}
+	return defaultID
 }
 
 // createFeedFwdNetwork creates feedforward neural network or fails with error
-func createFeedFwdNetwork(arch *config.NetArch) (*Network, error) {
+func createFeedFwdNetwork(arch *config.NetArch, rnd *rand.Rand) (*Network, error) {
 	// check if the supplied architecture is not nil
 	if arch == nil {
 		return nil, fmt.Errorf("Incorrect architecture supplied: %v\n", arch)
 	}
 	// create new network
 	net := &Network{}
-	net.id = helpers.PseudoRandString(10)
 	net.kind = FEEDFWD
+	net.inferPool = &sync.Pool{}
 	// INPUT layer can't be nil
 	if arch.Input == nil {
 		return nil, fmt.Errorf("Invalid INPUT layer: %v\n", arch.Input)
 	}
 	// Create INPUT layer
 	layerInSize := arch.Input.Size
-	inLayer, err := NewLayer(arch.Input, arch.Input.Size)
+	inLayer, err := newLayer(arch.Input, arch.Input.Size, rnd, layerID(arch.Input, "input"))
 	if err != nil {
 		return nil, err
 	}
@@ -92,8 +228,8 @@ func createFeedFwdNetwork(arch *config.NetArch) (*Network, error) {
 		return nil, err
 	}
 	// create HIDDEN layers
-	for _, layerConfig := range arch.Hidden {
-		layer, err := NewLayer(layerConfig, layerInSize)
+	for i, layerConfig := range arch.Hidden {
+		layer, err := newLayer(layerConfig, layerInSize, rnd, layerID(layerConfig, fmt.Sprintf("hidden%d", i+1)))
 		if err != nil {
 			return nil, err
 		}
@@ -109,7 +245,7 @@ func createFeedFwdNetwork(arch *config.NetArch) (*Network, error) {
 		return nil, fmt.Errorf("Invalid OUTPUT layer: %v\n", arch.Output)
 	}
 	// Create OUTPUT layer
-	outLayer, err := NewLayer(arch.Output, layerInSize)
+	outLayer, err := newLayer(arch.Output, layerInSize, rnd, layerID(arch.Output, "output"))
 	if err != nil {
 		return nil, err
 	}
@@ -181,6 +317,53 @@ func (n Network) Layers() []*Layer {
 	return n.layers
 }
 
+// Metadata returns network training metadata
+func (n Network) Metadata() Metadata {
+	return n.meta
+}
+
+// SetMetadata sets network training metadata.
+// Metadata are saved along with the network via Save method.
+func (n *Network) SetMetadata(meta Metadata) {
+	n.meta = meta
+}
+
+// Scaler returns features scaler the network has been trained with.
+// It returns nil if the network has been trained with unscaled features.
+func (n Network) Scaler() *dataset.Scaler {
+	return n.scaler
+}
+
+// SetScaler sets features scaler the network has been trained with.
+// Scaler is saved along with the network via Save method.
+func (n *Network) SetScaler(s *dataset.Scaler) {
+	n.scaler = s
+}
+
+// LabelMap returns the map of network labels to data set labels.
+// It returns nil if no label map has been set.
+func (n Network) LabelMap() dataset.LabelMap {
+	return n.labelMap
+}
+
+// SetLabelMap sets the map of network labels to data set labels.
+// Label map is saved along with the network via Save method.
+func (n *Network) SetLabelMap(lm dataset.LabelMap) {
+	n.labelMap = lm
+}
+
+// Pipeline returns features preprocessing pipeline the network has been trained with.
+// It returns nil if no pipeline has been set.
+func (n Network) Pipeline() *dataset.Pipeline {
+	return n.pipeline
+}
+
+// SetPipeline sets fitted features preprocessing pipeline the network has been trained with.
+// Pipeline is saved along with the network via Save method. Pipeline is applied before scaler.
+func (n *Network) SetPipeline(p *dataset.Pipeline) {
+	n.pipeline = p
+}
+
 // ForwardProp performs forward propagation for a given input up to a specified network layer.
 // It recursively activates all layers in the network and returns the output in a matrix
 // It fails with error if requested end layer index is beyond all available layers or if
@@ -218,6 +401,8 @@ func (n *Network) doForwardProp(inMx mat64.Matrix, from, to int) (mat64.Matrix,
 
 // BackProp performs back propagation of neural network. It traverses neural network recursively
 // from layer specified via parameter and calculates error deltas for each network layer.
+// BackProp operates on batches: both input and error matrices can contain multiple samples,
+// one sample per row, in which case the deltas of all samples are accumulated in a single pass.
 // It fails with error if either the supplied input and delta matrices are nil or if the specified
 // from boundary goes beyond the first network layer that can have output errors calculated
 func (n *Network) BackProp(inMx, errMx mat64.Matrix, fromLayer int) error {
@@ -234,58 +419,118 @@ func (n *Network) BackProp(inMx, errMx mat64.Matrix, fromLayer int) error {
 	if fromLayer < 1 || fromLayer > len(layers)-1 {
 		return fmt.Errorf("Cant backpropagate beyond first layer: %d\n", len(layers))
 	}
+	// run a single forward pass and cache all layer activations
+	cache, err := n.forwardCache(inMx)
+	if err != nil {
+		return err
+	}
+	// error dimensions must match the layer output dimensions
+	errRows, errCols := errMx.Dims()
+	outRows, outCols := cache.errMx[fromLayer].Dims()
+	if errRows != outRows || errCols != outCols {
+		return fmt.Errorf("Dimension mismatch. Output: %d x %d Error: %d x %d\n",
+			outRows, outCols, errRows, errCols)
+	}
+	cache.errMx[fromLayer].Copy(errMx)
+	// layer deltas accumulate the backpropagated errors
+	deltas := make([]*mat64.Dense, len(layers))
+	for i := 1; i < len(layers); i++ {
+		deltas[i] = layers[i].deltas
+	}
 	// perform the actual back propagation till the first hidden layer
-	return n.doBackProp(inMx, errMx, fromLayer, 1)
+	return n.doBackProp(cache, deltas, fromLayer, 1)
+}
+
+// forwardCache runs forward propagation through all network layers and returns the cache
+// that contains outputs and activation inputs of every network layer.
+func (n *Network) forwardCache(inMx mat64.Matrix) (*actCache, error) {
+	rows, _ := inMx.Dims()
+	cache := newActCache(n.Layers(), rows)
+	if err := cache.setInput(inMx); err != nil {
+		return nil, err
+	}
+	if err := n.forward(cache); err != nil {
+		return nil, err
+	}
+	return cache, nil
 }
 
-// doBackProp performs the actual backpropagation
-func (n *Network) doBackProp(inMx, errMx mat64.Matrix, from, to int) error {
+// forward runs forward propagation of the input stored in cache through all network layers
+// and stores layer outputs and activation inputs in the cache. It does not allocate any new matrices.
+// It returns error if the cache does not match the network layers.
+func (n *Network) forward(cache *actCache) error {
+	layers := n.Layers()
+	for i := 1; i < len(layers); i++ {
+		if err := layers[i].activate(cache.biasOut[i-1], cache.actIn[i], cache.out[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// doBackProp performs the actual backpropagation of the errors stored in cache.
+// Backpropagated errors are accumulated in deltas matrices: one matrix per network layer.
+func (n *Network) doBackProp(cache *actCache, deltas []*mat64.Dense, from, to int) error {
 	// get all the layers
 	layers := n.Layers()
 	// pick deltas layer
 	layer := layers[from]
-	deltasMx := layer.Deltas()
-	weightsMx := layer.Weights()
-	//forward propagate to previous layer
-	outMx, err := n.ForwardProp(inMx, from-1)
-	if err != nil {
-		return err
+	errMx := cache.errMx[from]
+	// update deltas in place: deltas += errMx' * output of the previous layer with bias
+	if rows, _ := errMx.Dims(); rows == 1 {
+		// single sample error is a rank-1 update
+		if err := matrix.Outer(deltas[from], errMx.RawRowView(0), cache.biasOut[from-1].RawRowView(0)); err != nil {
+			return err
+		}
+	} else {
+		blas64.Gemm(blas.Trans, blas.NoTrans, 1.0, errMx.RawMatrix(),
+			cache.biasOut[from-1].RawMatrix(), 1.0, deltas[from].RawMatrix())
 	}
-	outMxBias := matrix.AddBias(outMx)
-	// compute deltas update
-	dMx := new(mat64.Dense)
-	dMx.Mul(errMx.T(), outMxBias)
-	// update deltas
-	deltasMx.Add(deltasMx, dMx)
 	// If we reach the 1st hidden layer we return
 	if from == to {
 		return nil
 	}
-	// errTmpMx holds layer error not accounting for bias
-	errTmpMx := new(mat64.Dense)
-	errTmpMx.Mul(weightsMx.T(), errMx.T())
-	r, c := errTmpMx.Dims()
-	// avoid bias
-	layerErr := errTmpMx.View(1, 0, r-1, c).(*mat64.Dense)
-	// pre-activation unit
-	actInMx, err := n.ForwardProp(inMx, from-2)
-	if err != nil {
+	// propagate the error to the previous layer avoiding bias
+	_, c := layer.weights.Dims()
+	prev := layers[from-1]
+	layerErr := cache.errMx[from-1]
+	// highway layer output error is kept to propagate it through the carry gate
+	if prev.highway {
+		layerErr = cache.carry[from-1]
+	}
+	// weights without bias column are sliced from the raw matrix so that no view is allocated
+	w := layer.weights.RawMatrix()
+	w.Cols, w.Data = c-1, w.Data[1:]
+	blas64.Gemm(blas.NoTrans, blas.NoTrans, 1.0, errMx.RawMatrix(), w, 0.0, layerErr.RawMatrix())
+	// highway layer also passes the error carried from its output
+	if layer.highway {
+		layerErr.Add(layerErr, cache.carry[from])
+	}
+	if prev.highway {
+		prev.highwayErr(cache.errMx[from-1], layerErr, cache.actIn[from-1], cache.out[from-2])
+		return n.doBackProp(cache, deltas, from-1, to)
+	}
+	// multiply the error by the gradient of the cached activation inputs: activation inputs
+	// are not needed anymore so they are overwritten by the gradient to avoid allocation
+	gradMx := cache.actIn[from-1]
+	if err := matrix.ApplySlice(prev.gradSlice, gradMx, gradMx); err != nil {
 		return err
 	}
-	biasActInMx := matrix.AddBias(actInMx)
-	// pick errLayer
-	weightsErrLayer := layers[from-1]
-	weightsErrMx := weightsErrLayer.Weights()
-	// compute gradient matrix
-	gradMx := new(mat64.Dense)
-	gradMx.Mul(biasActInMx, weightsErrMx.T())
-	gradMx.Apply(weightsErrLayer.ActGrad(), gradMx)
-	gradMx.MulElem(layerErr.T(), gradMx)
-	return n.doBackProp(inMx, gradMx, from-1, to)
+	layerErr.MulElem(layerErr, gradMx)
+	return n.doBackProp(cache, deltas, from-1, to)
+}
+
+// checkFiniteGrad returns error if the gradient contains NaN or Inf values
+func checkFiniteGrad(grad []float64) error {
+	gradMx := mat64.NewDense(1, len(grad), grad)
+	if matrix.HasNaN(gradMx) || matrix.HasInf(gradMx) {
+		return fmt.Errorf("Non-finite gradient: norm %f\n", floats.Norm(grad, 2))
+	}
+	return nil
 }
 
 // costMap maps name of cost to their actual implementations
-var trainCost = map[string]Cost{
+var trainCost = map[string]trainingCost{
 	"xentropy": CrossEntropy{},
 	"loglike":  LogLikelihood{},
 }
@@ -305,6 +550,32 @@ func ValidateTrainConfig(c *config.TrainConfig) error {
 	if c.Lambda < 0 {
 		return fmt.Errorf("Incorrect regularizer supplied: %f\n", c.Lambda)
 	}
+	// Incorrect concurrency supplied
+	if c.Concurrency < 0 {
+		return fmt.Errorf("Incorrect concurrency supplied: %d\n", c.Concurrency)
+	}
+	// Incorrect weight averaging supplied
+	if c.SWAStart < 0 || c.SWAEvery < 0 {
+		return fmt.Errorf("Incorrect weight averaging. Start: %d, Every: %d\n", c.SWAStart, c.SWAEvery)
+	}
+	// incorrect pretraining supplied
+	if c.Pretrain != nil {
+		if err := validatePretrainConfig(c.Pretrain); err != nil {
+			return err
+		}
+	}
+	// evolutionary training is configured by evolution parameters
+	if c.Kind == evolutionKind {
+		return validateEvolutionConfig(c.Evolution)
+	}
+	// optimization parameters must be supplied
+	if c.Optimize == nil {
+		return fmt.Errorf("Incorrect optimization configuration supplied: %v\n", c.Optimize)
+	}
+	// mini-batch gradient descent is configured by epochs rather than iterations
+	if c.Optimize.Method == sgdMethod {
+		return validateSGDConfig(c.Optimize)
+	}
 	// if the optimization method is not supported
 	if _, ok := optim[c.Optimize.Method]; !ok {
 		return fmt.Errorf("Unsupported optimization method: %s\n", c.Optimize.Method)
@@ -313,16 +584,34 @@ func ValidateTrainConfig(c *config.TrainConfig) error {
 	if c.Optimize.Iterations <= 0 {
 		return fmt.Errorf("Incorrect number of iterations: %d\n", c.Optimize.Iterations)
 	}
+	// incorrect evaluation limits supplied
+	if c.Optimize.FuncEvaluations < 0 || c.Optimize.GradEvaluations < 0 {
+		return fmt.Errorf("Incorrect evaluation limits. Func: %d, Grad: %d\n",
+			c.Optimize.FuncEvaluations, c.Optimize.GradEvaluations)
+	}
+	// incorrect runtime limit supplied
+	if c.Optimize.Runtime < 0 {
+		return fmt.Errorf("Incorrect runtime limit: %s\n", c.Optimize.Runtime)
+	}
+	// incorrect convergence parameters supplied
+	if c.Optimize.GradientThreshold < 0 {
+		return fmt.Errorf("Incorrect gradient threshold: %f\n", c.Optimize.GradientThreshold)
+	}
+	if c.Optimize.ConvergeAbsolute < 0 || c.Optimize.ConvergeRelative < 0 || c.Optimize.ConvergeIterations < 0 {
+		return fmt.Errorf("Incorrect convergence. Absolute: %f, Relative: %f, Iterations: %d\n",
+			c.Optimize.ConvergeAbsolute, c.Optimize.ConvergeRelative, c.Optimize.ConvergeIterations)
+	}
+	// line search must be supported by the optimization method
+	if c.Optimize.LineSearch != "" {
+		if _, err := withLineSearch(optim[c.Optimize.Method], c.Optimize.LineSearch); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// Train trains feedforward neural network per configuration passed in as parameter.
-// It returns error if either the training configuration is invalid ot the training fails.
-func (n *Network) Train(c *config.TrainConfig, inMx *mat64.Dense, labelsVec *mat64.Vector) error {
-	// validate the supplied configuration
-	if err := ValidateTrainConfig(c); err != nil {
-		return err
-	}
+// checkTrainData checks if the training data set is valid
+func checkTrainData(inMx *mat64.Dense, labelsVec *mat64.Vector) error {
 	// input matrix can't be nil
 	if inMx == nil {
 		return fmt.Errorf("Incorrect input supplied: %v\n", inMx)
@@ -331,53 +620,180 @@ func (n *Network) Train(c *config.TrainConfig, inMx *mat64.Dense, labelsVec *mat
 	if labelsVec == nil {
 		return fmt.Errorf("Incorrect lables supplied: %v\n", labelsVec)
 	}
+	// every sample must be labeled
+	if rows, _ := inMx.Dims(); rows != labelsVec.Len() {
+		return fmt.Errorf("Samples count mismatch. Input: %d, Labels: %d\n", rows, labelsVec.Len())
+	}
+	return nil
+}
+
+// Train trains feedforward neural network per configuration passed in as parameter.
+// It returns error if either the training configuration is invalid ot the training fails.
+func (n *Network) Train(c *config.TrainConfig, inMx *mat64.Dense, labelsVec *mat64.Vector) error {
+	_, err := n.TrainMonitored(c, inMx, labelsVec, nil)
+	return err
+}
+
+// TrainMonitored trains feedforward neural network per configuration passed in as parameter
+// the same way as Train does, but it also allows to monitor the training via monitor parameter.
+// If the monitor contains validation data set, the network is evaluated on it periodically.
+// Monitor callbacks are called with training metrics after every training iteration.
+// If the configuration contains pretraining parameters, hidden layers of untrained network are
+// pretrained before the training: networks which carry training metadata, such as resumed networks,
+// are not pretrained again. DBN networks must always be pretrained.
+// It returns training history which contains training metrics recorded in every iteration.
+// It returns error if either the training configuration or monitor are invalid or the training fails.
+func (n *Network) TrainMonitored(c *config.TrainConfig, inMx *mat64.Dense,
+	labelsVec *mat64.Vector, m *Monitor) (History, error) {
+	// validate the supplied configuration
+	if err := ValidateTrainConfig(c); err != nil {
+		return nil, err
+	}
+	if err := checkTrainData(inMx, labelsVec); err != nil {
+		return nil, err
+	}
+	// validate monitor configuration
+	if m != nil {
+		if err := m.validate(); err != nil {
+			return nil, err
+		}
+	}
+	// recorder records training history and aborts the optimization if any evaluation fails
+	rec := &recorder{
+		net:     n,
+		c:       c,
+		monitor: m,
+	}
+	if c.SWAStart > 0 {
+		rec.swa = &weightAverage{start: c.SWAStart, every: c.SWAEvery}
+	}
+	// hidden layers are pretrained before the supervised training if requested:
+	// pretraining would overwrite the weights of already trained network
+	if n.kind == DBN && c.Pretrain == nil {
+		return nil, fmt.Errorf("%s network must be pretrained\n", n.kind)
+	}
+	if c.Pretrain != nil && n.meta.Trained.IsZero() {
+		if err := n.Pretrain(c.Pretrain, inMx); err != nil {
+			return nil, err
+		}
+	}
+	if c.Kind == evolutionKind {
+		return n.trainEvolution(c, inMx, labelsVec, rec)
+	}
+	if c.Optimize.Method == sgdMethod {
+		return n.trainSGD(c, inMx, labelsVec, rec)
+	}
+	// workspace is reused across all cost and gradient evaluations
+	ws := new(workspace)
 	// costFunc for optimization
 	costFunc := func(x []float64) float64 {
-		curCost, err := n.getCost(c, x, inMx, labelsVec)
+		curCost, err := n.getCost(c, ws, x, inMx, labelsVec)
 		if err != nil {
-			panic(err)
+			rec.evalErr = err
+			return math.NaN()
+		}
+		rec.evaluated(curCost, rec.last.GradNorm)
+		if c.CheckFinite && (math.IsNaN(curCost) || math.IsInf(curCost, 0)) {
+			rec.evalErr = fmt.Errorf("Non-finite cost: %f\n", curCost)
+			return curCost
 		}
-		// TODO: can be nebled via verbose flag
-		fmt.Printf("Current Cost: %f\n", curCost)
+		netLogger.Debugf("Current cost: %f", curCost)
 		return curCost
 	}
 	// gradfunc for optimization
 	gradFunc := func(grad []float64, x []float64) {
-		curGrad, err := n.getGradient(c, x, inMx, labelsVec)
-		if err != nil {
-			panic(err)
+		err := n.getGradient(c, ws, grad, x, inMx, labelsVec)
+		if err == nil {
+			rec.evaluated(rec.last.Cost, floats.Norm(grad, 2))
+		}
+		if err == nil && c.CheckFinite {
+			err = checkFiniteGrad(grad)
 		}
-		cdata := copy(grad, curGrad)
-		if len(curGrad) != cdata {
-			panic("Could not calculate gradient!")
+		if err != nil {
+			rec.evalErr = err
+			// NaN gradient stops optimization methods which don't call recorder
+			for i := range grad {
+				grad[i] = math.NaN()
+			}
 		}
 	}
 	// initialize parameters
-	var initWeights []float64
 	layers := n.Layers()
-	for i := range layers[1:] {
-		initWeights = append(initWeights, matrix.Mx2Vec(layers[i+1].Weights(), false)...)
-	}
+	initWeights := netWeights(layers[1:])
 	// optimization problem settings
 	p := optimize.Problem{
 		Func: costFunc,
 		Grad: gradFunc,
 	}
-	settings := optimize.DefaultSettings()
-	settings.Recorder = nil
-	settings.FunctionConverge = nil
-	settings.MajorIterations = c.Optimize.Iterations
+	settings := optimSettings(c.Optimize)
+	settings.Recorder = rec
+	// requested line search is used by a new instance of the registered method
+	method := optim[c.Optimize.Method]
+	if c.Optimize.LineSearch != "" {
+		var err error
+		if method, err = withLineSearch(method, c.Optimize.LineSearch); err != nil {
+			return nil, err
+		}
+	}
+	// Hessian based methods use Hessian approximated from the gradient
+	if method.Needs().Hessian {
+		p.Hess = func(hess mat64.MutableSymmetric, x []float64) {
+			numHessian(hess, x, gradFunc)
+		}
+	}
 	// run the optimization
-	result, err := optimize.Local(p, initWeights, settings, optim[c.Optimize.Method])
+	result, err := optimize.Local(p, initWeights, settings, method)
+	// failed evaluation is the cause of any optimization error
+	if rec.evalErr != nil {
+		return rec.history, fmt.Errorf("Training failed: %v\n", rec.evalErr)
+	}
+	// optimization methods fail when they evaluate NaN or infinite cost or gradient
+	if err != nil && rec.nonFinite != nil {
+		return rec.history, divergenceError(c, rec.nonFinite)
+	}
 	if err != nil {
-		return err
+		return rec.history, err
 	}
-	fmt.Printf("Result status: %s\n", result.Status)
-	return nil
+	// set the network weights to the best weights found
+	if err := setNetWeights(layers[1:], result.X); err != nil {
+		return rec.history, err
+	}
+	netLogger.Infof("Result status: %s", result.Status)
+	n.meta = Metadata{
+		Trained:    time.Now(),
+		Iterations: result.MajorIterations,
+		Cost:       result.F,
+	}
+	if err := n.setAvgWeights(c, rec.swa, inMx, labelsVec); err != nil {
+		return rec.history, err
+	}
+	return rec.history, nil
+}
+
+// optimSettings returns optimization settings with the convergence criteria and limits configured in c
+func optimSettings(c *config.OptimConfig) *optimize.Settings {
+	settings := optimize.DefaultSettings()
+	settings.FunctionConverge = nil
+	if c.ConvergeIterations > 0 {
+		settings.FunctionConverge = &optimize.FunctionConverge{
+			Absolute:   c.ConvergeAbsolute,
+			Relative:   c.ConvergeRelative,
+			Iterations: c.ConvergeIterations,
+		}
+	}
+	if c.GradientThreshold > 0 {
+		settings.GradientThreshold = c.GradientThreshold
+	}
+	settings.MajorIterations = c.Iterations
+	settings.FuncEvaluations = c.FuncEvaluations
+	settings.GradEvaluations = c.GradEvaluations
+	settings.Runtime = c.Runtime
+	return settings
 }
 
 // getCost calculates the cost of the neural network output for given input and expected output.
-func (n *Network) getCost(c *config.TrainConfig, weights []float64,
+// Matrices used in the calculation are allocated in the supplied workspace.
+func (n *Network) getCost(c *config.TrainConfig, ws *workspace, weights []float64,
 	inMx *mat64.Dense, labelsVec *mat64.Vector) (float64, error) {
 	// get all network layers
 	layers := n.Layers()
@@ -387,34 +803,34 @@ func (n *Network) getCost(c *config.TrainConfig, weights []float64,
 			return -1.0, err
 		}
 	}
-	// run forward propagation from INPUT layer
-	outMx, err := n.ForwardProp(inMx, len(layers)-1)
-	if err != nil {
+	// number of data samples
+	samples, _ := inMx.Dims()
+	ws.init(layers, samples, gradWorkers(c, samples))
+	// load input and labels into workspace
+	labelCount, _ := layers[len(layers)-1].weights.Dims()
+	if err := ws.load(inMx, labelsVec, labelCount); err != nil {
 		return -1.0, err
 	}
-	// labelsMx is one-of-N matrix for each output label
-	// i.e. 3rd label would be: 0 0 1 0 0 etc.
-	_, labelCount := outMx.Dims()
-	labelsMx, err := matrix.MakeLabelsMx(labelsVec, labelCount)
-	if err != nil {
+	// run forward propagation from INPUT layer
+	if err := n.forward(ws.cache); err != nil {
 		return -1.0, err
 	}
+	outMx := ws.cache.out[len(layers)-1]
 	// calculate cost
 	tc, _ := trainCost[c.Cost]
-	cost := tc.CostFunc(inMx, outMx, labelsMx)
-	// number of data samples
-	samples, _ := inMx.Dims()
+	cost := tc.CostFunc(inMx, outMx, ws.labelsMx)
 	reg := 0.0
 	// if regularizer is not 0, calculate L2-regularization
 	if c.Lambda > 0 {
 		// Ignore first layer i.e. input layer
 		for _, layer := range layers[1:] {
-			r, c := layer.Weights().Dims()
-			// Don't penalize bias units
-			weightsMx := layer.Weights().View(0, 1, r, c-1)
-			sqrMx := new(mat64.Dense)
-			sqrMx.Apply(matrix.PowMx(2), weightsMx)
-			reg += mat64.Sum(sqrMx)
+			rows, _ := layer.weights.Dims()
+			for i := 0; i < rows; i++ {
+				// Don't penalize bias units
+				for _, w := range layer.weights.RawRowView(i)[1:] {
+					reg += w * w
+				}
+			}
 		}
 		reg = (c.Lambda / (2 * float64(samples))) * reg
 	}
@@ -422,70 +838,127 @@ func (n *Network) getCost(c *config.TrainConfig, weights []float64,
 }
 
 // getGradient calculates network gradient for a particular network and configuration
-// It returns a gradient slice or fails with error
-func (n *Network) getGradient(c *config.TrainConfig, weights []float64,
-	inMx *mat64.Dense, labelsVec *mat64.Vector) ([]float64, error) {
+// and stores it in the grad slice which must be big enough to hold all network weights.
+// Matrices used in the calculation are allocated in the supplied workspace.
+func (n *Network) getGradient(c *config.TrainConfig, ws *workspace, grad []float64,
+	weights []float64, inMx *mat64.Dense, labelsVec *mat64.Vector) error {
 	// get all network layers
 	layers := n.Layers()
 	// if we supply network weights, set the neural network to provided weights
 	if weights != nil {
 		if err := setNetWeights(layers[1:], weights); err != nil {
-			return nil, err
+			return err
 		}
 	}
-	// run full forward propagation
-	outMx, err := n.ForwardProp(inMx, len(layers)-1)
-	if err != nil {
-		return nil, err
-	}
-	// labelsMx is one-of-N matrix for each output label
-	// i.e. 3rd label would be: 0 0 1 0 0 etc.
-	_, labelCount := outMx.Dims()
-	labelsMx, err := matrix.MakeLabelsMx(labelsVec, labelCount)
-	if err != nil {
-		return nil, err
-	}
 	// number of data samples
 	samples, _ := inMx.Dims()
-	// iterate through all samples and calculate errors and corrections
-	for i := 0; i < samples; i++ {
-		// input vector
-		inVec := inMx.RowView(i)
-		// expected output
-		expVec := labelsMx.RowView(i)
-		// output from output layer - safe switch type - ForwardProp returns *mat64.Dense
-		outVec := (outMx.(*mat64.Dense)).RowView(i)
-		// calculate the error = out - y
-		tc, _ := trainCost[c.Cost]
-		deltaVec := tc.Delta(outVec, expVec)
-		// run the backpropagation
-		if err := n.BackProp(inVec.T(), deltaVec.T(), len(layers)-1); err != nil {
-			return nil, err
+	ws.init(layers, samples, gradWorkers(c, samples))
+	// load input and labels into workspace
+	labelCount, _ := layers[len(layers)-1].weights.Dims()
+	if err := ws.load(inMx, labelsVec, labelCount); err != nil {
+		return err
+	}
+	// the first gradient worker accumulates the deltas directly in the gradient slice
+	acc := 0
+	for i := 1; i < len(layers); i++ {
+		r, c := layers[i].weights.Dims()
+		if len(grad)-acc < r*c {
+			return fmt.Errorf("Insufficient gradient length: %d\n", len(grad))
 		}
+		ws.deltas[0][i].SetRawMatrix(blas64.General{
+			Rows:   r,
+			Cols:   c,
+			Stride: c,
+			Data:   grad[acc:(acc + r*c)],
+		})
+		acc += r * c
 	}
-	// calculate the gradient and update network weights
-	var gradient []float64
+	// backpropagate output errors of all samples
+	if err := n.accumDeltas(c, ws); err != nil {
+		return err
+	}
+	// calculate the gradient: deltas of the first worker are stored in the gradient slice
+	reg := c.Lambda / float64(samples)
 	// skip zero layer - INPUT layer has no Deltas
-	for i := 1; i < len(layers); i++ {
-		layer := layers[i]
-		deltas := layer.Deltas()
+	for i, layer := range layers[1:] {
+		deltas := ws.deltas[0][i+1]
 		deltas.Scale(1/float64(samples), deltas)
 		if c.Lambda > 0.0 {
-			rows, cols := layer.Weights().Dims()
-			regWeights := mat64.NewDense(rows, cols, nil)
-			reg := c.Lambda / float64(samples)
-			regWeights.Clone(layer.Weights())
-			// set the first column to 0
-			zeros := make([]float64, rows)
-			regWeights.SetCol(0, zeros)
-			regWeights.Scale(reg, regWeights)
-			// Update particular layer deltas matrix
-			regWeights.Add(deltas, regWeights)
-			gradVec := matrix.Mx2Vec(regWeights, false)
-			gradient = append(gradient, gradVec...)
+			rows, _ := deltas.Dims()
+			for j := 0; j < rows; j++ {
+				deltasRow := deltas.RawRowView(j)
+				weightsRow := layer.weights.RawRowView(j)
+				// Don't regularize bias units
+				for k := 1; k < len(deltasRow); k++ {
+					deltasRow[k] += reg * weightsRow[k]
+				}
+			}
 		}
 	}
-	return gradient, nil
+	return nil
+}
+
+// accumDeltas backpropagates the output errors of all samples and accumulates them in the deltas
+// of the first gradient worker. Samples loaded in the workspace are partitioned across the gradient
+// workers allocated in it. Each worker accumulates the errors in its own deltas matrices which are
+// summed up at the end.
+func (n *Network) accumDeltas(c *config.TrainConfig, ws *workspace) error {
+	layers := n.Layers()
+	_, labelCount := ws.labelsMx.Dims()
+	// deltas are accumulated during backpropagation so they must be zeroed first
+	for _, deltas := range ws.deltas {
+		for _, deltasMx := range deltas[1:] {
+			data := deltasMx.RawMatrix().Data
+			for i := range data {
+				data[i] = 0.0
+			}
+		}
+	}
+	if len(ws.workers) == 1 {
+		return n.batchDeltas(c, ws.workers[0], ws.labelsMx, ws.deltas[0])
+	}
+	errs := make([]error, len(ws.workers))
+	var wg sync.WaitGroup
+	for w := range ws.workers {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			from, to := ws.offsets[w], ws.offsets[w+1]
+			batchLabelsMx := ws.labelsMx.View(from, 0, to-from, labelCount).(*mat64.Dense)
+			errs[w] = n.batchDeltas(c, ws.workers[w], batchLabelsMx, ws.deltas[w])
+		}(w)
+	}
+	wg.Wait()
+	// sum up the results
+	for w := range ws.workers {
+		if errs[w] != nil {
+			return errs[w]
+		}
+		if w == 0 {
+			continue
+		}
+		for i := 1; i < len(layers); i++ {
+			ws.deltas[0][i].Add(ws.deltas[0][i], ws.deltas[w][i])
+		}
+	}
+	return nil
+}
+
+// batchDeltas backpropagates the output errors of a batch of samples and accumulates them in deltas.
+// The batch samples must be loaded in the supplied cache which also caches the layer activations.
+func (n *Network) batchDeltas(c *config.TrainConfig, cache *actCache,
+	labelsMx *mat64.Dense, deltas []*mat64.Dense) error {
+	layers := n.Layers()
+	// run full forward propagation and cache layer activations
+	if err := n.forward(cache); err != nil {
+		return err
+	}
+	// calculate the output error of all samples at once: out - y
+	tc, _ := trainCost[c.Cost]
+	outIdx := len(layers) - 1
+	tc.deltaTo(cache.errMx[outIdx], cache.out[outIdx], labelsMx)
+	// run the backpropagation of the whole batch
+	return n.doBackProp(cache, deltas, outIdx, 1)
 }
 
 // Classify classifies the provided data vector to a particular label class.
@@ -495,35 +968,132 @@ func (n *Network) Classify(inMx mat64.Matrix) (mat64.Matrix, error) {
 	if inMx == nil {
 		return nil, fmt.Errorf("Can't classify %v\n", inMx)
 	}
-	// do forward propagation
-	out, err := n.ForwardProp(inMx, len(n.Layers())-1)
-	if err != nil {
+	samples, _ := inMx.Dims()
+	layers := n.Layers()
+	results, _ := layers[len(layers)-1].weights.Dims()
+	// classification matrix
+	classMx := mat64.NewDense(samples, results, nil)
+	if err := n.ClassifyBatch(inMx, classMx); err != nil {
 		return nil, err
 	}
+	return classMx, nil
+}
+
+// ClassifyBatch classifies the provided data the same way as Classify does, but it stores the
+// classification results in the supplied output matrix which must have as many rows as the input
+// and as many columns as the network has outputs. Matrices used during forward propagation are
+// reused across ClassifyBatch calls, so classifying batches of the same size does not allocate any
+// new matrices. ClassifyBatch is safe for concurrent use by multiple goroutines.
+// It returns error if either of the supplied matrices is nil or if their dimensions are incorrect.
+func (n *Network) ClassifyBatch(inMx mat64.Matrix, out *mat64.Dense) error {
+	if inMx == nil || out == nil {
+		return fmt.Errorf("Can't classify. In: %v, Out: %v\n", inMx, out)
+	}
+	layers := n.Layers()
 	samples, _ := inMx.Dims()
-	_, results := out.Dims()
-	// classification matrix
+	results, _ := layers[len(layers)-1].weights.Dims()
+	// output dimensions must match the network output
+	outRows, outCols := out.Dims()
+	if outRows != samples || outCols != results {
+		return fmt.Errorf("Dimension mismatch. Expected: %d x %d Output: %d x %d\n",
+			samples, results, outRows, outCols)
+	}
+	cache := n.inferCache(samples)
+	if n.inferPool != nil {
+		defer n.inferPool.Put(cache)
+	}
+	if err := cache.setInput(inMx); err != nil {
+		return err
+	}
+	if err := n.forward(cache); err != nil {
+		return err
+	}
+	// scale every row to percentages
+	netOut := cache.out[len(layers)-1]
+	for i := 0; i < samples; i++ {
+		row := out.RawRowView(i)
+		copy(row, netOut.RawRowView(i))
+		floats.Scale(100.0/floats.Sum(row), row)
+	}
+	return nil
+}
+
+// classifyChunk is the number of samples ClassifyContext classifies between cancellation checks
+var classifyChunk = 256
+
+// ClassifyContext classifies the provided data the same way as Classify does, but it classifies
+// the samples in chunks and checks whether the supplied context has been cancelled between them.
+// It returns the context error if the context is done before all samples have been classified.
+func (n *Network) ClassifyContext(ctx context.Context, inMx mat64.Matrix) (mat64.Matrix, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Can't classify %v\n", inMx)
+	}
+	samples, cols := inMx.Dims()
+	layers := n.Layers()
+	results, _ := layers[len(layers)-1].weights.Dims()
+	// chunks are views of the input so it must be dense
+	denseIn, ok := inMx.(*mat64.Dense)
+	if !ok {
+		denseIn = mat64.DenseCopyOf(inMx)
+	}
 	classMx := mat64.NewDense(samples, results, nil)
-	switch o := out.(type) {
-	case *mat64.Dense:
-		for i := 0; i < samples; i++ {
-			row := new(mat64.Dense)
-			row.Clone(o.RowView(i))
-			sum := mat64.Sum(row)
-			row.Scale(100.0/sum, row)
-			data := matrix.Mx2Vec(row, true)
-			classMx.SetRow(i, data)
-		}
-	case *mat64.Vector:
-		sum := mat64.Sum(o)
-		tmp := new(mat64.Dense)
-		tmp.Scale(100.0/sum, o)
-		data := matrix.Mx2Vec(tmp, true)
-		classMx.SetRow(0, data)
+	for from := 0; from < samples; from += classifyChunk {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		to := from + classifyChunk
+		if to > samples {
+			to = samples
+		}
+		chunkIn := denseIn.View(from, 0, to-from, cols)
+		chunkOut := classMx.View(from, 0, to-from, results).(*mat64.Dense)
+		if err := n.ClassifyBatch(chunkIn, chunkOut); err != nil {
+			return nil, err
+		}
 	}
 	return classMx, nil
 }
 
+// inferCache returns activation cache for forward propagation of given number of samples.
+// Caches are pooled so they can be reused by concurrent inference calls.
+func (n *Network) inferCache(samples int) *actCache {
+	layers := n.Layers()
+	if n.inferPool != nil {
+		if cache, ok := n.inferPool.Get().(*actCache); ok && cache.fits(layers, samples) {
+			return cache
+		}
+	}
+	return newActCache(layers, samples)
+}
+
+// Predict classifies the provided data to particular label classes.
+// It returns two vectors which contain for each sample the predicted label
+// and the probability of the sample belonging to the predicted label class.
+// Predicted labels start at 1 to match the labels of training data sets.
+// It returns error if the network forward propagation fails at any point during classification.
+func (n *Network) Predict(inMx mat64.Matrix) (*mat64.Vector, *mat64.Vector, error) {
+	if inMx == nil {
+		return nil, nil, fmt.Errorf("Can't predict %v\n", inMx)
+	}
+	// do forward propagation
+	out, err := n.ForwardProp(inMx, len(n.Layers())-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	labels, err := matrix.LabelsFromMx(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	samples, results := out.Dims()
+	probs := mat64.NewVector(samples, nil)
+	row := make([]float64, results)
+	for i := 0; i < samples; i++ {
+		mat64.Row(row, i, out)
+		probs.SetVec(i, row[int(labels.At(i, 0))-1]/floats.Sum(row))
+	}
+	return labels, probs, nil
+}
+
 // Validate runs forward propagation on the validation data set through neural network.
 // It returns the percentage of successful classifications or error.
 func (n *Network) Validate(valInMx *mat64.Dense, valOut *mat64.Vector) (float64, error) {
@@ -535,41 +1105,132 @@ func (n *Network) Validate(valInMx *mat64.Dense, valOut *mat64.Vector) (float64,
 	if err != nil {
 		return 0.0, err
 	}
-	rows, _ := out.Dims()
+	hits := 0.0
+	for i, maxIdx := range matrix.RowsArgMax(out) {
+		if maxIdx+1 == int(valOut.At(i, 0)) {
+			hits++
+		}
+	}
+	success := (hits / float64(valOut.Len())) * 100
+	return success, nil
+}
+
+// ValidateBalanced runs forward propagation on the validation data set through neural network.
+// Unlike Validate it returns balanced accuracy: the mean of per class percentages of successful
+// classifications, calculated over all classes present in the validation data set.
+// Balanced accuracy is not skewed by classes which are overrepresented in the validation data set.
+// It returns error if the supplied data set is invalid or if the forward propagation fails.
+func (n *Network) ValidateBalanced(valInMx *mat64.Dense, valOut *mat64.Vector) (float64, error) {
+	// validation set can't be nil
+	if valInMx == nil || valOut == nil {
+		return 0.0, fmt.Errorf("Cant validate data set. In: %v, Out: %v\n", valInMx, valOut)
+	}
+	predicted, _, err := n.Predict(valInMx)
+	if err != nil {
+		return 0.0, err
+	}
+	// per class sample counts and hits
+	counts := make(map[int]float64)
+	hits := make(map[int]float64)
+	for i := 0; i < valOut.Len(); i++ {
+		label := int(valOut.At(i, 0))
+		counts[label]++
+		if label == int(predicted.At(i, 0)) {
+			hits[label]++
+		}
+	}
+	recall := 0.0
+	for label, count := range counts {
+		recall += hits[label] / count
+	}
+	success := (recall / float64(len(counts))) * 100
+	return success, nil
+}
+
+// ValidateTopK runs forward propagation on the validation data set through neural network.
+// Unlike Validate it considers a sample successfully classified if its label is among
+// the k network outputs with the highest activations. It returns the percentage of successful
+// classifications or error if the supplied data set is invalid or k is not between 1
+// and the number of network outputs.
+func (n *Network) ValidateTopK(valInMx *mat64.Dense, valOut *mat64.Vector, k int) (float64, error) {
+	// validation set can't be nil
+	if valInMx == nil || valOut == nil {
+		return 0.0, fmt.Errorf("Cant validate data set. In: %v, Out: %v\n", valInMx, valOut)
+	}
+	out, err := n.ForwardProp(valInMx, len(n.Layers())-1)
+	if err != nil {
+		return 0.0, err
+	}
+	rows, cols := out.Dims()
+	if k <= 0 || k > cols {
+		return 0.0, fmt.Errorf("Incorrect k supplied: %d\n", k)
+	}
 	outMx := out.(*mat64.Dense)
 	hits := 0.0
 	for i := 0; i < rows; i++ {
-		row := outMx.RowView(i)
-		max := mat64.Max(row)
-		for j := 0; j < row.Len(); j++ {
-			if row.At(j, 0) == max {
-				if j+1 == int(valOut.At(i, 0)) {
-					hits++
-					break
-				}
+		label := int(valOut.At(i, 0))
+		if label <= 0 || label > cols {
+			continue
+		}
+		row := outMx.RawRowView(i)
+		// count outputs with higher activation than the expected label
+		higher := 0
+		for j := range row {
+			if row[j] > row[label-1] {
+				higher++
 			}
 		}
+		if higher < k {
+			hits++
+		}
 	}
 	success := (hits / float64(valOut.Len())) * 100
 	return success, nil
 }
 
-// setNetWeights sets weights of provided network layers to values supplied via weights slice
-// The new weights are stored in weights slice which is then rolled into particular layer's
-// weights matrix layer by layer. It fails with error if the supplied weights slice
-// does not contain enough elements
+// Prune prunes weights of all network layers whose absolute value is smaller than threshold.
+// Pruned layers use sparse weights representation in forward propagation until the network
+// weights change, i.e. until the network is trained again. It returns the number of pruned weights.
+// It fails with error if the threshold is negative.
+func (n *Network) Prune(threshold float64) (int, error) {
+	pruned := 0
+	for _, layer := range n.Layers()[1:] {
+		count, err := layer.Prune(threshold)
+		if err != nil {
+			return pruned, err
+		}
+		pruned += count
+	}
+	return pruned, nil
+}
+
+// netWeights returns weights of provided network layers rolled into a single slice.
+// Weights matrices are rolled row by row, layer by layer.
+func netWeights(layers []*Layer) []float64 {
+	var weights []float64
+	for _, layer := range layers {
+		layerWeights, _ := matrix.Flatten(layer.weights, matrix.RowMajor)
+		weights = append(weights, layerWeights...)
+	}
+	return weights
+}
+
+// setNetWeights sets weights of provided network layers to values supplied via weights slice.
+// Weights are not copied: layer weights matrices become views of weights slice segments laid out
+// the same way as the slice returned by netWeights, so the weights slice must not be modified
+// unless the network weights are supposed to change too. It fails with error if the supplied
+// weights slice does not contain enough elements
 func setNetWeights(layers []*Layer, weights []float64) error {
 	acc := 0
 	wLen := len(weights)
 	for _, layer := range layers {
-		r, c := layer.Weights().Dims()
+		r, c := layer.weights.Dims()
 		if (wLen - acc) < r*c {
 			return fmt.Errorf("Insufficient number of weights supplied %d\n", wLen)
 		}
-		err := matrix.SetMx2Vec(layer.Weights(), weights[acc:(acc+r*c)], false)
-		if err != nil {
-			return err
-		}
+		layer.weights = mat64.NewDense(r, c, weights[acc:(acc+r*c)])
+		// sparse weights are no longer valid
+		layer.sparse = nil
 		acc += r * c
 	}
 	return nil
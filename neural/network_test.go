@@ -1,16 +1,24 @@
 package neural
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
+	"math/rand"
 	"os"
 	"path"
 	"path/filepath"
 	"testing"
 
+	"github.com/gonum/blas"
+	"github.com/gonum/blas/blas64"
 	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize"
 	"github.com/milosgajdos83/go-neural/pkg/config"
-	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"github.com/milosgajdos83/go-neural/pkg/logger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -155,6 +163,29 @@ func TestNewNetwork(t *testing.T) {
 	c.Arch.Output.Size = origOutSize
 }
 
+func TestNewNetworkWithRand(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// networks created from the same source are identical
+	n1, err := NewNetworkWithRand(conf.Network, rand.New(rand.NewSource(7)))
+	assert.NoError(err)
+	n2, err := NewNetworkWithRand(conf.Network, rand.New(rand.NewSource(7)))
+	assert.NoError(err)
+	assert.Equal(n1.ID(), n2.ID())
+	for i, layer := range n1.Layers()[1:] {
+		assert.Equal(layer.ID(), n2.Layers()[i+1].ID())
+		assert.True(mat64.Equal(layer.Weights(), n2.Layers()[i+1].Weights()))
+	}
+	// different sources create different networks
+	n3, err := NewNetworkWithRand(conf.Network, rand.New(rand.NewSource(8)))
+	assert.NoError(err)
+	assert.False(mat64.Equal(n1.Layers()[1].Weights(), n3.Layers()[1].Weights()))
+}
+
 func TestAddLayer(t *testing.T) {
 	assert := assert.New(t)
 	// basic configuration settings
@@ -201,7 +232,29 @@ func TestID(t *testing.T) {
 	n, err := NewNetwork(c.Network)
 	assert.NotNil(n)
 	assert.NoError(err)
-	assert.Len(n.ID(), 10)
+	// ids are derived from network architecture
+	assert.Equal("feedfwd-4-5-5", n.ID())
+	expIDs := []string{"input", "hidden1", "output"}
+	for i, layer := range n.Layers() {
+		assert.Equal(expIDs[i], layer.ID())
+	}
+	// caller-supplied ids
+	c.Network.ID = "net"
+	c.Network.Arch.Hidden[0].ID = "features"
+	defer func() {
+		c.Network.ID = ""
+		c.Network.Arch.Hidden[0].ID = ""
+	}()
+	n, err = NewNetwork(c.Network)
+	assert.NoError(err)
+	assert.Equal("net", n.ID())
+	assert.Equal("features", n.Layers()[1].ID())
+	assert.Equal("output", n.Layers()[2].ID())
+	// layer ids must be unique
+	c.Network.Arch.Hidden[0].ID = "output"
+	n, err = NewNetwork(c.Network)
+	assert.Nil(n)
+	assert.Error(err)
 }
 
 func TestKind(t *testing.T) {
@@ -369,6 +422,51 @@ func TestBackProp(t *testing.T) {
 	// negative from boundary throws error
 	err = net.BackProp(sampleVec.T(), errVec.T(), 100)
 	assert.Error(err)
+	// single sample updates accumulate the same deltas as a batch update
+	outLayer := layers[len(layers)-1]
+	batchErr := out.(*mat64.Dense)
+	before := outLayer.Deltas()
+	err = net.BackProp(inMx, batchErr, len(layers)-1)
+	assert.NoError(err)
+	batch := outLayer.Deltas()
+	for i := 0; i < 5; i++ {
+		err = net.BackProp(inMx.RowView(i).T(), batchErr.RowView(i).T(), len(layers)-1)
+		assert.NoError(err)
+	}
+	single := outLayer.Deltas()
+	// compare the increments of deltas
+	single.Sub(single, batch)
+	batch.Sub(batch, before)
+	assert.True(mat64.EqualApprox(batch, single, 1e-9))
+}
+
+func TestForwardCache(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	layers := n.Layers()
+	cache, err := n.forwardCache(inMx)
+	assert.NotNil(cache)
+	assert.NoError(err)
+	assert.Len(cache.out, len(layers))
+	assert.Len(cache.actIn, len(layers))
+	// cached outputs must match forward propagation outputs
+	for i := range layers {
+		out, err := n.ForwardProp(inMx, i)
+		assert.NoError(err)
+		assert.True(mat64.EqualApprox(out, cache.out[i], 1e-12))
+	}
+	// incorrect input dimensions
+	cache, err = n.forwardCache(mat64.NewDense(2, 20, nil))
+	assert.Nil(cache)
+	assert.Error(err)
 }
 
 func TestValidateTrainConfig(t *testing.T) {
@@ -400,6 +498,11 @@ func TestValidateTrainConfig(t *testing.T) {
 	err = ValidateTrainConfig(c)
 	assert.Error(err)
 	c.Lambda = origLambda
+	// wrong concurrency
+	c.Concurrency = -1
+	err = ValidateTrainConfig(c)
+	assert.Error(err)
+	c.Concurrency = 0
 	// unsupported Optimization method
 	origMethod := c.Optimize.Method
 	c.Optimize.Method = "foobar"
@@ -412,6 +515,20 @@ func TestValidateTrainConfig(t *testing.T) {
 	err = ValidateTrainConfig(c)
 	assert.Error(err)
 	c.Optimize.Iterations = origIters
+	// wrong evaluation limits
+	c.Optimize.FuncEvaluations = -1
+	err = ValidateTrainConfig(c)
+	assert.Error(err)
+	c.Optimize.FuncEvaluations = 0
+	c.Optimize.GradEvaluations = -1
+	err = ValidateTrainConfig(c)
+	assert.Error(err)
+	c.Optimize.GradEvaluations = 0
+	// wrong runtime limit
+	c.Optimize.Runtime = -1
+	err = ValidateTrainConfig(c)
+	assert.Error(err)
+	c.Optimize.Runtime = 0
 }
 
 func TestTrain(t *testing.T) {
@@ -436,9 +553,389 @@ func TestTrain(t *testing.T) {
 	// nil labelsVec causes error
 	err = n.Train(trainConf, inMx, nil)
 	assert.Error(err)
+	// mismatched labelsVec causes error
+	err = n.Train(trainConf, inMx, mat64.NewVector(2, []float64{1.0, 2.0}))
+	assert.Error(err)
+	// failed cost evaluation returns error instead of panicking
+	badLabels := mat64.NewVector(labelsVec.Len(), nil)
+	badLabels.CopyVec(labelsVec)
+	badLabels.SetVec(0, 100.0)
+	assert.NotPanics(func() {
+		err = n.Train(trainConf, inMx, badLabels)
+	})
+	assert.Error(err)
 	// calculate cost
 	err = n.Train(trainConf, inMx, labelsVec)
 	assert.NoError(err)
+	// training records metadata
+	meta := n.Metadata()
+	assert.False(meta.Trained.IsZero())
+	assert.True(meta.Iterations > 0)
+	assert.True(meta.Cost > 0.0)
+}
+
+func TestRegisterOptimMethod(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// incorrect registrations
+	assert.Error(RegisterOptimMethod("", &optimize.GradientDescent{}))
+	assert.Error(RegisterOptimMethod("gd", nil))
+	// unregistered method can't be used for training
+	trainConf := *conf.Training
+	trainConf.Optimize = &config.OptimConfig{Method: "gd", Iterations: 2}
+	assert.Error(n.Train(&trainConf, inMx, labelsVec))
+	// registered method can be used both in manifests and in training
+	assert.NoError(RegisterOptimMethod("gd", &optimize.GradientDescent{}))
+	m, err := config.LoadManifest(tmpPath)
+	assert.NoError(err)
+	m.Training.Optimize.Method = "gd"
+	_, err = config.ParseManifest(m)
+	assert.NoError(err)
+	assert.NoError(n.Train(&trainConf, inMx, labelsVec))
+}
+
+func TestTrainMonitored(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	trainConf := conf.Training
+	// incomplete validation data set
+	m := &Monitor{ValInMx: inMx}
+	history, err := n.TrainMonitored(trainConf, inMx, labelsVec, m)
+	assert.Nil(history)
+	assert.Error(err)
+	// mismatched validation data set
+	m.ValLabels = mat64.NewVector(2, []float64{1.0, 2.0})
+	history, err = n.TrainMonitored(trainConf, inMx, labelsVec, m)
+	assert.Nil(history)
+	assert.Error(err)
+	// validate in every iteration and count callback calls
+	calls := 0
+	m.ValLabels = labelsVec
+	m.Every = 1
+	m.Callbacks = []Callback{func(m *Metrics) error {
+		calls++
+		return nil
+	}}
+	history, err = n.TrainMonitored(trainConf, inMx, labelsVec, m)
+	assert.NoError(err)
+	assert.Len(history, calls)
+	for i, metrics := range history {
+		assert.Equal(i+1, metrics.Iter)
+		assert.True(metrics.Validated)
+		assert.True(metrics.ValAccuracy >= 0.0)
+		assert.True(metrics.GradNorm > 0.0)
+	}
+	// gradient evaluations limit stops the training without error
+	trainConf.Optimize.GradEvaluations = 1
+	history, err = n.TrainMonitored(trainConf, inMx, labelsVec, nil)
+	assert.NoError(err)
+	assert.True(len(history) <= 1)
+	trainConf.Optimize.GradEvaluations = 0
+	// callback error stops the training
+	m.Callbacks = []Callback{func(m *Metrics) error {
+		return fmt.Errorf("stop")
+	}}
+	history, err = n.TrainMonitored(trainConf, inMx, labelsVec, m)
+	assert.Error(err)
+	assert.Len(history, 1)
+	// non-finite cost is reported as divergence
+	nanInMx := mat64.DenseCopyOf(inMx)
+	nanInMx.Set(0, 0, math.NaN())
+	history, err = n.TrainMonitored(trainConf, nanInMx, labelsVec, m)
+	assert.Error(err)
+	assert.Contains(err.Error(), "diverged")
+	assert.Empty(history)
+}
+
+func TestHistoryPlot(t *testing.T) {
+	assert := assert.New(t)
+	h := History{
+		{Iter: 1, Cost: 2.0, Validated: true, ValCost: 2.5},
+		{Iter: 2, Cost: 1.0},
+	}
+	// plots are only available if the package is built with plot build tag
+	for _, format := range []string{"png", "svg"} {
+		var buf bytes.Buffer
+		err := h.Plot(&buf, format)
+		if CanPlot(format) {
+			assert.NoError(err)
+			assert.True(buf.Len() > 0)
+			continue
+		}
+		assert.Error(err)
+	}
+	assert.False(CanPlot("foo"))
+	assert.Error(h.Plot(ioutil.Discard, "foo"))
+}
+
+func TestSetLogger(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	defer SetLogger(nil)
+	// debug level logs cost traces
+	buf := new(bytes.Buffer)
+	SetLogger(logger.New(buf, logger.Debug))
+	err = n.Train(conf.Training, inMx, labelsVec)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "Current cost")
+	assert.Contains(buf.String(), "Result status")
+	// quiet level does not log anything
+	buf.Reset()
+	SetLogger(logger.New(buf, logger.Quiet))
+	err = n.Train(conf.Training, inMx, labelsVec)
+	assert.NoError(err)
+	assert.Empty(buf.String())
+}
+
+func TestCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	ckptPath := filepath.Join(dir, "checkpoint.json")
+	// save the network in every other iteration
+	m := &Monitor{Callbacks: []Callback{Checkpoint(n, ckptPath, 2)}}
+	history, err := n.TrainMonitored(conf.Training, inMx, labelsVec, m)
+	assert.NoError(err)
+	assert.True(len(history) >= 2)
+	f, err := os.Open(ckptPath)
+	assert.NoError(err)
+	defer f.Close()
+	ckpt, err := Load(f)
+	assert.NoError(err)
+	assert.Equal(len(history)/2*2, ckpt.Metadata().Iterations)
+	// checkpointed network can be trained again
+	_, err = ckpt.TrainMonitored(conf.Training, inMx, labelsVec, nil)
+	assert.NoError(err)
+	// no temporary files are left behind
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(err)
+	assert.Len(files, 1)
+	// nonexistent checkpoint directory
+	m.Callbacks = []Callback{Checkpoint(n, filepath.Join(dir, "foo", "bar.json"), 0)}
+	_, err = n.TrainMonitored(conf.Training, inMx, labelsVec, m)
+	assert.Error(err)
+}
+
+func TestGetGradient(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// log-likelihood with softmax output has an exact output layer delta
+	trainConf := conf.Training
+	trainConf.Cost = "loglike"
+	ws := new(workspace)
+	for _, lambda := range []float64{0.0, 1.0} {
+		trainConf.Lambda = lambda
+		weights := netWeights(n.Layers()[1:])
+		grad := make([]float64, len(weights))
+		err := n.getGradient(trainConf, ws, grad, weights, inMx, labelsVec)
+		assert.NoError(err)
+		// repeated gradient calculation yields the same results
+		grad2 := make([]float64, len(weights))
+		err = n.getGradient(trainConf, ws, grad2, weights, inMx, labelsVec)
+		assert.NoError(err)
+		for i := range grad {
+			assert.InDelta(grad[i], grad2[i], 1e-9)
+		}
+		// compare the gradient with numerical gradient
+		eps := 1e-5
+		for i := range weights {
+			orig := weights[i]
+			weights[i] = orig + eps
+			costPlus, err := n.getCost(trainConf, ws, weights, inMx, labelsVec)
+			assert.NoError(err)
+			weights[i] = orig - eps
+			costMinus, err := n.getCost(trainConf, ws, weights, inMx, labelsVec)
+			assert.NoError(err)
+			weights[i] = orig
+			assert.InDelta((costPlus-costMinus)/(2*eps), grad[i], 1e-6)
+		}
+		// gradient slice must be big enough
+		err = n.getGradient(trainConf, ws, grad[1:], weights, inMx, labelsVec)
+		assert.Error(err)
+	}
+	// gradient calculated concurrently must be the same as the sequential one
+	weights := netWeights(n.Layers()[1:])
+	trainConf.Concurrency = 1
+	seqGrad := make([]float64, len(weights))
+	err = n.getGradient(trainConf, ws, seqGrad, weights, inMx, labelsVec)
+	assert.NoError(err)
+	for _, workers := range []int{2, 3, 100} {
+		trainConf.Concurrency = workers
+		parGrad := make([]float64, len(weights))
+		err = n.getGradient(trainConf, ws, parGrad, weights, inMx, labelsVec)
+		assert.NoError(err)
+		for i := range seqGrad {
+			assert.InDelta(seqGrad[i], parGrad[i], 1e-12)
+		}
+	}
+}
+
+// gemmCounter counts level-3 BLAS matrix multiplications
+type gemmCounter struct {
+	blas.Float64
+	calls int
+}
+
+func (g *gemmCounter) Dgemm(tA, tB blas.Transpose, m, n, k int, alpha float64, a []float64, lda int,
+	b []float64, ldb int, beta float64, c []float64, ldc int) {
+	g.calls++
+	g.Float64.Dgemm(tA, tB, m, n, k, alpha, a, lda, b, ldb, beta, c, ldc)
+}
+
+func TestGradientBLAS(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	layers := n.Layers()
+	// count matrix multiplications of the current BLAS implementation
+	impl := blas64.Implementation()
+	defer blas64.Use(impl)
+	counter := &gemmCounter{Float64: impl}
+	blas64.Use(counter)
+	trainConf := conf.Training
+	trainConf.Concurrency = 1
+	weights := netWeights(layers[1:])
+	// every layer is activated via single multiplication
+	ws := new(workspace)
+	_, err = n.getCost(trainConf, ws, weights, inMx, labelsVec)
+	assert.NoError(err)
+	assert.Equal(len(layers)-1, counter.calls)
+	// backpropagation adds one multiplication per layer to update deltas
+	// and one per HIDDEN layer to propagate the errors
+	counter.calls = 0
+	grad := make([]float64, len(weights))
+	err = n.getGradient(trainConf, ws, grad, weights, inMx, labelsVec)
+	assert.NoError(err)
+	assert.Equal(3*(len(layers)-1)-1, counter.calls)
+}
+
+func TestNetworkPrune(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	expOut, err := n.ForwardProp(inMx, len(n.Layers())-1)
+	assert.NoError(err)
+	// negative threshold
+	_, err = n.Prune(-1.0)
+	assert.Error(err)
+	// zero threshold does not change the network output
+	pruned, err := n.Prune(0.0)
+	assert.Equal(0, pruned)
+	assert.NoError(err)
+	for _, layer := range n.Layers()[1:] {
+		assert.True(layer.Sparse())
+	}
+	out, err := n.ForwardProp(inMx, len(n.Layers())-1)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(expOut, out, 1e-9))
+	// pruning all the weights
+	pruned, err = n.Prune(2.0)
+	assert.True(pruned > 0)
+	assert.NoError(err)
+	// changing network weights drops sparse representation
+	weights := netWeights(n.Layers()[1:])
+	err = setNetWeights(n.Layers()[1:], weights)
+	assert.NoError(err)
+	for _, layer := range n.Layers()[1:] {
+		assert.False(layer.Sparse())
+	}
+}
+
+func TestConcurrentInference(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	expClass, err := n.Classify(inMx)
+	assert.NoError(err)
+	expLabels, _, err := n.Predict(inMx)
+	assert.NoError(err)
+	// classify the same data from multiple goroutines
+	workers := 8
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			classMx, err := n.Classify(inMx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			labels, _, err := n.Predict(inMx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !mat64.Equal(expClass, classMx) || !mat64.Equal(expLabels, labels) {
+				errs <- fmt.Errorf("Concurrent inference results differ")
+				return
+			}
+			errs <- nil
+		}()
+	}
+	for i := 0; i < workers; i++ {
+		assert.NoError(<-errs)
+	}
 }
 
 func TestClassify(t *testing.T) {
@@ -477,6 +974,113 @@ func TestClassify(t *testing.T) {
 	assert.Equal(oCols, netConf.Arch.Output.Size)
 }
 
+func TestClassifyContext(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// nil input throws error
+	classOut, err := n.ClassifyContext(context.Background(), nil)
+	assert.Nil(classOut)
+	assert.Error(err)
+	// results must match Classify regardless of chunk size
+	expOut, err := n.Classify(inMx)
+	assert.NoError(err)
+	defer func(chunk int) { classifyChunk = chunk }(classifyChunk)
+	for _, chunk := range []int{1, 2, 256} {
+		classifyChunk = chunk
+		classOut, err = n.ClassifyContext(context.Background(), inMx)
+		assert.NoError(err)
+		assert.True(mat64.EqualApprox(expOut, classOut, 1e-12))
+	}
+	// cancelled context stops classification
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	classOut, err = n.ClassifyContext(ctx, inMx)
+	assert.Nil(classOut)
+	assert.Equal(context.Canceled, err)
+}
+
+func TestClassifyBatch(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	inRows, _ := inMx.Dims()
+	outCols := conf.Network.Arch.Output.Size
+	out := mat64.NewDense(inRows, outCols, nil)
+	// nil input or output throws error
+	err = n.ClassifyBatch(nil, out)
+	assert.Error(err)
+	err = n.ClassifyBatch(inMx, nil)
+	assert.Error(err)
+	// incorrect output dimensions
+	err = n.ClassifyBatch(inMx, mat64.NewDense(inRows, outCols+1, nil))
+	assert.Error(err)
+	// incorrect input dimensions
+	err = n.ClassifyBatch(mat64.NewDense(inRows, 20, nil), out)
+	assert.Error(err)
+	// results must match Classify
+	expOut, err := n.Classify(inMx)
+	assert.NoError(err)
+	for i := 0; i < 3; i++ {
+		err = n.ClassifyBatch(inMx, out)
+		assert.NoError(err)
+		assert.True(mat64.EqualApprox(expOut, out, 1e-12))
+	}
+	// batches of different sizes
+	single := mat64.NewDense(1, outCols, nil)
+	err = n.ClassifyBatch(inMx.RowView(0).T(), single)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(expOut.(*mat64.Dense).RowView(0).T(), single, 1e-12))
+}
+
+func TestPredict(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	netConf := conf.Network
+	n, err := NewNetwork(netConf)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// nil input throws error
+	labels, probs, err := n.Predict(nil)
+	assert.Nil(labels)
+	assert.Nil(probs)
+	assert.Error(err)
+	// predict the features input
+	labels, probs, err = n.Predict(inMx)
+	assert.NoError(err)
+	classOut, err := n.Classify(inMx)
+	assert.NoError(err)
+	inRows, _ := inMx.Dims()
+	assert.Equal(inRows, labels.Len())
+	assert.Equal(inRows, probs.Len())
+	for i := 0; i < inRows; i++ {
+		label := int(labels.At(i, 0))
+		assert.True(label >= 1 && label <= netConf.Arch.Output.Size)
+		// probability matches the classification percentage
+		assert.InDelta(classOut.At(i, label-1)/100.0, probs.At(i, 0), 0.0001)
+		assert.InDelta(mat64.Max(classOut.(*mat64.Dense).RowView(i))/100.0, probs.At(i, 0), 0.0001)
+	}
+}
+
 func TestValidate(t *testing.T) {
 	assert := assert.New(t)
 	// basic configuration settings
@@ -506,6 +1110,82 @@ func TestValidate(t *testing.T) {
 	assert.True(success < 100.0)
 }
 
+func TestValidateBalanced(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	netConf := conf.Network
+	n, err := NewNetwork(netConf)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// nil input throws error
+	success, err := n.ValidateBalanced(nil, labelsVec)
+	assert.Error(err)
+	assert.True(success == 0.0)
+	// all samples labeled as predicted: 100% balanced accuracy
+	predicted, _, err := n.Predict(inMx)
+	assert.NoError(err)
+	success, err = n.ValidateBalanced(inMx, predicted)
+	assert.NoError(err)
+	assert.Equal(100.0, success)
+	// force the network to classify every sample as class 1
+	layers := n.Layers()
+	outLayer := layers[len(layers)-1]
+	r, c := outLayer.Weights().Dims()
+	weights := mat64.NewDense(r, c, nil)
+	weights.Set(0, 0, 10.0)
+	assert.NoError(outLayer.SetWeights(weights))
+	// imbalanced labels: class 1 is always hit, class 2 is always missed
+	imbalanced := mat64.NewVector(5, []float64{1.0, 1.0, 1.0, 1.0, 2.0})
+	success, err = n.ValidateBalanced(inMx, imbalanced)
+	assert.NoError(err)
+	assert.InDelta(50.0, success, 0.0001)
+	plain, err := n.Validate(inMx, imbalanced)
+	assert.NoError(err)
+	assert.InDelta(80.0, plain, 0.0001)
+}
+
+func TestValidateTopK(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	netConf := conf.Network
+	n, err := NewNetwork(netConf)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// expected labels
+	expVal := []float64{2, 1, 3, 2, 4}
+	expVec := mat64.NewVector(len(expVal), expVal)
+	// nil input throws error
+	success, err := n.ValidateTopK(nil, expVec, 1)
+	assert.Error(err)
+	assert.True(success == 0.0)
+	// incorrect k throws error
+	for _, k := range []int{0, netConf.Arch.Output.Size + 1} {
+		success, err = n.ValidateTopK(inMx, expVec, k)
+		assert.Error(err)
+		assert.True(success == 0.0)
+	}
+	// top-1 accuracy is the same as the accuracy returned by Validate
+	top1, err := n.ValidateTopK(inMx, expVec, 1)
+	assert.NoError(err)
+	success, err = n.Validate(inMx, expVec)
+	assert.NoError(err)
+	assert.Equal(success, top1)
+	// all labels are among all outputs
+	success, err = n.ValidateTopK(inMx, expVec, netConf.Arch.Output.Size)
+	assert.NoError(err)
+	assert.Equal(100.0, success)
+}
+
 func TestSetNetWeights(t *testing.T) {
 	assert := assert.New(t)
 	// basic configuration settings
@@ -526,15 +1206,94 @@ func TestSetNetWeights(t *testing.T) {
 		acc += r * c
 	}
 	weights := make([]float64, acc)
-	var netWeights []float64
+	for i := range weights {
+		weights[i] = float64(i)
+	}
 	err = setNetWeights(layers[1:], weights)
 	assert.NoError(err)
-	for i := range layers[1:] {
-		netWeights = append(netWeights, matrix.Mx2Vec(layers[i+1].Weights(), false)...)
-	}
-	assert.Equal(weights, netWeights)
+	assert.Equal(weights, netWeights(layers[1:]))
+	// layer weights are views of weights slice
+	weights[0] = 100.0
+	assert.Equal(100.0, layers[1].Weights().At(0, 0))
 	// incorrect length of weights
 	weights = make([]float64, 5)
 	err = setNetWeights(layers[1:], weights)
 	assert.Error(err)
 }
+
+func TestHighway(t *testing.T) {
+	assert := assert.New(t)
+	// highway layers carry their input so their size must match it
+	n, err := NewFeedForward().Input(4).Hidden(3, "tanh").Highway("relu").Highway("tanh").Output(5, "softmax").
+		Rand(rand.New(rand.NewSource(1))).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	layers := n.Layers()
+	assert.False(layers[1].Highway())
+	assert.True(layers[2].Highway())
+	rows, cols := layers[2].Weights().Dims()
+	assert.Equal([2]int{6, 4}, [2]int{rows, cols})
+	// closed gates carry the input to the output
+	w := layers[2].Weights()
+	for i := 3; i < 6; i++ {
+		w.Set(i, 0, -1000.0)
+	}
+	assert.NoError(layers[2].SetWeights(w))
+	hidOut, err := n.ForwardProp(inMx, 1)
+	assert.NoError(err)
+	hwOut, err := n.ForwardProp(inMx, 2)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(hidOut, hwOut, 1e-9))
+	// analytic gradient matches numerical gradient
+	c := &config.TrainConfig{Cost: "loglike", Lambda: 0.5}
+	ws := new(workspace)
+	rnd := rand.New(rand.NewSource(2))
+	weights := netWeights(layers[1:])
+	for i := range weights {
+		weights[i] = rnd.NormFloat64()
+	}
+	grad := make([]float64, len(weights))
+	assert.NoError(n.getGradient(c, ws, grad, weights, inMx, labelsVec))
+	eps := 1e-5
+	for i := range weights {
+		orig := weights[i]
+		weights[i] = orig + eps
+		costPlus, err := n.getCost(c, ws, weights, inMx, labelsVec)
+		assert.NoError(err)
+		weights[i] = orig - eps
+		costMinus, err := n.getCost(c, ws, weights, inMx, labelsVec)
+		assert.NoError(err)
+		weights[i] = orig
+		assert.InDelta((costPlus-costMinus)/(2*eps), grad[i], 1e-6)
+	}
+	// concurrent gradient workers carry the errors the same way
+	c.Concurrency = 2
+	concGrad := make([]float64, len(weights))
+	assert.NoError(n.getGradient(c, new(workspace), concGrad, weights, inMx, labelsVec))
+	for i := range grad {
+		assert.InDelta(grad[i], concGrad[i], 1e-9)
+	}
+	// saved highway layers are loaded back
+	var buf bytes.Buffer
+	assert.NoError(n.Save(&buf))
+	loaded, err := Load(&buf)
+	assert.NoError(err)
+	assert.True(loaded.Layers()[2].Highway())
+	out, err := n.Classify(inMx)
+	assert.NoError(err)
+	loadedOut, err := loaded.Classify(inMx)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(out, loadedOut, 1e-12))
+	// incorrect highway layers
+	n, err = NewFeedForward().Input(4).Highway("softmax").Output(5, "softmax").Build()
+	assert.Nil(n)
+	assert.Error(err)
+	c2 := &config.LayerConfig{Kind: "hidden", Size: 3, NeurFn: &config.NeuronConfig{Activation: "relu"}, Highway: true}
+	layer, err := NewLayer(c2, 4)
+	assert.Nil(layer)
+	assert.Error(err)
+	c2.Kind = "output"
+	layer, err = NewLayer(c2, 3)
+	assert.Nil(layer)
+	assert.Error(err)
+}
@@ -0,0 +1,278 @@
+package neural
+
+import (
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+)
+
+const (
+	// DefaultCost is the cost function used by TrainWithOptions unless configured otherwise
+	DefaultCost = "xentropy"
+	// DefaultMethod is the optimization method used by TrainWithOptions unless configured otherwise
+	DefaultMethod = "bfgs"
+	// DefaultIterations is the number of iterations used by TrainWithOptions unless configured otherwise
+	DefaultIterations = 100
+)
+
+// trainOptions holds training configuration assembled by TrainOption functions
+type trainOptions struct {
+	c       *config.TrainConfig
+	monitor *Monitor
+}
+
+// optimConfig returns optimization configuration creating it if it does not exist yet
+func (o *trainOptions) optimConfig() *config.OptimConfig {
+	if o.c.Optimize == nil {
+		o.c.Optimize = new(config.OptimConfig)
+	}
+	return o.c.Optimize
+}
+
+// monitorConfig returns training monitor creating it if it does not exist yet
+func (o *trainOptions) monitorConfig() *Monitor {
+	if o.monitor == nil {
+		o.monitor = new(Monitor)
+	}
+	return o.monitor
+}
+
+// TrainOption configures neural network training run by TrainWithOptions
+type TrainOption func(*trainOptions)
+
+// WithConfig replaces the whole training configuration with a copy of the supplied configuration.
+// Options are applied in the order they are passed, so any option which follows WithConfig
+// modifies the supplied configuration and WithConfig overrides any option which precedes it.
+// Nil configuration is ignored.
+func WithConfig(c *config.TrainConfig) TrainOption {
+	return func(o *trainOptions) {
+		if c == nil {
+			return
+		}
+		tc := *c
+		if c.Optimize != nil {
+			optim := *c.Optimize
+			tc.Optimize = &optim
+		}
+		if c.Evolution != nil {
+			evol := *c.Evolution
+			tc.Evolution = &evol
+		}
+		if c.Pretrain != nil {
+			pre := *c.Pretrain
+			tc.Pretrain = &pre
+		}
+		o.c = &tc
+	}
+}
+
+// WithCost sets training cost function: xentropy or loglike
+func WithCost(cost string) TrainOption {
+	return func(o *trainOptions) {
+		o.c.Cost = cost
+	}
+}
+
+// WithLambda sets regularization parameter
+func WithLambda(lambda float64) TrainOption {
+	return func(o *trainOptions) {
+		o.c.Lambda = lambda
+	}
+}
+
+// WithConcurrency sets the number of goroutines used to calculate gradient
+func WithConcurrency(workers int) TrainOption {
+	return func(o *trainOptions) {
+		o.c.Concurrency = workers
+	}
+}
+
+// WithCheckFinite enables aborting the training when cost or gradient become NaN or infinite
+func WithCheckFinite(check bool) TrainOption {
+	return func(o *trainOptions) {
+		o.c.CheckFinite = check
+	}
+}
+
+// WithSWA enables stochastic weight averaging of training iterations since start.
+// Weights are sampled every given number of iterations and the network is set to
+// their average when the training finishes.
+func WithSWA(start, every int) TrainOption {
+	return func(o *trainOptions) {
+		o.c.SWAStart = start
+		o.c.SWAEvery = every
+	}
+}
+
+// WithEvolution switches the training to evolutionary training configured by a copy of c
+func WithEvolution(c config.EvolutionConfig) TrainOption {
+	return func(o *trainOptions) {
+		o.c.Kind = evolutionKind
+		o.c.Evolution = &c
+	}
+}
+
+// WithPretrain requests pretraining of hidden layers configured by a copy of c before the training.
+// Networks which have already been trained are not pretrained.
+func WithPretrain(c config.PretrainConfig) TrainOption {
+	return func(o *trainOptions) {
+		o.c.Pretrain = &c
+	}
+}
+
+// WithMethod sets optimization method
+func WithMethod(method string) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().Method = method
+	}
+}
+
+// WithIterations sets the number of optimization iterations
+func WithIterations(iters int) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().Iterations = iters
+	}
+}
+
+// WithEpochs sets the number of passes through the training data set of epoch based methods
+func WithEpochs(epochs int) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().Epochs = epochs
+	}
+}
+
+// WithBatchSize sets the number of samples used to calculate each gradient descent step
+func WithBatchSize(size int) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().BatchSize = size
+	}
+}
+
+// WithLearningRate sets gradient descent step size
+func WithLearningRate(rate float64) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().LearningRate = rate
+	}
+}
+
+// WithMomentum sets gradient descent momentum
+func WithMomentum(momentum float64) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().Momentum = momentum
+	}
+}
+
+// WithNesterov enables Nesterov momentum of gradient descent
+func WithNesterov(nesterov bool) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().Nesterov = nesterov
+	}
+}
+
+// WithClipNorm sets the maximum norm of gradient descent gradients: larger gradients are rescaled
+func WithClipNorm(norm float64) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().ClipNorm = norm
+	}
+}
+
+// WithSchedule sets gradient descent learning rate schedule. Cyclical schedules vary
+// the learning rate between the rate set by WithLearningRate and maxRate in cycles
+// of cycleLength gradient steps.
+func WithSchedule(schedule string, maxRate float64, cycleLength int) TrainOption {
+	return func(o *trainOptions) {
+		c := o.optimConfig()
+		c.Schedule = schedule
+		c.MaxLearningRate = maxRate
+		c.CycleLength = cycleLength
+	}
+}
+
+// WithEvaluations sets the maximum number of cost function and gradient evaluations.
+// If either of the limits is 0, the number of respective evaluations is not limited.
+func WithEvaluations(funcEvals, gradEvals int) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().FuncEvaluations = funcEvals
+		o.optimConfig().GradEvaluations = gradEvals
+	}
+}
+
+// WithRuntime sets the maximum optimization runtime
+func WithRuntime(runtime time.Duration) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().Runtime = runtime
+	}
+}
+
+// WithGradientThreshold sets the gradient infinity norm which stops the optimization
+func WithGradientThreshold(threshold float64) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().GradientThreshold = threshold
+	}
+}
+
+// WithConvergence stops the optimization if the cost does not decrease by more than
+// relative * |cost| + absolute in the given number of iterations
+func WithConvergence(absolute, relative float64, iters int) TrainOption {
+	return func(o *trainOptions) {
+		c := o.optimConfig()
+		c.ConvergeAbsolute = absolute
+		c.ConvergeRelative = relative
+		c.ConvergeIterations = iters
+	}
+}
+
+// WithLineSearch sets line search used by the optimization method: bisection, backtracking or morethuente
+func WithLineSearch(name string) TrainOption {
+	return func(o *trainOptions) {
+		o.optimConfig().LineSearch = name
+	}
+}
+
+// WithValidationSet sets the data set the network is evaluated on during training
+func WithValidationSet(valInMx *mat64.Dense, valLabels *mat64.Vector) TrainOption {
+	return func(o *trainOptions) {
+		m := o.monitorConfig()
+		m.ValInMx = valInMx
+		m.ValLabels = valLabels
+	}
+}
+
+// WithValidationEvery sets how often the network is evaluated on the validation data set
+func WithValidationEvery(every int) TrainOption {
+	return func(o *trainOptions) {
+		o.monitorConfig().Every = every
+	}
+}
+
+// WithCallbacks appends callbacks which are called with training metrics after every iteration
+func WithCallbacks(callbacks ...Callback) TrainOption {
+	return func(o *trainOptions) {
+		m := o.monitorConfig()
+		m.Callbacks = append(m.Callbacks, callbacks...)
+	}
+}
+
+// TrainWithOptions trains feedforward neural network the same way as TrainMonitored does, but
+// training configuration and monitoring are specified via options rather than config structs.
+// Unless the options say otherwise, the network is trained by backpropagation with DefaultCost
+// cost function, no regularization and DefaultIterations iterations of DefaultMethod optimization.
+// It returns training history or error if the resulting configuration is invalid or the training fails.
+func (n *Network) TrainWithOptions(inMx *mat64.Dense, labelsVec *mat64.Vector,
+	opts ...TrainOption) (History, error) {
+	o := &trainOptions{
+		c: &config.TrainConfig{
+			Kind: "backprop",
+			Cost: DefaultCost,
+			Optimize: &config.OptimConfig{
+				Method:     DefaultMethod,
+				Iterations: DefaultIterations,
+			},
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return n.TrainMonitored(o.c, inMx, labelsVec, o.monitor)
+}
@@ -0,0 +1,76 @@
+package neural
+
+import (
+	"math"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrainWithOptions(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	// invalid options cause error
+	history, err := n.TrainWithOptions(inMx, labelsVec, WithLambda(-1.0))
+	assert.Nil(history)
+	assert.Error(err)
+	history, err = n.TrainWithOptions(inMx, labelsVec, WithCost("foo"))
+	assert.Nil(history)
+	assert.Error(err)
+	history, err = n.TrainWithOptions(inMx, labelsVec, WithMethod("foo"))
+	assert.Nil(history)
+	assert.Error(err)
+	// options override supplied configuration which is left intact
+	iters := conf.Training.Optimize.Iterations
+	calls := 0
+	history, err = n.TrainWithOptions(inMx, labelsVec,
+		WithConfig(conf.Training),
+		WithIterations(2),
+		WithLambda(0.5),
+		WithValidationSet(inMx, labelsVec),
+		WithValidationEvery(1),
+		WithCallbacks(func(m *Metrics) error {
+			calls++
+			return nil
+		}))
+	assert.NoError(err)
+	assert.True(len(history) <= 2)
+	assert.Len(history, calls)
+	for _, metrics := range history {
+		assert.True(metrics.Validated)
+	}
+	assert.Equal(iters, conf.Training.Optimize.Iterations)
+	// convergence settings are passed to the optimization
+	history, err = n.TrainWithOptions(inMx, labelsVec, WithLineSearch("foo"))
+	assert.Nil(history)
+	assert.Error(err)
+	history, err = n.TrainWithOptions(inMx, labelsVec,
+		WithIterations(5),
+		WithGradientThreshold(1e-3),
+		WithConvergence(1e-8, 0.0, 2),
+		WithLineSearch("morethuente"))
+	assert.NoError(err)
+	assert.True(len(history) <= 5)
+	// non-finite input aborts the training when checked
+	nanInMx := mat64.DenseCopyOf(inMx)
+	nanInMx.Set(0, 0, math.NaN())
+	history, err = n.TrainWithOptions(nanInMx, labelsVec, WithIterations(3), WithCheckFinite(true))
+	assert.Error(err)
+	assert.Contains(err.Error(), "Non-finite")
+	// default configuration trains the network
+	history, err = n.TrainWithOptions(inMx, labelsVec, WithIterations(3), WithEvaluations(0, 0))
+	assert.NoError(err)
+	assert.NotEmpty(history)
+	assert.True(n.Metadata().Iterations > 0)
+}
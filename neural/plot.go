@@ -0,0 +1,75 @@
+//go:build plot
+// +build plot
+
+package neural
+
+import (
+	"image/color"
+	"io"
+
+	"github.com/gonum/plot"
+	"github.com/gonum/plot/plotter"
+	"github.com/gonum/plot/vg"
+)
+
+// gonum/plot renders plots in png and svg formats
+func init() {
+	for _, format := range []string{"png", "svg"} {
+		format := format
+		historyPlotters[format] = func(h History, w io.Writer) error {
+			return plotHistory(h, w, format)
+		}
+	}
+}
+
+// plotHistory plots training and validation cost curves of training history to w in the given format
+func plotHistory(h History, w io.Writer, format string) error {
+	p, err := plot.New()
+	if err != nil {
+		return err
+	}
+	p.Title.Text = "Training cost"
+	p.X.Label.Text = "Iteration"
+	p.Y.Label.Text = "Cost"
+	validated := 0
+	for _, m := range h {
+		if m.Validated {
+			validated++
+		}
+	}
+	train, val := make(plotter.XYs, len(h)), make(plotter.XYs, validated)
+	k := 0
+	for i, m := range h {
+		train[i].X, train[i].Y = float64(m.Iter), m.Cost
+		if m.Validated {
+			val[k].X, val[k].Y = float64(m.Iter), m.ValCost
+			k++
+		}
+	}
+	curves := []struct {
+		name  string
+		xys   plotter.XYs
+		color color.Color
+	}{
+		{"train", train, color.RGBA{R: 31, G: 119, B: 180, A: 255}},
+		{"validation", val, color.RGBA{R: 255, G: 127, B: 14, A: 255}},
+	}
+	for _, c := range curves {
+		if len(c.xys) == 0 {
+			continue
+		}
+		line, err := plotter.NewLine(c.xys)
+		if err != nil {
+			return err
+		}
+		line.Color = c.color
+		p.Add(line)
+		p.Legend.Add(c.name, line)
+	}
+	wt, err := p.WriterTo(6*vg.Inch, 4*vg.Inch, format)
+	if err != nil {
+		return err
+	}
+	_, err = wt.WriteTo(w)
+	return err
+}
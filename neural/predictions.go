@@ -0,0 +1,174 @@
+package neural
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// prediction is a single sample prediction written by PredictionWriter
+type prediction struct {
+	// ID is the sample number starting at 1
+	ID int `json:"id"`
+	// Label is the predicted label
+	Label float64 `json:"label"`
+	// Probabilities contains the probabilities of the sample belonging to each label class
+	Probabilities []float64 `json:"probabilities"`
+}
+
+// classLabels returns data set labels of network output classes: network labels are
+// mapped to data set labels if the network contains label map
+func classLabels(n *Network) ([]float64, error) {
+	if n == nil {
+		return nil, fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	layers := n.Layers()
+	classes, _ := layers[len(layers)-1].weights.Dims()
+	labels := make([]float64, classes)
+	for i := range labels {
+		labels[i] = float64(i + 1)
+	}
+	if lm := n.LabelMap(); lm != nil {
+		if len(lm) != classes {
+			return nil, fmt.Errorf("Label map size %d does not match network outputs: %d\n", len(lm), classes)
+		}
+		copy(labels, lm)
+	}
+	return labels, nil
+}
+
+// preprocess preprocesses features by the network pipeline and scales them by the network
+// scaler if the network contains them, so they match the network training samples
+func preprocess(n *Network, features mat64.Matrix) (mat64.Matrix, error) {
+	var err error
+	if p := n.Pipeline(); p != nil {
+		if features, err = p.Transform(features); err != nil {
+			return nil, err
+		}
+	}
+	if s := n.Scaler(); s != nil {
+		if features, err = s.Scale(features); err != nil {
+			return nil, err
+		}
+	}
+	return features, nil
+}
+
+// PredictionWriter classifies samples by neural network and writes the predictions to the underlying
+// writer in either CSV or JSON lines format. Each prediction contains sample id, predicted label and
+// the probabilities of the sample belonging to each label class. Sample ids start at 1 and continue
+// across Write calls. Predicted network labels are mapped to data set labels if the network contains
+// label map. CSV output starts with a header: id, label and one prob_<label> column per label class.
+type PredictionWriter struct {
+	net    *Network
+	labels []float64
+	csv    *csv.Writer
+	enc    *json.Encoder
+	id     int
+}
+
+// NewPredictionWriter creates new PredictionWriter which writes predictions of network n to w
+// in the given format: csv or jsonl. It fails with error if the format is not supported.
+func NewPredictionWriter(w io.Writer, n *Network, format string) (*PredictionWriter, error) {
+	labels, err := classLabels(n)
+	if err != nil {
+		return nil, err
+	}
+	pw := &PredictionWriter{net: n, labels: labels}
+	switch format {
+	case "csv":
+		pw.csv = csv.NewWriter(w)
+		header := []string{"id", "label"}
+		for _, label := range labels {
+			header = append(header, "prob_"+strconv.FormatFloat(label, 'f', -1, 64))
+		}
+		if err := pw.csv.Write(header); err != nil {
+			return nil, err
+		}
+	case "jsonl":
+		pw.enc = json.NewEncoder(w)
+	default:
+		return nil, fmt.Errorf("Unsupported predictions format: %s\n", format)
+	}
+	return pw, nil
+}
+
+// Write classifies the samples stored in rows of inMx and writes their predictions.
+// Samples must be scaled the same way as the network training samples.
+// It fails with error if the classification or writing fails.
+func (pw *PredictionWriter) Write(inMx mat64.Matrix) error {
+	if inMx == nil {
+		return fmt.Errorf("Can't predict %v\n", inMx)
+	}
+	out, err := pw.net.ForwardProp(inMx, len(pw.net.Layers())-1)
+	if err != nil {
+		return err
+	}
+	predLabels, err := matrix.LabelsFromMx(out)
+	if err != nil {
+		return err
+	}
+	samples, classes := out.Dims()
+	for i := 0; i < samples; i++ {
+		pw.id++
+		p := prediction{
+			ID:            pw.id,
+			Label:         pw.labels[int(predLabels.At(i, 0))-1],
+			Probabilities: make([]float64, classes),
+		}
+		mat64.Row(p.Probabilities, i, out)
+		floats.Scale(1/floats.Sum(p.Probabilities), p.Probabilities)
+		if pw.enc != nil {
+			if err := pw.enc.Encode(p); err != nil {
+				return err
+			}
+			continue
+		}
+		record := []string{strconv.Itoa(p.ID), strconv.FormatFloat(p.Label, 'f', -1, 64)}
+		for _, prob := range p.Probabilities {
+			record = append(record, strconv.FormatFloat(prob, 'f', -1, 64))
+		}
+		if err := pw.csv.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes any buffered predictions to the underlying writer
+func (pw *PredictionWriter) Flush() error {
+	if pw.csv != nil {
+		pw.csv.Flush()
+		return pw.csv.Error()
+	}
+	return nil
+}
+
+// WritePredictions classifies all samples of data set ds by network n and writes their predictions
+// to w in the given format: csv or jsonl. Samples are preprocessed by the network pipeline and scaled
+// by the network scaler if the network contains them. It fails with error if the format is not
+// supported or if the classification fails.
+func WritePredictions(w io.Writer, n *Network, ds *dataset.DataSet, format string) error {
+	if ds == nil {
+		return fmt.Errorf("Incorrect data set supplied: %v\n", ds)
+	}
+	pw, err := NewPredictionWriter(w, n, format)
+	if err != nil {
+		return err
+	}
+	features, err := preprocess(n, ds.Features())
+	if err != nil {
+		return err
+	}
+	if err := pw.Write(features); err != nil {
+		return err
+	}
+	return pw.Flush()
+}
@@ -0,0 +1,109 @@
+package neural
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredictionWriter(t *testing.T) {
+	assert := assert.New(t)
+	tmpPath := path.Join(os.TempDir(), fileName)
+	c, err := config.New(tmpPath)
+	assert.NotNil(c)
+	assert.NoError(err)
+	n, err := NewNetwork(c.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	labels, probs, err := n.Predict(inMx)
+	assert.NoError(err)
+	samples, _ := inMx.Dims()
+	// CSV predictions: ids continue across writes
+	var buf bytes.Buffer
+	pw, err := NewPredictionWriter(&buf, n, "csv")
+	assert.NotNil(pw)
+	assert.NoError(err)
+	assert.NoError(pw.Write(inMx.View(0, 0, 2, 4)))
+	assert.NoError(pw.Write(inMx.View(2, 0, samples-2, 4)))
+	assert.NoError(pw.Flush())
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(err)
+	assert.Len(records, samples+1)
+	assert.Equal([]string{"id", "label", "prob_1", "prob_2", "prob_3", "prob_4", "prob_5"}, records[0])
+	for i, record := range records[1:] {
+		assert.Equal(strconv.Itoa(i+1), record[0])
+		label, err := strconv.ParseFloat(record[1], 64)
+		assert.NoError(err)
+		assert.Equal(labels.At(i, 0), label)
+		prob, err := strconv.ParseFloat(record[1+int(label)], 64)
+		assert.NoError(err)
+		assert.InDelta(probs.At(i, 0), prob, 1e-9)
+	}
+	// JSON lines predictions are decoded to data set labels
+	n.SetLabelMap(dataset.LabelMap{10, 20, 30, 40, 50})
+	buf.Reset()
+	pw, err = NewPredictionWriter(&buf, n, "jsonl")
+	assert.NoError(err)
+	assert.NoError(pw.Write(inMx))
+	assert.NoError(pw.Flush())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(lines, samples)
+	for i, line := range lines {
+		var p prediction
+		assert.NoError(json.Unmarshal([]byte(line), &p))
+		assert.Equal(i+1, p.ID)
+		assert.Equal(10*labels.At(i, 0), p.Label)
+		assert.Len(p.Probabilities, 5)
+		assert.InDelta(probs.At(i, 0), p.Probabilities[int(labels.At(i, 0))-1], 1e-9)
+	}
+	// label map does not match network outputs
+	n.SetLabelMap(dataset.LabelMap{1, 2})
+	pw, err = NewPredictionWriter(&buf, n, "csv")
+	assert.Nil(pw)
+	assert.Error(err)
+	n.SetLabelMap(nil)
+	// unsupported format
+	pw, err = NewPredictionWriter(&buf, n, "xml")
+	assert.Nil(pw)
+	assert.Error(err)
+	// nil network
+	pw, err = NewPredictionWriter(&buf, nil, "csv")
+	assert.Nil(pw)
+	assert.Error(err)
+}
+
+func TestWritePredictions(t *testing.T) {
+	assert := assert.New(t)
+	tmpPath := path.Join(os.TempDir(), fileName)
+	c, err := config.New(tmpPath)
+	assert.NoError(err)
+	n, err := NewNetwork(c.Network)
+	assert.NoError(err)
+	// labeled data set
+	data := "5.1,3.5,1.4,0.1,2\n" +
+		"4.9,3.0,1.4,0.2,1\n" +
+		"4.7,3.2,1.3,0.3,3\n"
+	dataPath := filepath.Join(os.TempDir(), "predictions.csv")
+	assert.NoError(ioutil.WriteFile(dataPath, []byte(data), 0666))
+	defer os.Remove(dataPath)
+	ds, err := dataset.NewDataSet(dataPath, true)
+	assert.NoError(err)
+	var buf bytes.Buffer
+	assert.NoError(WritePredictions(&buf, n, ds, "jsonl"))
+	assert.Len(strings.Split(strings.TrimSpace(buf.String()), "\n"), 3)
+	// nil data set
+	assert.Error(WritePredictions(&buf, n, nil, "csv"))
+	// unsupported format
+	assert.Error(WritePredictions(&buf, n, ds, "xml"))
+}
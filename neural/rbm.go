@@ -0,0 +1,240 @@
+package neural
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// RBM is a restricted Boltzmann machine with binary visible and hidden units.
+// Its weights are laid out the same way as the weights of network layers, so that a trained
+// machine can initialize the weights of a network layer with the same number of inputs and outputs.
+type RBM struct {
+	// weights has a row per hidden unit: the first column contains hidden biases
+	weights *mat64.Dense
+	// visBias contains visible biases
+	visBias []float64
+	// rnd is the source of random numbers used in training
+	rnd *rand.Rand
+}
+
+// NewRBM creates new restricted Boltzmann machine with the given number of visible and hidden units.
+// Initial weights are drawn from normal distribution with 0.01 standard deviation using the default
+// math/rand source, biases are initialized to zeros. It fails with error if any of the sizes is not positive.
+func NewRBM(visible, hidden int) (*RBM, error) {
+	return NewRBMWithRand(visible, hidden, nil)
+}
+
+// NewRBMWithRand creates new restricted Boltzmann machine the same way as NewRBM does, but it draws
+// initial weights, unit samples and sample order in training from rnd. If rnd is nil, the default
+// math/rand source is used.
+func NewRBMWithRand(visible, hidden int, rnd *rand.Rand) (*RBM, error) {
+	if visible <= 0 || hidden <= 0 {
+		return nil, fmt.Errorf("Incorrect RBM size. Visible: %d, Hidden: %d\n", visible, hidden)
+	}
+	weights, err := matrix.MakeRandMxFrom(matrix.Normal, matrix.DistParams{StdDev: 0.01},
+		hidden, visible+1, rnd)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < hidden; i++ {
+		weights.Set(i, 0, 0.0)
+	}
+	return &RBM{weights: weights, visBias: make([]float64, visible), rnd: randOrDefault(rnd)}, nil
+}
+
+// Weights returns a copy of the machine weights matrix: it contains a row per hidden unit
+// and the first column contains hidden biases. Visible biases are not included.
+func (r *RBM) Weights() *mat64.Dense {
+	return mat64.DenseCopyOf(r.weights)
+}
+
+// HiddenProbs returns activation probabilities of hidden units for visible units stored in rows of inMx.
+// It fails with error if the number of columns of inMx does not match the number of visible units.
+func (r *RBM) HiddenProbs(inMx mat64.Matrix) (*mat64.Dense, error) {
+	if _, cols := inMx.Dims(); cols != len(r.visBias) {
+		return nil, fmt.Errorf("Dimension mismatch. Visible: %d, Input: %d\n", len(r.visBias), cols)
+	}
+	return r.hidden(matrix.AddBias(inMx))
+}
+
+// hidden calculates hidden units probabilities for visible units with bias
+func (r *RBM) hidden(biasVisMx *mat64.Dense) (*mat64.Dense, error) {
+	hidMx := new(mat64.Dense)
+	hidMx.Mul(biasVisMx, r.weights.T())
+	if err := matrix.ApplySlice(matrix.SigmoidSlice, hidMx, hidMx); err != nil {
+		return nil, err
+	}
+	return hidMx, nil
+}
+
+// visible calculates visible units probabilities for hidden units
+func (r *RBM) visible(hidMx *mat64.Dense) (*mat64.Dense, error) {
+	hidden, visible := r.weights.Dims()
+	visMx := new(mat64.Dense)
+	visMx.Mul(hidMx, r.weights.View(0, 1, hidden, visible-1))
+	rows, _ := visMx.Dims()
+	for i := 0; i < rows; i++ {
+		floats.Add(visMx.RawRowView(i), r.visBias)
+	}
+	if err := matrix.ApplySlice(matrix.SigmoidSlice, visMx, visMx); err != nil {
+		return nil, err
+	}
+	return visMx, nil
+}
+
+// sampleUnits samples binary unit states from unit probabilities
+func sampleUnits(probMx *mat64.Dense, rnd *rand.Rand) *mat64.Dense {
+	rows, cols := probMx.Dims()
+	out := mat64.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		for j, p := range probMx.RawRowView(i) {
+			if rnd.Float64() < p {
+				out.Set(i, j, 1.0)
+			}
+		}
+	}
+	return out
+}
+
+// Train trains the machine on the samples stored in rows of inMx by contrastive divergence.
+// Samples are shuffled at the beginning of every epoch and split into batches of configured size.
+// Visible units should contain values in [0, 1] interval.
+// It fails with error if either the configuration is invalid or if the number of columns
+// of inMx does not match the number of visible units.
+func (r *RBM) Train(c *config.PretrainConfig, inMx *mat64.Dense) error {
+	if err := validatePretrainConfig(c); err != nil {
+		return err
+	}
+	samples, cols := inMx.Dims()
+	if cols != len(r.visBias) {
+		return fmt.Errorf("Dimension mismatch. Visible: %d, Input: %d\n", len(r.visBias), cols)
+	}
+	batchSize := c.BatchSize
+	if batchSize == 0 || batchSize > samples {
+		batchSize = samples
+	}
+	hidden, _ := r.weights.Dims()
+	batchMx := mat64.NewDense(batchSize, cols, nil)
+	grad := new(mat64.Dense)
+	negGrad := new(mat64.Dense)
+	for epoch := 0; epoch < c.Epochs; epoch++ {
+		perm := r.rnd.Perm(samples)
+		for from := 0; from < samples; from += batchSize {
+			to := from + batchSize
+			if to > samples {
+				to = samples
+			}
+			v0 := batchMx.View(0, 0, to-from, cols).(*mat64.Dense)
+			for i, idx := range perm[from:to] {
+				v0.SetRow(i, inMx.RawRowView(idx))
+			}
+			// positive phase
+			biasV0 := matrix.AddBias(v0)
+			h0, err := r.hidden(biasV0)
+			if err != nil {
+				return err
+			}
+			// negative phase: Gibbs sampling starts from sampled hidden states
+			hk := h0
+			var vk, biasVk *mat64.Dense
+			for k := 0; k < c.CDSteps; k++ {
+				if vk, err = r.visible(sampleUnits(hk, r.rnd)); err != nil {
+					return err
+				}
+				biasVk = matrix.AddBias(vk)
+				if hk, err = r.hidden(biasVk); err != nil {
+					return err
+				}
+			}
+			// weights and hidden biases: (h0^T * v0 - hk^T * vk) / batch
+			rate := c.LearningRate / float64(to-from)
+			grad.Mul(h0.T(), biasV0)
+			negGrad.Mul(hk.T(), biasVk)
+			grad.Sub(grad, negGrad)
+			grad.Scale(rate, grad)
+			r.weights.Add(r.weights, grad)
+			// visible biases: sum(v0 - vk) / batch
+			for i := 0; i < to-from; i++ {
+				floats.AddScaled(r.visBias, rate, v0.RawRowView(i))
+				floats.AddScaled(r.visBias, -rate, vk.RawRowView(i))
+			}
+		}
+		netLogger.Debugf("RBM %dx%d epoch %d", len(r.visBias), hidden, epoch+1)
+	}
+	return nil
+}
+
+// validatePretrainConfig validates pretraining configuration
+func validatePretrainConfig(c *config.PretrainConfig) error {
+	// config can't be nil
+	if c == nil {
+		return fmt.Errorf("Incorrect pretraining configuration supplied: %v\n", c)
+	}
+	if c.Epochs <= 0 {
+		return fmt.Errorf("Incorrect number of pretraining epochs: %d\n", c.Epochs)
+	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("Incorrect pretraining batch size: %d\n", c.BatchSize)
+	}
+	if c.LearningRate <= 0 {
+		return fmt.Errorf("Incorrect pretraining learning rate: %f\n", c.LearningRate)
+	}
+	if c.CDSteps <= 0 {
+		return fmt.Errorf("Incorrect number of CD steps: %d\n", c.CDSteps)
+	}
+	return nil
+}
+
+// Pretrain pretrains hidden layers of the network one by one as restricted Boltzmann machines
+// on the samples stored in rows of inMx. The first hidden layer is pretrained on the samples,
+// each following hidden layer is pretrained on the hidden unit probabilities of the previous
+// machine. Trained machine weights replace the hidden layer weights; the output layer is left
+// intact. Pretrained DBN network becomes FEEDFWD network which can be fine-tuned by backpropagation.
+// Machines draw random numbers from the source of random numbers the network was created with.
+// It fails with error if either the configuration is invalid, if any hidden layer is a highway layer
+// or does not use sigmoid activation function, or if the pretraining fails.
+func (n *Network) Pretrain(c *config.PretrainConfig, inMx *mat64.Dense) error {
+	if err := validatePretrainConfig(c); err != nil {
+		return err
+	}
+	if inMx == nil {
+		return fmt.Errorf("Incorrect input supplied: %v\n", inMx)
+	}
+	layers := n.Layers()
+	// machine weights are only meaningful in sigmoid layers
+	for _, layer := range layers[1 : len(layers)-1] {
+		if layer.highway {
+			return fmt.Errorf("Can't pretrain highway layer %s\n", layer.ID())
+		}
+		if layer.Activation() != "sigmoid" {
+			return fmt.Errorf("Can't pretrain %s layer %s\n", layer.Activation(), layer.ID())
+		}
+	}
+	visMx := inMx
+	for _, layer := range layers[1 : len(layers)-1] {
+		hidden, in := layer.weights.Dims()
+		rbm, err := NewRBMWithRand(in-1, hidden, n.rnd)
+		if err != nil {
+			return err
+		}
+		if err := rbm.Train(c, visMx); err != nil {
+			return err
+		}
+		if err := layer.SetWeights(rbm.weights); err != nil {
+			return err
+		}
+		if visMx, err = rbm.HiddenProbs(visMx); err != nil {
+			return err
+		}
+		netLogger.Infof("Pretrained layer %s", layer.ID())
+	}
+	if n.kind == DBN {
+		n.kind = FEEDFWD
+	}
+	return nil
+}
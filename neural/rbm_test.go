@@ -0,0 +1,161 @@
+package neural
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRBM(t *testing.T) {
+	assert := assert.New(t)
+	// incorrect sizes
+	r, err := NewRBM(0, 2)
+	assert.Nil(r)
+	assert.Error(err)
+	r, err = NewRBM(6, 2)
+	assert.NotNil(r)
+	assert.NoError(err)
+	rows, cols := r.Weights().Dims()
+	assert.Equal(2, rows)
+	assert.Equal(7, cols)
+	// two binary patterns
+	inMx := mat64.NewDense(4, 6, []float64{
+		1, 1, 1, 0, 0, 0,
+		0, 0, 0, 1, 1, 1,
+		1, 1, 1, 0, 0, 0,
+		0, 0, 0, 1, 1, 1,
+	})
+	c := &config.PretrainConfig{Epochs: 500, BatchSize: 2, LearningRate: 0.5, CDSteps: 1}
+	// incorrect configuration and input
+	badConf := *c
+	badConf.CDSteps = 0
+	assert.Error(r.Train(&badConf, inMx))
+	assert.Error(r.Train(c, mat64.NewDense(2, 3, nil)))
+	hidMx, err := r.HiddenProbs(mat64.NewDense(2, 3, nil))
+	assert.Nil(hidMx)
+	assert.Error(err)
+	// trained machine reconstructs the patterns
+	assert.NoError(r.Train(c, inMx))
+	hidMx, err = r.HiddenProbs(inMx)
+	assert.NoError(err)
+	visMx, err := r.visible(hidMx)
+	assert.NoError(err)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 6; j++ {
+			assert.InDelta(inMx.At(i, j), visMx.At(i, j), 0.3)
+		}
+	}
+	// hidden representations of the two patterns differ
+	assert.False(mat64.EqualApprox(hidMx.RowView(0), hidMx.RowView(1), 0.3))
+	// machines trained with the same source of random numbers are equal
+	r1, err := NewRBMWithRand(6, 2, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	assert.NoError(r1.Train(c, inMx))
+	r2, err := NewRBMWithRand(6, 2, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	assert.NoError(r2.Train(c, inMx))
+	assert.True(mat64.Equal(r1.Weights(), r2.Weights()))
+}
+
+func TestPretrain(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	c := &config.PretrainConfig{Epochs: 5, LearningRate: 0.1, CDSteps: 1}
+	// incorrect configuration and input
+	assert.Error(n.Pretrain(nil, inMx))
+	assert.Error(n.Pretrain(c, nil))
+	// hidden layers are pretrained, output layer is left intact
+	layers := n.Layers()
+	hidden := layers[1].Weights()
+	output := layers[2].Weights()
+	assert.NoError(n.Pretrain(c, inMx))
+	assert.False(mat64.Equal(hidden, layers[1].Weights()))
+	assert.True(mat64.Equal(output, layers[2].Weights()))
+	// only sigmoid hidden layers can be pretrained
+	relu, err := NewFeedForward().Input(4).Hidden(5, "relu").Output(5, "softmax").Build()
+	assert.NoError(err)
+	hidden = relu.Layers()[1].Weights()
+	assert.Error(relu.Pretrain(c, inMx))
+	assert.True(mat64.Equal(hidden, relu.Layers()[1].Weights()))
+	// incorrect pretraining configuration is rejected by validation
+	trainConf := *conf.Training
+	trainConf.Pretrain = &config.PretrainConfig{Epochs: 5, LearningRate: -0.1, CDSteps: 1}
+	assert.Error(ValidateTrainConfig(&trainConf))
+	// feedforward network is pretrained before the training if requested
+	pretrained, err := NewNetworkWithRand(conf.Network, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	_, err = pretrained.TrainWithOptions(inMx, labelsVec, WithConfig(conf.Training),
+		WithPretrain(*c), WithConcurrency(1), WithIterations(2))
+	assert.NoError(err)
+	assert.Equal(FEEDFWD, pretrained.Kind())
+	plain, err := NewNetworkWithRand(conf.Network, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	_, err = plain.TrainWithOptions(inMx, labelsVec, WithConfig(conf.Training),
+		WithConcurrency(1), WithIterations(2))
+	assert.NoError(err)
+	assert.False(mat64.Equal(plain.Layers()[1].Weights(), pretrained.Layers()[1].Weights()))
+}
+
+func TestDBN(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	netConf := *conf.Network
+	netConf.Kind = "dbn"
+	netConf.ID = ""
+	n, err := NewNetwork(&netConf)
+	assert.NotNil(n)
+	assert.NoError(err)
+	assert.Equal(DBN, n.Kind())
+	assert.Equal("DBN", n.Kind().String())
+	// deep belief network must be pretrained
+	history, err := n.TrainWithOptions(inMx, labelsVec, WithIterations(5))
+	assert.Nil(history)
+	assert.Error(err)
+	assert.Equal(DBN, n.Kind())
+	// pretrained network is fine-tuned as a feedforward network
+	c := config.PretrainConfig{Epochs: 5, LearningRate: 0.1, CDSteps: 1}
+	history, err = n.TrainWithOptions(inMx, labelsVec, WithPretrain(c), WithIterations(5))
+	assert.NotEmpty(history)
+	assert.NoError(err)
+	assert.Equal(FEEDFWD, n.Kind())
+	// resumed training does not pretrain the saved network again
+	var buf bytes.Buffer
+	assert.NoError(n.Save(&buf))
+	model := buf.Bytes()
+	resumed, err := Load(bytes.NewReader(model))
+	assert.NoError(err)
+	assert.Equal(FEEDFWD, resumed.Kind())
+	_, err = resumed.TrainWithOptions(inMx, labelsVec, WithPretrain(c), WithConcurrency(1), WithIterations(2))
+	assert.NoError(err)
+	plain, err := Load(bytes.NewReader(model))
+	assert.NoError(err)
+	_, err = plain.TrainWithOptions(inMx, labelsVec, WithConcurrency(1), WithIterations(2))
+	assert.NoError(err)
+	for i := 1; i < len(plain.Layers()); i++ {
+		assert.True(mat64.Equal(plain.Layers()[i].Weights(), resumed.Layers()[i].Weights()))
+	}
+	// hidden layers are required
+	arch := *netConf.Arch
+	arch.Hidden = nil
+	netConf.Arch = &arch
+	n, err = NewNetwork(&netConf)
+	assert.Nil(n)
+	assert.Error(err)
+}
@@ -0,0 +1,234 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// sgdMethod is the name of mini-batch gradient descent optimization method
+const sgdMethod = "sgd"
+
+// validateSGDConfig validates mini-batch gradient descent configuration
+func validateSGDConfig(c *config.OptimConfig) error {
+	// incorrect number of epochs supplied
+	if c.Epochs <= 0 {
+		return fmt.Errorf("Incorrect number of epochs: %d\n", c.Epochs)
+	}
+	// incorrect batch size supplied
+	if c.BatchSize < 0 {
+		return fmt.Errorf("Incorrect batch size: %d\n", c.BatchSize)
+	}
+	// incorrect learning rate supplied
+	if c.LearningRate <= 0 {
+		return fmt.Errorf("Incorrect learning rate: %f\n", c.LearningRate)
+	}
+	// incorrect momentum supplied
+	if c.Momentum < 0 || c.Momentum >= 1 {
+		return fmt.Errorf("Incorrect momentum: %f\n", c.Momentum)
+	}
+	// incorrect gradient clipping supplied
+	if c.ClipNorm < 0 {
+		return fmt.Errorf("Incorrect gradient clipping norm: %f\n", c.ClipNorm)
+	}
+	// incorrect learning rate schedule supplied
+	switch c.Schedule {
+	case "", "constant":
+	case "triangular", "triangular2":
+		if c.MaxLearningRate < c.LearningRate {
+			return fmt.Errorf("Incorrect maximum learning rate: %f\n", c.MaxLearningRate)
+		}
+		if c.CycleLength < 2 {
+			return fmt.Errorf("Incorrect cycle length: %d\n", c.CycleLength)
+		}
+	default:
+		return fmt.Errorf("Unsupported learning rate schedule: %s\n", c.Schedule)
+	}
+	// incorrect runtime limit supplied
+	if c.Runtime < 0 {
+		return fmt.Errorf("Incorrect runtime limit: %s\n", c.Runtime)
+	}
+	return nil
+}
+
+// learningRate returns the learning rate of gradient step t (counted from 0) as set by schedule.
+// Triangular schedules start each cycle at LearningRate, reach MaxLearningRate in the middle
+// of the cycle and return back to LearningRate at its end.
+func learningRate(c *config.OptimConfig, t int) float64 {
+	if c.Schedule != "triangular" && c.Schedule != "triangular2" {
+		return c.LearningRate
+	}
+	cycle := t / c.CycleLength
+	pos := float64(t%c.CycleLength) / float64(c.CycleLength)
+	amp := (c.MaxLearningRate - c.LearningRate) * (1 - math.Abs(2*pos-1))
+	// triangular2 halves the amplitude after every cycle
+	if c.Schedule == "triangular2" {
+		amp = amp / math.Pow(2, float64(cycle))
+	}
+	return c.LearningRate + amp
+}
+
+// trainSGD trains the network by mini-batch gradient descent with either classical or Nesterov momentum.
+// Training samples are shuffled at the beginning of every epoch and split into batches of
+// configured size; network weights are updated once per batch. Training metrics are recorded
+// by rec once per epoch: the recorded cost is the cost of the whole training data set,
+// the recorded gradient is the gradient of the last batch of the epoch.
+func (n *Network) trainSGD(c *config.TrainConfig, inMx *mat64.Dense, labelsVec *mat64.Vector,
+	rec *recorder) (History, error) {
+	samples, cols := inMx.Dims()
+	batchSize := c.Optimize.BatchSize
+	if batchSize == 0 || batchSize > samples {
+		batchSize = samples
+	}
+	// batch gradients are regularized by lambda/samples so that all batches minimize the same cost
+	bc := *c
+	bc.Lambda = c.Lambda * float64(batchSize) / float64(samples)
+	lastBc := *c
+	lastBc.Lambda = c.Lambda * float64(samples%batchSize) / float64(samples)
+	// samples are shuffled in a copy so that the supplied data set is not modified
+	shufInMx := mat64.DenseCopyOf(inMx)
+	shufLabels := mat64.NewVector(samples, nil)
+	shufLabels.CopyVec(labelsVec)
+	// network weights become views of the weights slice updated in place
+	layers := n.Layers()
+	weights := netWeights(layers[1:])
+	if err := setNetWeights(layers[1:], weights); err != nil {
+		return nil, err
+	}
+	grad := make([]float64, len(weights))
+	gradMx := mat64.NewDense(1, len(grad), grad)
+	step := make([]float64, len(weights))
+	// full batches and the last smaller batch use separate workspaces to avoid reallocation
+	batchWs, lastWs, costWs := new(workspace), new(workspace), new(workspace)
+	if err := rec.Init(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	epoch, t, cost := 0, 0, math.NaN()
+	for epoch < c.Optimize.Epochs {
+		if c.Optimize.Runtime > 0 && time.Since(start) > c.Optimize.Runtime {
+			break
+		}
+		if err := matrix.ShuffleRows(shufInMx, shufLabels, n.rnd); err != nil {
+			return rec.history, err
+		}
+		for from := 0; from < samples; from += batchSize {
+			to, ws, tc := from+batchSize, batchWs, &bc
+			if to > samples {
+				to, ws, tc = samples, lastWs, &lastBc
+			}
+			batchInMx := shufInMx.View(from, 0, to-from, cols).(*mat64.Dense)
+			batchLabels := shufLabels.ViewVec(from, to-from)
+			// Nesterov momentum calculates the gradient at the lookahead weights
+			if c.Optimize.Nesterov {
+				floats.AddScaled(weights, c.Optimize.Momentum, step)
+			}
+			err := n.getGradient(tc, ws, grad, nil, batchInMx, batchLabels)
+			if c.Optimize.Nesterov {
+				floats.AddScaled(weights, -c.Optimize.Momentum, step)
+			}
+			if err != nil {
+				return rec.history, fmt.Errorf("Training failed: %v\n", err)
+			}
+			if c.CheckFinite {
+				if err := checkFiniteGrad(grad); err != nil {
+					return rec.history, err
+				}
+			}
+			// large gradients are clipped so that a single batch can't blow up the weights
+			if c.Optimize.ClipNorm > 0 {
+				if _, err := matrix.ClipNorm(gradMx, c.Optimize.ClipNorm); err != nil {
+					return rec.history, err
+				}
+			}
+			// step = momentum * step - rate * grad
+			floats.Scale(c.Optimize.Momentum, step)
+			floats.AddScaled(step, -learningRate(c.Optimize, t), grad)
+			floats.Add(weights, step)
+			t++
+		}
+		epoch++
+		var err error
+		if cost, err = n.getCost(c, costWs, nil, inMx, labelsVec); err != nil {
+			return rec.history, fmt.Errorf("Training failed: %v\n", err)
+		}
+		if c.CheckFinite && (math.IsNaN(cost) || math.IsInf(cost, 0)) {
+			return rec.history, fmt.Errorf("Non-finite cost: %f\n", cost)
+		}
+		netLogger.Debugf("Epoch %d cost: %f", epoch, cost)
+		loc := &optimize.Location{X: weights, F: cost, Gradient: grad}
+		stats := &optimize.Stats{MajorIterations: epoch}
+		if err := rec.Record(loc, optimize.MajorIteration, stats); err != nil {
+			return rec.history, err
+		}
+	}
+	netLogger.Infof("Trained %d epochs", epoch)
+	n.meta = Metadata{
+		Trained:    time.Now(),
+		Iterations: epoch,
+		Cost:       cost,
+	}
+	if err := n.setAvgWeights(c, rec.swa, inMx, labelsVec); err != nil {
+		return rec.history, err
+	}
+	return rec.history, nil
+}
+
+// PartialFit updates the network weights by a single gradient descent step calculated on the
+// supplied batch of samples, so that the network can be trained incrementally on streaming data
+// without retraining it on the whole data set. The step size is set by the learning rate of the
+// optimization configuration and the gradient is clipped by its ClipNorm, other optimization
+// parameters are ignored. Training metadata record the time of the update and the total number
+// of updates; the recorded cost is not updated.
+// It returns error if either the training configuration or the batch are invalid or if the
+// gradient calculation fails.
+func (n *Network) PartialFit(inMx *mat64.Dense, labelsVec *mat64.Vector, c *config.TrainConfig) error {
+	// config can't be nil
+	if c == nil {
+		return fmt.Errorf("Incorrect configuration supplied: %v\n", c)
+	}
+	// check if the requested cost is supported
+	if _, ok := trainCost[c.Cost]; !ok {
+		return fmt.Errorf("Unsupported training cost: %s\n", c.Cost)
+	}
+	// Incorrect lambda supplied
+	if c.Lambda < 0 {
+		return fmt.Errorf("Incorrect regularizer supplied: %f\n", c.Lambda)
+	}
+	// learning rate must be supplied
+	if c.Optimize == nil || c.Optimize.LearningRate <= 0 {
+		return fmt.Errorf("Incorrect learning rate supplied\n")
+	}
+	if c.Optimize.ClipNorm < 0 {
+		return fmt.Errorf("Incorrect gradient clipping norm: %f\n", c.Optimize.ClipNorm)
+	}
+	if err := checkTrainData(inMx, labelsVec); err != nil {
+		return err
+	}
+	layers := n.Layers()
+	weights := netWeights(layers[1:])
+	grad := make([]float64, len(weights))
+	if err := n.getGradient(c, new(workspace), grad, weights, inMx, labelsVec); err != nil {
+		return fmt.Errorf("Training failed: %v\n", err)
+	}
+	if c.CheckFinite {
+		if err := checkFiniteGrad(grad); err != nil {
+			return err
+		}
+	}
+	if c.Optimize.ClipNorm > 0 {
+		if _, err := matrix.ClipNorm(mat64.NewDense(1, len(grad), grad), c.Optimize.ClipNorm); err != nil {
+			return err
+		}
+	}
+	floats.AddScaled(weights, -c.Optimize.LearningRate, grad)
+	n.meta.Trained = time.Now()
+	n.meta.Iterations++
+	return nil
+}
@@ -0,0 +1,189 @@
+package neural
+
+import (
+	"math"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrainSGD(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	trainConf := conf.Training
+	trainConf.Optimize = &config.OptimConfig{
+		Method:       "sgd",
+		Epochs:       20,
+		BatchSize:    2,
+		LearningRate: 0.5,
+		Momentum:     0.5,
+	}
+	// incorrect mini-batch parameters
+	for _, set := range []func(*config.OptimConfig){
+		func(c *config.OptimConfig) { c.Epochs = 0 },
+		func(c *config.OptimConfig) { c.BatchSize = -1 },
+		func(c *config.OptimConfig) { c.LearningRate = 0.0 },
+		func(c *config.OptimConfig) { c.Momentum = 1.0 },
+		func(c *config.OptimConfig) { c.ClipNorm = -1.0 },
+		func(c *config.OptimConfig) { c.Schedule = "foo" },
+		func(c *config.OptimConfig) { c.Schedule, c.MaxLearningRate, c.CycleLength = "triangular", 0.1, 4 },
+		func(c *config.OptimConfig) { c.Schedule, c.MaxLearningRate, c.CycleLength = "triangular", 1.0, 1 },
+	} {
+		optim := *trainConf.Optimize
+		badConf := *trainConf
+		badConf.Optimize = &optim
+		set(badConf.Optimize)
+		assert.Error(ValidateTrainConfig(&badConf))
+	}
+	// training records metrics once per epoch and does not modify the training data
+	origInMx := mat64.DenseCopyOf(inMx)
+	history, err := n.TrainMonitored(trainConf, inMx, labelsVec, nil)
+	assert.NoError(err)
+	assert.Len(history, 20)
+	for i, metrics := range history {
+		assert.Equal(i+1, metrics.Iter)
+	}
+	assert.True(history[len(history)-1].Cost < history[0].Cost)
+	assert.True(mat64.Equal(origInMx, inMx))
+	// recorded cost is the cost of the trained network
+	cost, err := n.getCost(trainConf, new(workspace), nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.InDelta(cost, history[len(history)-1].Cost, 1e-12)
+	assert.Equal(20, n.Metadata().Iterations)
+	// callbacks are called once per epoch with validation metrics
+	calls := 0
+	m := &Monitor{
+		ValInMx:   inMx,
+		ValLabels: labelsVec,
+		Callbacks: []Callback{func(m *Metrics) error {
+			calls++
+			assert.True(m.Validated)
+			return nil
+		}},
+	}
+	trainConf.Optimize.BatchSize = 0
+	trainConf.Optimize.Epochs = 3
+	history, err = n.TrainMonitored(trainConf, inMx, labelsVec, m)
+	assert.NoError(err)
+	assert.Len(history, 3)
+	assert.Equal(3, calls)
+	// diverged epoch is recorded, but it's not passed to callbacks
+	nanInMx := mat64.DenseCopyOf(inMx)
+	nanInMx.Set(0, 0, math.NaN())
+	history, err = n.TrainMonitored(trainConf, nanInMx, labelsVec, m)
+	assert.Error(err)
+	assert.Contains(err.Error(), "learning rate")
+	assert.Len(history, 1)
+	assert.True(math.IsNaN(history[0].Cost))
+	assert.Equal(3, calls)
+	// Nesterov momentum decreases the cost, too
+	n, err = NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	trainConf.Optimize.Nesterov = true
+	trainConf.Optimize.BatchSize = 2
+	trainConf.Optimize.Epochs = 20
+	history, err = n.TrainMonitored(trainConf, inMx, labelsVec, nil)
+	assert.NoError(err)
+	assert.Len(history, 20)
+	assert.True(history[len(history)-1].Cost < history[0].Cost)
+	// clipped gradient step does not exceed the clipping norm scaled by the learning rate
+	weights := netWeights(n.Layers()[1:])
+	_, err = n.TrainWithOptions(inMx, labelsVec, WithConfig(trainConf), WithNesterov(false),
+		WithMomentum(0.0), WithBatchSize(0), WithEpochs(1), WithClipNorm(0.01))
+	assert.NoError(err)
+	assert.InDelta(0.5*0.01, floats.Distance(weights, netWeights(n.Layers()[1:]), 2), 1e-12)
+}
+
+func TestLearningRate(t *testing.T) {
+	assert := assert.New(t)
+	c := &config.OptimConfig{LearningRate: 0.1, MaxLearningRate: 0.5, CycleLength: 4}
+	// constant schedule
+	for _, schedule := range []string{"", "constant"} {
+		c.Schedule = schedule
+		for i := 0; i < 10; i++ {
+			assert.Equal(0.1, learningRate(c, i))
+		}
+	}
+	// triangular schedule repeats the same cycle
+	c.Schedule = "triangular"
+	expected := []float64{0.1, 0.3, 0.5, 0.3, 0.1, 0.3, 0.5, 0.3}
+	for i, rate := range expected {
+		assert.InDelta(rate, learningRate(c, i), 1e-12)
+	}
+	// triangular2 halves the amplitude after each cycle
+	c.Schedule = "triangular2"
+	expected = []float64{0.1, 0.3, 0.5, 0.3, 0.1, 0.2, 0.3, 0.2, 0.1, 0.15, 0.2}
+	for i, rate := range expected {
+		assert.InDelta(rate, learningRate(c, i), 1e-12)
+	}
+}
+
+func TestPartialFit(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	trainConf := *conf.Training
+	trainConf.Optimize = &config.OptimConfig{LearningRate: 0.5}
+	// incorrect configuration and data
+	assert.Error(n.PartialFit(inMx, labelsVec, nil))
+	badConf := trainConf
+	badConf.Optimize = nil
+	assert.Error(n.PartialFit(inMx, labelsVec, &badConf))
+	badConf = trainConf
+	badConf.Cost = "foo"
+	assert.Error(n.PartialFit(inMx, labelsVec, &badConf))
+	assert.Error(n.PartialFit(nil, labelsVec, &trainConf))
+	assert.Error(n.PartialFit(inMx, labelsVec.ViewVec(0, 2), &trainConf))
+	// each update is a single gradient step
+	weights := netWeights(n.Layers()[1:])
+	grad := make([]float64, len(weights))
+	assert.NoError(n.getGradient(&trainConf, new(workspace), grad, nil, inMx, labelsVec))
+	assert.NoError(n.PartialFit(inMx, labelsVec, &trainConf))
+	updated := netWeights(n.Layers()[1:])
+	for i := range weights {
+		assert.InDelta(weights[i]-0.5*grad[i], updated[i], 1e-12)
+	}
+	assert.Equal(1, n.Metadata().Iterations)
+	// streaming single samples decreases the cost
+	initCost, err := n.getCost(&trainConf, new(workspace), nil, inMx, labelsVec)
+	assert.NoError(err)
+	rows, cols := inMx.Dims()
+	for epoch := 0; epoch < 20; epoch++ {
+		for i := 0; i < rows; i++ {
+			sample := inMx.View(i, 0, 1, cols).(*mat64.Dense)
+			assert.NoError(n.PartialFit(sample, labelsVec.ViewVec(i, 1), &trainConf))
+		}
+	}
+	cost, err := n.getCost(&trainConf, new(workspace), nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.True(cost < initCost)
+	assert.Equal(1+20*rows, n.Metadata().Iterations)
+	// clipped update does not exceed the clipping norm scaled by the learning rate
+	badConf = trainConf
+	badConf.Optimize = &config.OptimConfig{LearningRate: 0.5, ClipNorm: -1.0}
+	assert.Error(n.PartialFit(inMx, labelsVec, &badConf))
+	clipConf := trainConf
+	clipConf.Optimize = &config.OptimConfig{LearningRate: 0.5, ClipNorm: 0.01}
+	weights = netWeights(n.Layers()[1:])
+	assert.NoError(n.PartialFit(inMx, labelsVec, &clipConf))
+	assert.InDelta(0.5*0.01, floats.Distance(weights, netWeights(n.Layers()[1:]), 2), 1e-12)
+}
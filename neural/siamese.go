@@ -0,0 +1,282 @@
+package neural
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonum/blas/blas64"
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/optimize"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// Siamese is a twin network used for similarity learning. Both samples of a pair are embedded
+// by the same network, so the twins share all their weights: the output layer of the network
+// produces the embedding and the similarity of two samples is the euclidean distance of their
+// embeddings. The network is trained by contrastive loss which pulls embeddings of similar pairs
+// together and pushes embeddings of dissimilar pairs at least margin apart.
+type Siamese struct {
+	net    *Network
+	margin float64
+}
+
+// NewSiamese creates new twin network which embeds samples by network n and trains it
+// with contrastive loss with the given margin. The network is shared, not copied.
+// It fails with error if the margin is not positive or if the network output layer uses
+// softmax activation: softmax outputs are normalized, so they can't embed samples freely.
+func NewSiamese(n *Network, margin float64) (*Siamese, error) {
+	if n == nil {
+		return nil, fmt.Errorf("Incorrect network supplied: %v\n", n)
+	}
+	if margin <= 0 {
+		return nil, fmt.Errorf("Incorrect margin: %f\n", margin)
+	}
+	layers := n.Layers()
+	if len(layers) < 2 {
+		return nil, fmt.Errorf("Incorrect number of network layers: %d\n", len(layers))
+	}
+	if act := layers[len(layers)-1].Activation(); act == "softmax" {
+		return nil, fmt.Errorf("Unsupported embedding activation: %s\n", act)
+	}
+	return &Siamese{net: n, margin: margin}, nil
+}
+
+// Network returns the network shared by the twins
+func (s *Siamese) Network() *Network {
+	return s.net
+}
+
+// Margin returns contrastive loss margin
+func (s *Siamese) Margin() float64 {
+	return s.margin
+}
+
+// Embed returns embeddings of the samples stored in rows of inMx: one row per sample.
+// It fails with error if the samples dimension does not match the network input.
+func (s *Siamese) Embed(inMx mat64.Matrix) (*mat64.Dense, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Incorrect input supplied: %v\n", inMx)
+	}
+	cache, err := s.net.forwardCache(inMx)
+	if err != nil {
+		return nil, err
+	}
+	return cache.out[len(cache.out)-1], nil
+}
+
+// Distance returns euclidean distances of the embeddings of sample pairs: the pairs are made of
+// the corresponding rows of aMx and bMx. It fails with error if the pair matrices dimensions differ
+// or if they do not match the network input.
+func (s *Siamese) Distance(aMx, bMx mat64.Matrix) (*mat64.Vector, error) {
+	if err := checkPairs(aMx, bMx); err != nil {
+		return nil, err
+	}
+	aEmb, err := s.Embed(aMx)
+	if err != nil {
+		return nil, err
+	}
+	bEmb, err := s.Embed(bMx)
+	if err != nil {
+		return nil, err
+	}
+	rows, _ := aEmb.Dims()
+	dist := mat64.NewVector(rows, nil)
+	for i := 0; i < rows; i++ {
+		dist.SetVec(i, floats.Distance(aEmb.RawRowView(i), bEmb.RawRowView(i), 2))
+	}
+	return dist, nil
+}
+
+// Cost returns the contrastive loss of sample pairs made of the corresponding rows of aMx and bMx.
+// simVec marks similar pairs by positive values and dissimilar pairs by 0. The loss of a similar pair
+// is d^2/2, the loss of a dissimilar pair is max(0, margin-d)^2/2 where d is the distance of the pair
+// embeddings; the returned cost is the average loss of all pairs.
+// It fails with error if the pair matrices or similarity vector dimensions do not match.
+func (s *Siamese) Cost(aMx, bMx *mat64.Dense, simVec *mat64.Vector) (float64, error) {
+	if err := checkLabeledPairs(aMx, bMx, simVec); err != nil {
+		return -1.0, err
+	}
+	return s.contrastive(nil, nil, aMx, bMx, simVec)
+}
+
+// Train trains the shared network by minimizing contrastive loss of sample pairs made of
+// the corresponding rows of aMx and bMx: simVec marks similar pairs by positive values and
+// dissimilar pairs by 0. The network is optimized by the optimization method, line search and
+// convergence criteria configured in c; mini-batch gradient descent is not supported.
+// It fails with error if either the configuration or the training data are invalid or if
+// the optimization fails.
+func (s *Siamese) Train(c *config.OptimConfig, aMx, bMx *mat64.Dense, simVec *mat64.Vector) error {
+	// config can't be nil
+	if c == nil {
+		return fmt.Errorf("Incorrect optimization configuration supplied: %v\n", c)
+	}
+	method, ok := optim[c.Method]
+	if !ok {
+		return fmt.Errorf("Unsupported optimization method: %s\n", c.Method)
+	}
+	if c.Iterations <= 0 {
+		return fmt.Errorf("Incorrect number of iterations: %d\n", c.Iterations)
+	}
+	if c.LineSearch != "" {
+		var err error
+		if method, err = withLineSearch(method, c.LineSearch); err != nil {
+			return err
+		}
+	}
+	if err := checkLabeledPairs(aMx, bMx, simVec); err != nil {
+		return err
+	}
+	layers := s.net.Layers()
+	var evalErr error
+	p := optimize.Problem{
+		Func: func(x []float64) float64 {
+			cost, err := s.contrastive(x, nil, aMx, bMx, simVec)
+			if err != nil {
+				evalErr = err
+			}
+			return cost
+		},
+		Grad: func(grad []float64, x []float64) {
+			if _, err := s.contrastive(x, grad, aMx, bMx, simVec); err != nil {
+				evalErr = err
+			}
+		},
+	}
+	// Hessian based methods use Hessian approximated from the gradient
+	if method.Needs().Hessian {
+		p.Hess = func(hess mat64.MutableSymmetric, x []float64) {
+			numHessian(hess, x, p.Grad)
+		}
+	}
+	result, err := optimize.Local(p, netWeights(layers[1:]), optimSettings(c), method)
+	if evalErr != nil {
+		return fmt.Errorf("Training failed: %v\n", evalErr)
+	}
+	if err != nil {
+		return err
+	}
+	if err := setNetWeights(layers[1:], result.X); err != nil {
+		return err
+	}
+	netLogger.Infof("Result status: %s", result.Status)
+	s.net.meta = Metadata{
+		Trained:    time.Now(),
+		Iterations: result.MajorIterations,
+		Cost:       result.F,
+	}
+	return nil
+}
+
+// contrastive calculates contrastive loss of the pairs. If weights is not nil, the network weights
+// are set to weights first. If grad is not nil, the loss gradient is stored in it.
+func (s *Siamese) contrastive(weights, grad []float64, aMx, bMx *mat64.Dense,
+	simVec *mat64.Vector) (float64, error) {
+	layers := s.net.Layers()
+	if weights != nil {
+		if err := setNetWeights(layers[1:], weights); err != nil {
+			return -1.0, err
+		}
+	}
+	aCache, err := s.net.forwardCache(aMx)
+	if err != nil {
+		return -1.0, err
+	}
+	bCache, err := s.net.forwardCache(bMx)
+	if err != nil {
+		return -1.0, err
+	}
+	outIdx := len(layers) - 1
+	aOut, bOut := aCache.out[outIdx], bCache.out[outIdx]
+	aErr, bErr := aCache.errMx[outIdx], bCache.errMx[outIdx]
+	samples, _ := aMx.Dims()
+	cost := 0.0
+	for i := 0; i < samples; i++ {
+		a, b := aOut.RawRowView(i), bOut.RawRowView(i)
+		d := floats.Distance(a, b, 2)
+		// scale is the derivative of the pair loss by d divided by d
+		scale := 0.0
+		if simVec.At(i, 0) > 0 {
+			cost += d * d / 2
+			scale = 1.0
+		} else if d < s.margin {
+			cost += (s.margin - d) * (s.margin - d) / 2
+			if d > 0 {
+				scale = -(s.margin - d) / d
+			}
+		}
+		// loss derivatives: dL/da = scale * (a - b), dL/db = -dL/da
+		aRow, bRow := aErr.RawRowView(i), bErr.RawRowView(i)
+		floats.SubTo(aRow, a, b)
+		floats.Scale(scale/float64(samples), aRow)
+		copy(bRow, aRow)
+		floats.Scale(-1.0, bRow)
+	}
+	if grad == nil {
+		return cost / float64(samples), nil
+	}
+	// gradient is accumulated directly in the grad slice
+	deltas := make([]*mat64.Dense, len(layers))
+	acc := 0
+	for i := 1; i < len(layers); i++ {
+		r, c := layers[i].weights.Dims()
+		if len(grad)-acc < r*c {
+			return -1.0, fmt.Errorf("Insufficient gradient length: %d\n", len(grad))
+		}
+		data := grad[acc:(acc + r*c)]
+		for j := range data {
+			data[j] = 0.0
+		}
+		deltas[i] = new(mat64.Dense)
+		deltas[i].SetRawMatrix(blas64.General{Rows: r, Cols: c, Stride: c, Data: data})
+		acc += r * c
+	}
+	// both twins backpropagate their errors into the same shared deltas
+	for _, cache := range []*actCache{aCache, bCache} {
+		gradMx := cache.actIn[outIdx]
+		if err := matrix.ApplySlice(layers[outIdx].gradSlice, gradMx, gradMx); err != nil {
+			return -1.0, err
+		}
+		// tanh and softsign outputs are rescaled to [0, 1] interval which halves their derivatives
+		if act := layers[outIdx].Activation(); act == "tanh" || act == "softsign" {
+			gradMx.Scale(0.5, gradMx)
+		}
+		cache.errMx[outIdx].MulElem(cache.errMx[outIdx], gradMx)
+		if err := s.net.doBackProp(cache, deltas, outIdx, 1); err != nil {
+			return -1.0, err
+		}
+	}
+	return cost / float64(samples), nil
+}
+
+// checkPairs checks if the sample pairs are valid
+func checkPairs(aMx, bMx mat64.Matrix) error {
+	if aMx == nil || bMx == nil {
+		return fmt.Errorf("Incorrect pairs supplied: %v, %v\n", aMx, bMx)
+	}
+	aRows, aCols := aMx.Dims()
+	bRows, bCols := bMx.Dims()
+	if aRows != bRows || aCols != bCols {
+		return fmt.Errorf("Dimension mismatch. Pairs: %d x %d, %d x %d\n", aRows, aCols, bRows, bCols)
+	}
+	return nil
+}
+
+// checkLabeledPairs checks if the sample pairs and their similarities are valid
+func checkLabeledPairs(aMx, bMx *mat64.Dense, simVec *mat64.Vector) error {
+	// avoid passing typed nil matrices as mat64.Matrix
+	if aMx == nil || bMx == nil {
+		return fmt.Errorf("Incorrect pairs supplied: %v, %v\n", aMx, bMx)
+	}
+	if err := checkPairs(aMx, bMx); err != nil {
+		return err
+	}
+	if simVec == nil {
+		return fmt.Errorf("Incorrect similarities supplied: %v\n", simVec)
+	}
+	if rows, _ := aMx.Dims(); simVec.Len() != rows {
+		return fmt.Errorf("Samples count mismatch. Pairs: %d, Similarities: %d\n", rows, simVec.Len())
+	}
+	return nil
+}
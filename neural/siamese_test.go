@@ -0,0 +1,133 @@
+package neural
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiamese(t *testing.T) {
+	assert := assert.New(t)
+	rnd := rand.New(rand.NewSource(3))
+	n, err := NewFeedForward().Input(2).Hidden(4, "tanh").Output(2, "sigmoid").Rand(rnd).Build()
+	assert.NotNil(n)
+	assert.NoError(err)
+	// incorrect twins
+	s, err := NewSiamese(nil, 1.0)
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = NewSiamese(n, 0.0)
+	assert.Nil(s)
+	assert.Error(err)
+	softmax, err := NewFeedForward().Input(2).Output(2, "softmax").Build()
+	assert.NoError(err)
+	s, err = NewSiamese(softmax, 1.0)
+	assert.Nil(s)
+	assert.Error(err)
+	s, err = NewSiamese(n, 1.0)
+	assert.NotNil(s)
+	assert.NoError(err)
+	assert.Equal(n, s.Network())
+	assert.Equal(1.0, s.Margin())
+	// pairs: the first two pairs are similar, the last two are dissimilar
+	aMx := mat64.NewDense(4, 2, []float64{0.1, 0.2, 0.9, 0.8, 0.1, 0.1, 0.8, 0.9})
+	bMx := mat64.NewDense(4, 2, []float64{0.2, 0.1, 0.8, 0.9, 0.9, 0.9, 0.2, 0.1})
+	simVec := mat64.NewVector(4, []float64{1, 1, 0, 0})
+	// incorrect pairs
+	_, err = s.Cost(aMx, mat64.NewDense(3, 2, nil), simVec)
+	assert.Error(err)
+	_, err = s.Cost(aMx, bMx, mat64.NewVector(3, nil))
+	assert.Error(err)
+	_, err = s.Cost(aMx, bMx, nil)
+	assert.Error(err)
+	dist, err := s.Distance(mat64.NewDense(4, 3, nil), mat64.NewDense(4, 3, nil))
+	assert.Nil(dist)
+	assert.Error(err)
+	// analytic gradient matches numerical gradient
+	layers := n.Layers()
+	weights := netWeights(layers[1:])
+	grad := make([]float64, len(weights))
+	_, err = s.contrastive(weights, grad, aMx, bMx, simVec)
+	assert.NoError(err)
+	x := make([]float64, len(weights))
+	copy(x, weights)
+	eps := 1e-6
+	for i := range x {
+		x[i] = weights[i] + eps
+		plus, err := s.contrastive(x, nil, aMx, bMx, simVec)
+		assert.NoError(err)
+		x[i] = weights[i] - eps
+		minus, err := s.contrastive(x, nil, aMx, bMx, simVec)
+		assert.NoError(err)
+		x[i] = weights[i]
+		assert.InDelta((plus-minus)/(2*eps), grad[i], 1e-6)
+	}
+	assert.NoError(setNetWeights(layers[1:], x))
+	// training decreases the cost
+	before, err := s.Cost(aMx, bMx, simVec)
+	assert.NoError(err)
+	c := &config.OptimConfig{Method: "bfgs", Iterations: 50}
+	assert.Error(s.Train(nil, aMx, bMx, simVec))
+	assert.Error(s.Train(&config.OptimConfig{Method: "sgd", Iterations: 50}, aMx, bMx, simVec))
+	assert.NoError(s.Train(c, aMx, bMx, simVec))
+	after, err := s.Cost(aMx, bMx, simVec)
+	assert.NoError(err)
+	assert.True(after < before)
+	assert.InDelta(after, n.Metadata().Cost, 1e-9)
+	// trained twins embed similar pairs closer than dissimilar pairs
+	dist, err = s.Distance(aMx, bMx)
+	assert.NoError(err)
+	d := dist.RawVector().Data
+	assert.True(floats.Max(d[:2]) < floats.Min(d[2:]))
+	// configured line search and convergence criteria are applied
+	assert.Error(s.Train(&config.OptimConfig{Method: "bfgs", Iterations: 50, LineSearch: "foo"}, aMx, bMx, simVec))
+	conv := &config.OptimConfig{
+		Method:             "bfgs",
+		Iterations:         50,
+		LineSearch:         "backtracking",
+		ConvergeIterations: 1,
+		ConvergeAbsolute:   1e3,
+	}
+	initWeights := make([]float64, len(weights))
+	copy(initWeights, weights)
+	assert.NoError(setNetWeights(layers[1:], initWeights))
+	assert.NoError(s.Train(conv, aMx, bMx, simVec))
+	assert.True(n.Metadata().Iterations < 3)
+}
+
+func TestSiameseActivations(t *testing.T) {
+	assert := assert.New(t)
+	aMx := mat64.NewDense(4, 2, []float64{0.1, 0.2, 0.9, 0.8, 0.1, 0.1, 0.8, 0.9})
+	bMx := mat64.NewDense(4, 2, []float64{0.2, 0.1, 0.8, 0.9, 0.9, 0.9, 0.2, 0.1})
+	simVec := mat64.NewVector(4, []float64{1, 1, 0, 0})
+	// analytic gradient matches numerical gradient for all embedding activations
+	for _, act := range []string{"sigmoid", "relu", "plainrelu", "tanh", "softsign"} {
+		rnd := rand.New(rand.NewSource(3))
+		n, err := NewFeedForward().Input(2).Hidden(4, "tanh").Output(2, act).Rand(rnd).Build()
+		assert.NoError(err)
+		s, err := NewSiamese(n, 1.0)
+		assert.NotNil(s)
+		assert.NoError(err)
+		weights := netWeights(n.Layers()[1:])
+		grad := make([]float64, len(weights))
+		_, err = s.contrastive(weights, grad, aMx, bMx, simVec)
+		assert.NoError(err)
+		x := make([]float64, len(weights))
+		copy(x, weights)
+		eps := 1e-6
+		for i := range x {
+			x[i] = weights[i] + eps
+			plus, err := s.contrastive(x, nil, aMx, bMx, simVec)
+			assert.NoError(err)
+			x[i] = weights[i] - eps
+			minus, err := s.contrastive(x, nil, aMx, bMx, simVec)
+			assert.NoError(err)
+			x[i] = weights[i]
+			assert.InDelta((plus-minus)/(2*eps), grad[i], 1e-6, act)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// somMinRadius is the neighborhood radius at the end of self-organizing map training
+const somMinRadius = 0.1
+
+// SOM is a self-organizing map: a rectangular grid of units whose weights live in
+// the space of samples. Trained map clusters samples by assigning each sample to the unit
+// with the closest weights; neighboring units represent similar samples.
+type SOM struct {
+	c *config.SOMConfig
+	// weights contains unit weights: one row per unit, units are stored row by row
+	weights *mat64.Dense
+	// rnd is the source of random numbers used in training
+	rnd *rand.Rand
+}
+
+// NewSOM creates new self-organizing map per configuration passed in as parameter.
+// Initial unit weights are drawn uniformly from [0, 1) interval using the default
+// math/rand source. It fails with error if the configuration is invalid.
+func NewSOM(c *config.SOMConfig) (*SOM, error) {
+	return NewSOMWithRand(c, nil)
+}
+
+// NewSOMWithRand creates new self-organizing map the same way as NewSOM does, but it draws
+// initial unit weights and sample order in training from rnd. If rnd is nil, the default
+// math/rand source is used.
+func NewSOMWithRand(c *config.SOMConfig, rnd *rand.Rand) (*SOM, error) {
+	// supplied configuration cant be nil
+	if c == nil {
+		return nil, fmt.Errorf("Invalid som configuration: %v\n", c)
+	}
+	if c.Inputs <= 0 {
+		return nil, fmt.Errorf("Incorrect number of inputs: %d\n", c.Inputs)
+	}
+	if c.Rows <= 0 || c.Cols <= 0 {
+		return nil, fmt.Errorf("Incorrect map size: %d x %d\n", c.Rows, c.Cols)
+	}
+	if c.Epochs <= 0 {
+		return nil, fmt.Errorf("Incorrect number of epochs: %d\n", c.Epochs)
+	}
+	if c.LearningRate <= 0 {
+		return nil, fmt.Errorf("Incorrect learning rate: %f\n", c.LearningRate)
+	}
+	if c.Radius < 0 {
+		return nil, fmt.Errorf("Incorrect neighborhood radius: %f\n", c.Radius)
+	}
+	weights, err := matrix.MakeRandMxFrom(matrix.Uniform, matrix.DistParams{Min: 0.0, Max: 1.0},
+		c.Rows*c.Cols, c.Inputs, rnd)
+	if err != nil {
+		return nil, err
+	}
+	sc := *c
+	return &SOM{c: &sc, weights: weights, rnd: randOrDefault(rnd)}, nil
+}
+
+// Dims returns the number of map grid rows and columns
+func (s *SOM) Dims() (int, int) {
+	return s.c.Rows, s.c.Cols
+}
+
+// Weights returns a copy of unit weights matrix: each row contains weights of a single unit.
+// Unit u is located in grid row u / cols and grid column u % cols.
+func (s *SOM) Weights() *mat64.Dense {
+	return mat64.DenseCopyOf(s.weights)
+}
+
+// Train trains the self-organizing map on the samples stored in rows of inMx.
+// Samples are presented in random order in every epoch. The weights of the unit closest to
+// the presented sample and of its grid neighbors move towards the sample proportionally to
+// the learning rate and the gaussian neighborhood function. Both the learning rate and the
+// neighborhood radius decay linearly: the radius decays to a small fraction of the grid unit,
+// so that only the closest units are fine-tuned at the end of the training.
+// It fails with error if the samples dimension does not match the map inputs.
+func (s *SOM) Train(inMx mat64.Matrix) error {
+	if err := s.checkInput(inMx); err != nil {
+		return err
+	}
+	samples, _ := inMx.Dims()
+	units, _ := s.weights.Dims()
+	rows := mat64.DenseCopyOf(inMx)
+	total := float64(s.c.Epochs * samples)
+	radius := math.Max(s.c.Radius, somMinRadius)
+	diff := make([]float64, s.c.Inputs)
+	t := 0
+	for epoch := 0; epoch < s.c.Epochs; epoch++ {
+		for _, i := range s.rnd.Perm(samples) {
+			decay := 1.0 - float64(t)/total
+			rate := s.c.LearningRate * decay
+			sigma := somMinRadius + (radius-somMinRadius)*decay
+			x := rows.RawRowView(i)
+			bmu := s.bmu(x)
+			br, bc := bmu/s.c.Cols, bmu%s.c.Cols
+			for u := 0; u < units; u++ {
+				ur, uc := u/s.c.Cols, u%s.c.Cols
+				d2 := float64((ur-br)*(ur-br) + (uc-bc)*(uc-bc))
+				h := math.Exp(-d2 / (2 * sigma * sigma))
+				// w = w + rate * h * (x - w)
+				w := s.weights.RawRowView(u)
+				floats.SubTo(diff, x, w)
+				floats.AddScaled(w, rate*h, diff)
+			}
+			t++
+		}
+	}
+	netLogger.Infof("Trained %d epochs", s.c.Epochs)
+	return nil
+}
+
+// Assign returns the index of the unit closest to each sample stored in rows of inMx.
+// Unit index identifies the cluster the sample belongs to.
+// It fails with error if the samples dimension does not match the map inputs.
+func (s *SOM) Assign(inMx mat64.Matrix) ([]int, error) {
+	if err := s.checkInput(inMx); err != nil {
+		return nil, err
+	}
+	samples, _ := inMx.Dims()
+	x := make([]float64, s.c.Inputs)
+	units := make([]int, samples)
+	for i := range units {
+		mat64.Row(x, i, inMx)
+		units[i] = s.bmu(x)
+	}
+	return units, nil
+}
+
+// Map maps samples stored in rows of inMx onto the map grid. It returns a matrix
+// with a row per sample which contains grid row and column of the closest unit.
+// It fails with error if the samples dimension does not match the map inputs.
+func (s *SOM) Map(inMx mat64.Matrix) (*mat64.Dense, error) {
+	units, err := s.Assign(inMx)
+	if err != nil {
+		return nil, err
+	}
+	out := mat64.NewDense(len(units), 2, nil)
+	for i, u := range units {
+		out.Set(i, 0, float64(u/s.c.Cols))
+		out.Set(i, 1, float64(u%s.c.Cols))
+	}
+	return out, nil
+}
+
+// QuantError returns quantization error of the samples stored in rows of inMx:
+// the average euclidean distance between the samples and their closest units.
+// It fails with error if the samples dimension does not match the map inputs.
+func (s *SOM) QuantError(inMx mat64.Matrix) (float64, error) {
+	if err := s.checkInput(inMx); err != nil {
+		return 0.0, err
+	}
+	return s.quantError(mat64.DenseCopyOf(inMx)), nil
+}
+
+// quantError calculates quantization error of samples stored in rows of inMx
+func (s *SOM) quantError(inMx *mat64.Dense) float64 {
+	samples, _ := inMx.Dims()
+	sum := 0.0
+	for i := 0; i < samples; i++ {
+		x := inMx.RawRowView(i)
+		sum += floats.Distance(x, s.weights.RawRowView(s.bmu(x)), 2)
+	}
+	return sum / float64(samples)
+}
+
+// bmu returns the index of the best matching unit: the unit closest to sample x
+func (s *SOM) bmu(x []float64) int {
+	units, _ := s.weights.Dims()
+	best, bestDist := 0, math.Inf(1)
+	for u := 0; u < units; u++ {
+		w := s.weights.RawRowView(u)
+		dist := 0.0
+		for j := range x {
+			dist += (x[j] - w[j]) * (x[j] - w[j])
+		}
+		if dist < bestDist {
+			best, bestDist = u, dist
+		}
+	}
+	return best
+}
+
+// checkInput checks if the samples stored in rows of inMx can be mapped
+func (s *SOM) checkInput(inMx mat64.Matrix) error {
+	if inMx == nil {
+		return fmt.Errorf("Incorrect input supplied: %v\n", inMx)
+	}
+	samples, cols := inMx.Dims()
+	if samples == 0 || cols != s.c.Inputs {
+		return fmt.Errorf("Dimension mismatch. Inputs: %d, Samples: %d x %d\n", s.c.Inputs, samples, cols)
+	}
+	return nil
+}
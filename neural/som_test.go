@@ -0,0 +1,98 @@
+package neural
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSOM(t *testing.T) {
+	assert := assert.New(t)
+	c := &config.SOMConfig{Inputs: 2, Rows: 3, Cols: 4, Epochs: 10, LearningRate: 0.5, Radius: 2.0}
+	s, err := NewSOM(c)
+	assert.NotNil(s)
+	assert.NoError(err)
+	rows, cols := s.Dims()
+	assert.Equal(3, rows)
+	assert.Equal(4, cols)
+	units, inputs := s.Weights().Dims()
+	assert.Equal(12, units)
+	assert.Equal(2, inputs)
+	// returned weights are a copy
+	s.Weights().Set(0, 0, 10.0)
+	assert.NotEqual(10.0, s.Weights().At(0, 0))
+	// incorrect configuration
+	s, err = NewSOM(nil)
+	assert.Nil(s)
+	assert.Error(err)
+	for _, set := range []func(*config.SOMConfig){
+		func(c *config.SOMConfig) { c.Inputs = 0 },
+		func(c *config.SOMConfig) { c.Rows = 0 },
+		func(c *config.SOMConfig) { c.Epochs = 0 },
+		func(c *config.SOMConfig) { c.LearningRate = 0.0 },
+		func(c *config.SOMConfig) { c.Radius = -1.0 },
+	} {
+		badConf := *c
+		set(&badConf)
+		s, err = NewSOM(&badConf)
+		assert.Nil(s)
+		assert.Error(err)
+	}
+}
+
+func TestSOMCluster(t *testing.T) {
+	assert := assert.New(t)
+	c := &config.SOMConfig{Inputs: 2, Rows: 2, Cols: 2, Epochs: 50, LearningRate: 0.5, Radius: 1.0}
+	s, err := NewSOM(c)
+	assert.NotNil(s)
+	assert.NoError(err)
+	// two well separated groups of samples
+	inMx := mat64.NewDense(6, 2, []float64{
+		0.0, 0.1,
+		0.1, 0.0,
+		0.0, 0.0,
+		5.0, 5.1,
+		5.1, 5.0,
+		5.0, 5.0,
+	})
+	// incorrect input
+	assert.Error(s.Train(nil))
+	assert.Error(s.Train(mat64.NewDense(2, 3, nil)))
+	units, err := s.Assign(mat64.NewDense(2, 3, nil))
+	assert.Nil(units)
+	assert.Error(err)
+	// samples of the same group belong to the same cluster
+	initErr, err := s.QuantError(inMx)
+	assert.NoError(err)
+	assert.NoError(s.Train(inMx))
+	units, err = s.Assign(inMx)
+	assert.NoError(err)
+	assert.Len(units, 6)
+	assert.Equal(units[0], units[1])
+	assert.Equal(units[0], units[2])
+	assert.Equal(units[3], units[4])
+	assert.Equal(units[3], units[5])
+	assert.NotEqual(units[0], units[3])
+	qErr, err := s.QuantError(inMx)
+	assert.NoError(err)
+	assert.True(qErr < initErr)
+	assert.True(qErr < 0.5)
+	// samples are mapped onto grid coordinates of their units
+	grid, err := s.Map(inMx)
+	assert.NoError(err)
+	for i, u := range units {
+		assert.Equal(float64(u/2), grid.At(i, 0))
+		assert.Equal(float64(u%2), grid.At(i, 1))
+	}
+	// maps trained with the same source of random numbers are equal
+	s1, err := NewSOMWithRand(c, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	assert.NoError(s1.Train(inMx))
+	s2, err := NewSOMWithRand(c, rand.New(rand.NewSource(1)))
+	assert.NoError(err)
+	assert.NoError(s2.Train(inMx))
+	assert.True(mat64.Equal(s1.Weights(), s2.Weights()))
+}
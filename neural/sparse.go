@@ -0,0 +1,57 @@
+package neural
+
+import "github.com/gonum/matrix/mat64"
+
+// sparseMx is a weights matrix stored in compressed sparse row (CSR) format
+type sparseMx struct {
+	// rows and cols are matrix dimensions
+	rows, cols int
+	// indptr contains offsets of each row's non-zero elements in ind and data
+	indptr []int
+	// ind contains column indices of non-zero elements
+	ind []int
+	// data contains non-zero elements
+	data []float64
+}
+
+// newSparseMx creates sparse matrix from the non-zero elements of the supplied dense matrix
+func newSparseMx(mx *mat64.Dense) *sparseMx {
+	rows, cols := mx.Dims()
+	s := &sparseMx{
+		rows:   rows,
+		cols:   cols,
+		indptr: make([]int, rows+1),
+	}
+	for i := 0; i < rows; i++ {
+		for j, v := range mx.RawRowView(i) {
+			if v != 0.0 {
+				s.ind = append(s.ind, j)
+				s.data = append(s.data, v)
+			}
+		}
+		s.indptr[i+1] = len(s.data)
+	}
+	return s
+}
+
+// NNZ returns the number of non-zero matrix elements
+func (s *sparseMx) NNZ() int {
+	return len(s.data)
+}
+
+// mulT multiplies inMx by transposed sparse matrix and stores the result in out: out = inMx * s'
+// out must be allocated with as many rows as inMx has and as many columns as s has rows.
+func (s *sparseMx) mulT(out, inMx *mat64.Dense) {
+	rows, _ := inMx.Dims()
+	for i := 0; i < rows; i++ {
+		in := inMx.RawRowView(i)
+		outRow := out.RawRowView(i)
+		for r := 0; r < s.rows; r++ {
+			sum := 0.0
+			for k := s.indptr[r]; k < s.indptr[r+1]; k++ {
+				sum += s.data[k] * in[s.ind[k]]
+			}
+			outRow[r] = sum
+		}
+	}
+}
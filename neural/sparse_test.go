@@ -0,0 +1,31 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparseMx(t *testing.T) {
+	assert := assert.New(t)
+
+	weights := mat64.NewDense(3, 4, []float64{
+		1.0, 0.0, 0.0, 2.0,
+		0.0, 0.0, 0.0, 0.0,
+		0.0, 3.0, 4.0, 0.0,
+	})
+	s := newSparseMx(weights)
+	assert.Equal(4, s.NNZ())
+	assert.Equal([]int{0, 2, 2, 4}, s.indptr)
+	// sparse multiplication matches the dense one
+	inMx := mat64.NewDense(2, 4, []float64{
+		1.0, 2.0, 3.0, 4.0,
+		5.0, 6.0, 7.0, 8.0,
+	})
+	expMx := new(mat64.Dense)
+	expMx.Mul(inMx, weights.T())
+	out := mat64.NewDense(2, 3, nil)
+	s.mulT(out, inMx)
+	assert.True(mat64.Equal(expMx, out))
+}
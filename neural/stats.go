@@ -0,0 +1,72 @@
+package neural
+
+import (
+	"fmt"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/stat"
+)
+
+// WeightStats contains statistics of neural network weights.
+// They allow to detect unhealthy training e.g. dead ReLU layers or exploding weights.
+type WeightStats struct {
+	// Count is the number of weights
+	Count int `json:"count"`
+	// Min is the smallest weight
+	Min float64 `json:"min"`
+	// Max is the biggest weight
+	Max float64 `json:"max"`
+	// Mean is the mean weight
+	Mean float64 `json:"mean"`
+	// StdDev is the standard deviation of weights
+	StdDev float64 `json:"stddev"`
+	// ZeroFraction is the fraction of weights which are zero
+	ZeroFraction float64 `json:"zero_fraction"`
+}
+
+// WeightStats returns statistics of all layer weights including bias weights.
+// It fails with error if the layer is an INPUT layer: INPUT layer has no weights matrix.
+func (l *Layer) WeightStats() (WeightStats, error) {
+	if l.kind == INPUT {
+		return WeightStats{}, fmt.Errorf("Can't calculate weight stats of %s layer\n", l.kind)
+	}
+	rows, _ := l.weights.Dims()
+	var weights []float64
+	for i := 0; i < rows; i++ {
+		weights = append(weights, l.weights.RawRowView(i)...)
+	}
+	return weightStats(weights), nil
+}
+
+// WeightStats returns statistics of all network weights aggregated across all network layers
+// along with the statistics of every non-INPUT layer sorted from the first HIDDEN to OUTPUT layer.
+func (n *Network) WeightStats() (WeightStats, []WeightStats) {
+	layers := n.Layers()[1:]
+	layerStats := make([]WeightStats, len(layers))
+	for i, layer := range layers {
+		// only INPUT layer fails and it has been skipped
+		layerStats[i], _ = layer.WeightStats()
+	}
+	return weightStats(netWeights(layers)), layerStats
+}
+
+// weightStats calculates statistics of supplied weights
+func weightStats(weights []float64) WeightStats {
+	if len(weights) == 0 {
+		return WeightStats{}
+	}
+	zeros := 0
+	for _, w := range weights {
+		if w == 0.0 {
+			zeros++
+		}
+	}
+	s := WeightStats{
+		Count:        len(weights),
+		Min:          floats.Min(weights),
+		Max:          floats.Max(weights),
+		ZeroFraction: float64(zeros) / float64(len(weights)),
+	}
+	s.Mean, s.StdDev = stat.MeanStdDev(weights, nil)
+	return s
+}
@@ -0,0 +1,45 @@
+package neural
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightStats(t *testing.T) {
+	assert := assert.New(t)
+	n, err := NewFeedForward().Input(2).Hidden(2, "relu").Output(1, "sigmoid").Build()
+	assert.NoError(err)
+	layers := n.Layers()
+	// INPUT layer has no weights
+	_, err = layers[0].WeightStats()
+	assert.Error(err)
+	// HIDDEN layer: 2 x 3 weights, half of them dead
+	err = layers[1].SetWeights(mat64.NewDense(2, 3, []float64{
+		0.0, 0.0, 0.0,
+		-1.0, 2.0, 5.0,
+	}))
+	assert.NoError(err)
+	s, err := layers[1].WeightStats()
+	assert.NoError(err)
+	assert.Equal(6, s.Count)
+	assert.Equal(-1.0, s.Min)
+	assert.Equal(5.0, s.Max)
+	assert.Equal(1.0, s.Mean)
+	assert.InDelta(2.1909, s.StdDev, 1e-4)
+	assert.Equal(0.5, s.ZeroFraction)
+	// OUTPUT layer: 1 x 3 weights
+	err = layers[2].SetWeights(mat64.NewDense(1, 3, []float64{4.0, 4.0, 4.0}))
+	assert.NoError(err)
+	// network statistics aggregate all layers
+	total, layerStats := n.WeightStats()
+	assert.Len(layerStats, 2)
+	assert.Equal(s, layerStats[0])
+	assert.Equal(WeightStats{Count: 3, Min: 4.0, Max: 4.0, Mean: 4.0}, layerStats[1])
+	assert.Equal(9, total.Count)
+	assert.Equal(-1.0, total.Min)
+	assert.Equal(5.0, total.Max)
+	assert.Equal(2.0, total.Mean)
+	assert.InDelta(1.0/3.0, total.ZeroFraction, 1e-12)
+}
@@ -0,0 +1,57 @@
+package neural
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+)
+
+// weightAverage calculates running average of network weights sampled over training iterations
+type weightAverage struct {
+	// start is the first sampled iteration
+	start int
+	// every is the number of iterations between two samples
+	every int
+	// avg contains averaged weights
+	avg []float64
+	// count is the number of averaged samples
+	count int
+}
+
+// add adds weights of the training iteration iter to the average if the iteration is sampled
+func (a *weightAverage) add(iter int, weights []float64) {
+	if iter < a.start {
+		return
+	}
+	if a.every > 0 && (iter-a.start)%a.every != 0 {
+		return
+	}
+	if a.avg == nil {
+		a.avg = make([]float64, len(weights))
+	}
+	a.count++
+	// avg = avg + (weights - avg) / count
+	floats.AddScaled(a.avg, -1/float64(a.count), a.avg)
+	floats.AddScaled(a.avg, 1/float64(a.count), weights)
+}
+
+// setAvgWeights sets network weights to the weights averaged by a and updates the network
+// metadata cost to the cost of the averaged weights. If a is nil or it has not averaged
+// any weights, the network is left intact.
+func (n *Network) setAvgWeights(c *config.TrainConfig, a *weightAverage,
+	inMx *mat64.Dense, labelsVec *mat64.Vector) error {
+	if a == nil || a.count == 0 {
+		return nil
+	}
+	cost, err := n.getCost(c, new(workspace), a.avg, inMx, labelsVec)
+	if err != nil {
+		return fmt.Errorf("Weight averaging failed: %v\n", err)
+	}
+	netLogger.Infof("Averaged weights of %d iterations", a.count)
+	n.meta.Trained = time.Now()
+	n.meta.Cost = cost
+	return nil
+}
@@ -0,0 +1,75 @@
+package neural
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightAverage(t *testing.T) {
+	assert := assert.New(t)
+	a := &weightAverage{start: 3, every: 2}
+	for iter := 1; iter <= 8; iter++ {
+		w := float64(iter)
+		a.add(iter, []float64{w, -w})
+	}
+	// iterations 3, 5 and 7 are averaged
+	assert.Equal(3, a.count)
+	assert.InDelta(5.0, a.avg[0], 1e-12)
+	assert.InDelta(-5.0, a.avg[1], 1e-12)
+}
+
+func TestTrainSWA(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	trainConf := *conf.Training
+	trainConf.Optimize = &config.OptimConfig{
+		Method:       "sgd",
+		Epochs:       10,
+		BatchSize:    2,
+		LearningRate: 0.5,
+	}
+	// incorrect weight averaging parameters
+	trainConf.SWAStart = -1
+	assert.Error(ValidateTrainConfig(&trainConf))
+	trainConf.SWAStart, trainConf.SWAEvery = 5, -1
+	assert.Error(ValidateTrainConfig(&trainConf))
+	// network weights are set to the average of the sampled epochs
+	trainConf.SWAEvery = 1
+	var sum []float64
+	m := &Monitor{
+		Callbacks: []Callback{func(m *Metrics) error {
+			if m.Iter < 5 {
+				return nil
+			}
+			weights := netWeights(n.Layers()[1:])
+			if sum == nil {
+				sum = make([]float64, len(weights))
+			}
+			for i := range weights {
+				sum[i] += weights[i]
+			}
+			return nil
+		}},
+	}
+	_, err = n.TrainMonitored(&trainConf, inMx, labelsVec, m)
+	assert.NoError(err)
+	weights := netWeights(n.Layers()[1:])
+	for i := range sum {
+		assert.InDelta(sum[i]/6.0, weights[i], 1e-9)
+	}
+	// metadata cost is the cost of averaged weights
+	cost, err := n.getCost(&trainConf, new(workspace), nil, inMx, labelsVec)
+	assert.NoError(err)
+	assert.InDelta(cost, n.Metadata().Cost, 1e-12)
+}
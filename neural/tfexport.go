@@ -0,0 +1,335 @@
+package neural
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// TensorFlow data types used by exported graphs
+const (
+	tfFloat = 1
+	tfInt32 = 3
+	tfInt64 = 9
+)
+
+const (
+	// tfGraphProducer is the GraphDef version of exported graphs
+	tfGraphProducer = 27
+	// tfServeTag tags the exported SavedModel graph so that TensorFlow Serving can load it
+	tfServeTag = "serve"
+	// tfSignature is the name of the exported SavedModel signature
+	tfSignature = "serving_default"
+	// tfPredictMethod is the method name of the exported SavedModel signature
+	tfPredictMethod = "tensorflow/serving/predict"
+)
+
+// tfActivations maps activation functions to TensorFlow ops
+var tfActivations = map[string]string{
+	"sigmoid":   "Sigmoid",
+	"tanh":      "Tanh",
+	"relu":      "LeakyRelu",
+	"plainrelu": "Relu",
+	"softsign":  "Softsign",
+	"softmax":   "Softmax",
+}
+
+// pbuf encodes protocol buffers messages field by field
+type pbuf []byte
+
+// varint appends varint encoded field
+func (p *pbuf) varint(field int, v uint64) {
+	p.key(field, 0)
+	p.uvarint(v)
+}
+
+// fixed32 appends 32-bit field
+func (p *pbuf) fixed32(field int, v uint32) {
+	p.key(field, 5)
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	*p = append(*p, buf[:]...)
+}
+
+// bytes appends length delimited field
+func (p *pbuf) bytes(field int, b []byte) {
+	p.key(field, 2)
+	p.uvarint(uint64(len(b)))
+	*p = append(*p, b...)
+}
+
+// str appends string field
+func (p *pbuf) str(field int, s string) {
+	p.bytes(field, []byte(s))
+}
+
+// key appends field key
+func (p *pbuf) key(field, wireType int) {
+	p.uvarint(uint64(field<<3 | wireType))
+}
+
+// uvarint appends varint encoded v
+func (p *pbuf) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	*p = append(*p, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+// tfShape encodes TensorShapeProto: unknown dimensions are -1
+func tfShape(dims ...int) pbuf {
+	var shape pbuf
+	for _, d := range dims {
+		var dim pbuf
+		dim.varint(1, uint64(int64(d)))
+		shape.bytes(2, dim)
+	}
+	return shape
+}
+
+// tfFloatTensor encodes TensorProto containing float32 values of vals
+func tfFloatTensor(vals []float64, dims ...int) pbuf {
+	content := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint32(content[4*i:], math.Float32bits(float32(v)))
+	}
+	var t pbuf
+	t.varint(1, tfFloat)
+	t.bytes(2, tfShape(dims...))
+	t.bytes(4, content)
+	return t
+}
+
+// tfInt32Tensor encodes TensorProto containing int32 values of vals
+func tfInt32Tensor(vals []int32, dims ...int) pbuf {
+	content := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint32(content[4*i:], uint32(v))
+	}
+	var t pbuf
+	t.varint(1, tfInt32)
+	t.bytes(2, tfShape(dims...))
+	t.bytes(4, content)
+	return t
+}
+
+// tfAttr holds encoded AttrValue of a graph node attribute
+type tfAttr struct {
+	name  string
+	value pbuf
+}
+
+// tfTypeAttr returns data type attribute
+func tfTypeAttr(name string, dtype int) tfAttr {
+	var v pbuf
+	v.varint(6, uint64(dtype))
+	return tfAttr{name: name, value: v}
+}
+
+// tfGraph builds GraphDef node by node
+type tfGraph struct {
+	nodes pbuf
+}
+
+// node appends node with the given name, op, inputs and attributes and returns its name
+func (g *tfGraph) node(name, op string, inputs []string, attrs ...tfAttr) string {
+	var n pbuf
+	n.str(1, name)
+	n.str(2, op)
+	for _, in := range inputs {
+		n.str(3, in)
+	}
+	for _, a := range attrs {
+		var entry pbuf
+		entry.str(1, a.name)
+		entry.bytes(2, a.value)
+		n.bytes(5, entry)
+	}
+	g.nodes.bytes(1, n)
+	return name
+}
+
+// op appends float32 op node with the given inputs
+func (g *tfGraph) op(name, op string, inputs ...string) string {
+	return g.node(name, op, inputs, tfTypeAttr("T", tfFloat))
+}
+
+// constant appends constant node holding encoded tensor
+func (g *tfGraph) constant(name string, dtype int, tensor pbuf) string {
+	var v pbuf
+	v.bytes(8, tensor)
+	return g.node(name, "Const", nil, tfTypeAttr("dtype", dtype), tfAttr{name: "value", value: v})
+}
+
+// encode returns encoded GraphDef
+func (g *tfGraph) encode() pbuf {
+	graph := append(pbuf(nil), g.nodes...)
+	var versions pbuf
+	versions.varint(1, tfGraphProducer)
+	graph.bytes(4, versions)
+	return graph
+}
+
+// tfGraphDef builds TensorFlow graph which computes the same outputs as the network. The graph
+// has a single float32 input node called inputs which accepts raw samples: it scales them by the
+// network scaler if the network contains one. It has two output nodes: probabilities contains
+// the probabilities of samples belonging to each label class and classes contains predicted labels
+// mapped to data set labels. It fails with error if the network contains layers other than dense.
+func (n *Network) tfGraphDef() (pbuf, error) {
+	layers := n.Layers()
+	if len(layers) < 2 {
+		return nil, fmt.Errorf("Incorrect number of network layers: %d\n", len(layers))
+	}
+	if n.Pipeline() != nil {
+		return nil, fmt.Errorf("Can't export features preprocessing pipeline\n")
+	}
+	_, in := layers[1].weights.Dims()
+	in--
+	g := new(tfGraph)
+	var shape pbuf
+	shape.bytes(7, tfShape(-1, in))
+	x := g.node("inputs", "Placeholder", nil, tfTypeAttr("dtype", tfFloat), tfAttr{name: "shape", value: shape})
+	if s := n.Scaler(); s != nil {
+		if len(s.Mean) != in || len(s.StdDev) != in {
+			return nil, fmt.Errorf("Scaler size does not match network inputs: %d\n", in)
+		}
+		mean := g.constant("scale/mean", tfFloat, tfFloatTensor(s.Mean, in))
+		stdDev := g.constant("scale/stddev", tfFloat, tfFloatTensor(s.StdDev, in))
+		x = g.op("scale/truediv", "RealDiv", g.op("scale/sub", "Sub", x, mean), stdDev)
+	}
+	var out string
+	for i, layer := range layers[1:] {
+		if layer.highway {
+			return nil, fmt.Errorf("Can't export highway layer %s\n", layer.ID())
+		}
+		op, ok := tfActivations[layer.meta]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported activation function: %s\n", layer.meta)
+		}
+		prefix := fmt.Sprintf("layer_%d/", i+1)
+		// layer weights are transposed so that samples are multiplied by kernel from the left
+		rows, cols := layer.weights.Dims()
+		kernel := make([]float64, 0, rows*(cols-1))
+		for j := 1; j < cols; j++ {
+			for k := 0; k < rows; k++ {
+				kernel = append(kernel, layer.weights.At(k, j))
+			}
+		}
+		bias := make([]float64, rows)
+		for k := range bias {
+			bias[k] = layer.weights.At(k, 0)
+		}
+		x = g.op(prefix+"MatMul", "MatMul", x, g.constant(prefix+"kernel", tfFloat, tfFloatTensor(kernel, cols-1, rows)))
+		x = g.op(prefix+"BiasAdd", "BiasAdd", x, g.constant(prefix+"bias", tfFloat, tfFloatTensor(bias, rows)))
+		if op == "LeakyRelu" {
+			var alpha pbuf
+			alpha.fixed32(4, math.Float32bits(0.1))
+			x = g.node(prefix+op, op, []string{x}, tfTypeAttr("T", tfFloat), tfAttr{name: "alpha", value: alpha})
+		} else {
+			x = g.op(prefix+op, op, x)
+		}
+		// tanh and softsign outputs are rescaled to (0, 1) in OUTPUT layer
+		if layer.kind == OUTPUT && (layer.meta == "tanh" || layer.meta == "softsign") {
+			one := g.constant(prefix+"one", tfFloat, tfFloatTensor([]float64{1.0}))
+			half := g.constant(prefix+"half", tfFloat, tfFloatTensor([]float64{0.5}))
+			x = g.op(prefix+"rescale", "Mul", g.op(prefix+"shift", "Add", x, one), half)
+		}
+		out = x
+	}
+	// outputs other than softmax are normalized to probabilities
+	var probs string
+	if layers[len(layers)-1].meta == "softmax" {
+		probs = g.op("probabilities", "Identity", out)
+	} else {
+		axis := g.constant("sum/axis", tfInt32, tfInt32Tensor([]int32{1}, 1))
+		var keepDims pbuf
+		keepDims.varint(5, 1)
+		sum := g.node("sum", "Sum", []string{out, axis}, tfTypeAttr("T", tfFloat),
+			tfTypeAttr("Tidx", tfInt32), tfAttr{name: "keep_dims", value: keepDims})
+		probs = g.op("probabilities", "RealDiv", out, sum)
+	}
+	// predicted network labels are mapped to data set labels
+	classes, _ := layers[len(layers)-1].weights.Dims()
+	labels := make([]float64, classes)
+	for i := range labels {
+		labels[i] = float64(i + 1)
+	}
+	if lm := n.LabelMap(); lm != nil {
+		if len(lm) != classes {
+			return nil, fmt.Errorf("Label map size %d does not match network outputs: %d\n", len(lm), classes)
+		}
+		copy(labels, lm)
+	}
+	dim := g.constant("argmax/dimension", tfInt32, tfInt32Tensor([]int32{1}))
+	argMax := g.node("argmax", "ArgMax", []string{probs, dim}, tfTypeAttr("T", tfFloat),
+		tfTypeAttr("Tidx", tfInt32), tfTypeAttr("output_type", tfInt64))
+	labelsConst := g.constant("labels", tfFloat, tfFloatTensor(labels, classes))
+	axis := g.constant("classes/axis", tfInt32, tfInt32Tensor([]int32{0}))
+	g.node("classes", "GatherV2", []string{labelsConst, argMax, axis}, tfTypeAttr("Tparams", tfFloat),
+		tfTypeAttr("Tindices", tfInt64), tfTypeAttr("Taxis", tfInt32))
+	return g.encode(), nil
+}
+
+// ExportGraphDef writes the network to w as a frozen TensorFlow GraphDef: network weights are
+// stored in constant nodes. The graph has a single float32 input node called inputs which accepts
+// raw samples: they are scaled by the network scaler if the network contains one. Output node
+// probabilities contains the probabilities of samples belonging to each label class and output
+// node classes contains the predicted data set labels. Only networks made of dense layers can be
+// exported. It fails with error if the network can't be exported or written.
+func (n *Network) ExportGraphDef(w io.Writer) error {
+	graph, err := n.tfGraphDef()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(graph)
+	return err
+}
+
+// ExportSavedModel exports the network to directory dir in TensorFlow SavedModel format, so that
+// it can be served by TensorFlow Serving. The model contains the graph written by ExportGraphDef
+// tagged with serve tag and serving_default predict signature which maps inputs to probabilities
+// and classes outputs. Weights are frozen in the graph, so the variables directory is empty.
+// It fails with error if the network can't be exported or the model files can't be written.
+func (n *Network) ExportSavedModel(dir string) error {
+	graph, err := n.tfGraphDef()
+	if err != nil {
+		return err
+	}
+	_, in := n.Layers()[1].weights.Dims()
+	classes, _ := n.Layers()[len(n.Layers())-1].weights.Dims()
+	var info pbuf
+	info.str(4, tfServeTag)
+	var sig pbuf
+	sig.bytes(1, tfTensorInfo("inputs", "inputs:0", tfFloat, -1, in-1))
+	sig.bytes(2, tfTensorInfo("probabilities", "probabilities:0", tfFloat, -1, classes))
+	sig.bytes(2, tfTensorInfo("classes", "classes:0", tfFloat, -1))
+	sig.str(3, tfPredictMethod)
+	var sigEntry pbuf
+	sigEntry.str(1, tfSignature)
+	sigEntry.bytes(2, sig)
+	var meta pbuf
+	meta.bytes(1, info)
+	meta.bytes(2, graph)
+	meta.bytes(5, sigEntry)
+	var model pbuf
+	model.varint(1, 1)
+	model.bytes(2, meta)
+	if err := os.MkdirAll(filepath.Join(dir, "variables"), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "saved_model.pb"), model, 0644)
+}
+
+// tfTensorInfo encodes signature map entry which maps key to tensor described by TensorInfo
+func tfTensorInfo(key, tensor string, dtype int, dims ...int) pbuf {
+	var info pbuf
+	info.str(1, tensor)
+	info.varint(2, uint64(dtype))
+	info.bytes(3, tfShape(dims...))
+	var entry pbuf
+	entry.str(1, key)
+	entry.bytes(2, info)
+	return entry
+}
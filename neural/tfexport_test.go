@@ -0,0 +1,264 @@
+package neural
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+// pbFields decodes protocol buffers message into its fields: varint and 32-bit fields
+// are returned as their little endian encoded values
+func pbFields(b []byte) map[int][][]byte {
+	fields := make(map[int][][]byte)
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		b = b[n:]
+		field := int(key >> 3)
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(b)
+			buf := make([]byte, 8)
+			binary.LittleEndian.PutUint64(buf, v)
+			fields[field] = append(fields[field], buf)
+			b = b[n:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			fields[field] = append(fields[field], b[n:n+int(l)])
+			b = b[n+int(l):]
+		case 5:
+			fields[field] = append(fields[field], b[:4])
+			b = b[4:]
+		}
+	}
+	return fields
+}
+
+// tfTensor is a tensor evaluated by tfEval
+type tfTensor struct {
+	vals  []float64
+	shape []int
+}
+
+// tfNode is a decoded GraphDef node
+type tfNode struct {
+	op     string
+	inputs []string
+	attrs  map[string]map[int][][]byte
+}
+
+// tfEval evaluates output node of decoded graph nodes fed by inMx
+func tfEval(nodes map[string]tfNode, name string, inMx *mat64.Dense) tfTensor {
+	node := nodes[name]
+	var in []tfTensor
+	for _, input := range node.inputs {
+		in = append(in, tfEval(nodes, input, inMx))
+	}
+	switch node.op {
+	case "Placeholder":
+		rows, cols := inMx.Dims()
+		return tfTensor{vals: append([]float64(nil), inMx.RawMatrix().Data...), shape: []int{rows, cols}}
+	case "Const":
+		tensor := pbFields(node.attrs["value"][8][0])
+		var shape []int
+		for _, dim := range pbFields(tensor[2][0])[2] {
+			shape = append(shape, int(binary.LittleEndian.Uint64(pbFields(dim)[1][0])))
+		}
+		content := tensor[4][0]
+		vals := make([]float64, len(content)/4)
+		for i := range vals {
+			bits := binary.LittleEndian.Uint32(content[4*i:])
+			if binary.LittleEndian.Uint64(tensor[1][0]) == tfFloat {
+				vals[i] = float64(math.Float32frombits(bits))
+			} else {
+				vals[i] = float64(int32(bits))
+			}
+		}
+		return tfTensor{vals: vals, shape: shape}
+	case "MatMul":
+		a, b := in[0], in[1]
+		out := mat64.NewDense(a.shape[0], b.shape[1], nil)
+		out.Mul(mat64.NewDense(a.shape[0], a.shape[1], a.vals), mat64.NewDense(b.shape[0], b.shape[1], b.vals))
+		return tfTensor{vals: out.RawMatrix().Data, shape: []int{a.shape[0], b.shape[1]}}
+	case "BiasAdd", "Add", "Sub", "Mul", "RealDiv":
+		a, b := in[0], in[1]
+		out := tfTensor{vals: make([]float64, len(a.vals)), shape: a.shape}
+		for i, x := range a.vals {
+			// b is a scalar, a row broadcast to all rows or a column broadcast to all columns
+			var y float64
+			switch {
+			case len(b.vals) == 1:
+				y = b.vals[0]
+			case len(b.shape) == 1:
+				y = b.vals[i%a.shape[1]]
+			default:
+				y = b.vals[i/a.shape[1]]
+			}
+			switch node.op {
+			case "Sub":
+				out.vals[i] = x - y
+			case "Mul":
+				out.vals[i] = x * y
+			case "RealDiv":
+				out.vals[i] = x / y
+			default:
+				out.vals[i] = x + y
+			}
+		}
+		return out
+	case "Sigmoid", "Tanh", "Softsign", "LeakyRelu", "Relu", "Identity":
+		out := tfTensor{vals: make([]float64, len(in[0].vals)), shape: in[0].shape}
+		for i, x := range in[0].vals {
+			switch node.op {
+			case "Sigmoid":
+				out.vals[i] = 1 / (1 + math.Exp(-x))
+			case "Tanh":
+				out.vals[i] = math.Tanh(x)
+			case "Softsign":
+				out.vals[i] = x / (1 + math.Abs(x))
+			case "LeakyRelu":
+				alpha := float64(math.Float32frombits(binary.LittleEndian.Uint32(node.attrs["alpha"][4][0])))
+				out.vals[i] = math.Max(x, alpha*x)
+			case "Relu":
+				out.vals[i] = math.Max(x, 0)
+			default:
+				out.vals[i] = x
+			}
+		}
+		return out
+	case "Softmax", "Sum", "ArgMax":
+		rows, cols := in[0].shape[0], in[0].shape[1]
+		out := tfTensor{shape: []int{rows, 1}}
+		if node.op == "Softmax" {
+			out.shape = in[0].shape
+		}
+		for i := 0; i < rows; i++ {
+			row := in[0].vals[i*cols : (i+1)*cols]
+			sum, max := 0.0, 0
+			for j, x := range row {
+				sum += math.Exp(x)
+				if x > row[max] {
+					max = j
+				}
+			}
+			switch node.op {
+			case "Softmax":
+				for _, x := range row {
+					out.vals = append(out.vals, math.Exp(x)/sum)
+				}
+			case "Sum":
+				sum = 0.0
+				for _, x := range row {
+					sum += x
+				}
+				out.vals = append(out.vals, sum)
+			default:
+				out.vals = append(out.vals, float64(max))
+			}
+		}
+		return out
+	case "GatherV2":
+		out := tfTensor{shape: []int{len(in[1].vals)}}
+		for _, idx := range in[1].vals {
+			out.vals = append(out.vals, in[0].vals[int(idx)])
+		}
+		return out
+	}
+	panic("unsupported op " + node.op)
+}
+
+// tfNodes decodes GraphDef nodes
+func tfNodes(graph []byte) map[string]tfNode {
+	nodes := make(map[string]tfNode)
+	for _, b := range pbFields(graph)[1] {
+		fields := pbFields(b)
+		node := tfNode{op: string(fields[2][0]), attrs: make(map[string]map[int][][]byte)}
+		for _, in := range fields[3] {
+			node.inputs = append(node.inputs, string(in))
+		}
+		for _, entry := range fields[5] {
+			attr := pbFields(entry)
+			node.attrs[string(attr[1][0])] = pbFields(attr[2][0])
+		}
+		nodes[string(fields[1][0])] = node
+	}
+	return nodes
+}
+
+func TestExportGraphDef(t *testing.T) {
+	assert := assert.New(t)
+	for _, output := range []string{"softmax", "sigmoid", "tanh", "softsign"} {
+		n, err := NewFeedForward().Input(4).Hidden(6, "relu").Hidden(4, "plainrelu").Hidden(3, "tanh").Output(5, output).
+			Rand(rand.New(rand.NewSource(1))).Build()
+		assert.NoError(err)
+		n.SetScaler(dataset.NewScaler(inMx))
+		n.SetLabelMap(dataset.LabelMap{10, 20, 30, 40, 50})
+		var buf bytes.Buffer
+		assert.NoError(n.ExportGraphDef(&buf))
+		nodes := tfNodes(buf.Bytes())
+		assert.Equal("Placeholder", nodes["inputs"].op)
+		// exported graph computes the same probabilities and labels as the network
+		scaled, err := n.Scaler().Scale(inMx)
+		assert.NoError(err)
+		classMx, err := n.Classify(scaled)
+		assert.NoError(err)
+		labels, _, err := n.Predict(scaled)
+		assert.NoError(err)
+		probs := tfEval(nodes, "probabilities", inMx)
+		classes := tfEval(nodes, "classes", inMx)
+		samples, results := classMx.Dims()
+		assert.Equal([]int{samples, results}, probs.shape)
+		for i := 0; i < samples; i++ {
+			for j := 0; j < results; j++ {
+				assert.InDelta(classMx.At(i, j)/100, probs.vals[i*results+j], 1e-5, output)
+			}
+			assert.Equal(10*labels.At(i, 0), classes.vals[i], output)
+		}
+	}
+	// highway layers can't be exported
+	n, err := NewFeedForward().Input(4).Hidden(3, "tanh").Highway("relu").Output(5, "softmax").Build()
+	assert.NoError(err)
+	assert.Error(n.ExportGraphDef(ioutil.Discard))
+	// preprocessing pipelines can't be exported
+	n, err = NewFeedForward().Input(4).Hidden(3, "tanh").Output(5, "softmax").Build()
+	assert.NoError(err)
+	n.SetPipeline(dataset.NewPipeline(new(dataset.Scaler)))
+	assert.Error(n.ExportGraphDef(ioutil.Discard))
+}
+
+func TestExportSavedModel(t *testing.T) {
+	assert := assert.New(t)
+	n, err := NewFeedForward().Input(4).Hidden(6, "sigmoid").Output(5, "softmax").Build()
+	assert.NoError(err)
+	dir, err := ioutil.TempDir("", "savedmodel")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	assert.NoError(n.ExportSavedModel(dir))
+	info, err := os.Stat(filepath.Join(dir, "variables"))
+	assert.NoError(err)
+	assert.True(info.IsDir())
+	data, err := ioutil.ReadFile(filepath.Join(dir, "saved_model.pb"))
+	assert.NoError(err)
+	model := pbFields(data)
+	assert.Equal(uint64(1), binary.LittleEndian.Uint64(model[1][0]))
+	meta := pbFields(model[2][0])
+	assert.Equal(tfServeTag, string(pbFields(meta[1][0])[4][0]))
+	var graph bytes.Buffer
+	assert.NoError(n.ExportGraphDef(&graph))
+	assert.Equal(graph.Bytes(), meta[2][0])
+	// serving signature maps inputs to outputs
+	sigEntry := pbFields(meta[5][0])
+	assert.Equal(tfSignature, string(sigEntry[1][0]))
+	sig := pbFields(sigEntry[2][0])
+	assert.Equal("inputs:0", string(pbFields(pbFields(sig[1][0])[2][0])[1][0]))
+	assert.Len(sig[2], 2)
+	assert.Equal(tfPredictMethod, string(sig[3][0]))
+}
@@ -0,0 +1,224 @@
+package neural
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// actCache caches layer outputs and activation inputs computed in a single forward pass
+// and the errors backpropagated to each network layer. Cache matrices are allocated once
+// and reused in every forward and backward pass of the same number of samples.
+type actCache struct {
+	// biasOut contains outputs of all but the OUTPUT layer with bias in the first column
+	biasOut []*mat64.Dense
+	// out contains outputs of all network layers: out[0] is the network input
+	out []*mat64.Dense
+	// actIn contains activation inputs of all network layers: actIn[0] is nil
+	// Backpropagation overwrites the activation inputs with activation gradients.
+	actIn []*mat64.Dense
+	// errMx contains errors backpropagated to network layers: errMx[0] is nil
+	errMx []*mat64.Dense
+	// carry contains errors of highway layer outputs: nil for other layers
+	carry []*mat64.Dense
+}
+
+// newActCache allocates activation cache of the network layers for given number of samples
+func newActCache(layers []*Layer, samples int) *actCache {
+	cache := &actCache{
+		biasOut: make([]*mat64.Dense, len(layers)-1),
+		out:     make([]*mat64.Dense, len(layers)),
+		actIn:   make([]*mat64.Dense, len(layers)),
+		errMx:   make([]*mat64.Dense, len(layers)),
+		carry:   make([]*mat64.Dense, len(layers)),
+	}
+	for i := range layers {
+		// INPUT layer size is derived from the weights of the first HIDDEN layer
+		var size int
+		if i == 0 {
+			_, cols := layers[1].weights.Dims()
+			size = cols - 1
+		} else {
+			size = layers[i].size()
+			// highway layers have twice as many activation inputs as outputs
+			actSize, _ := layers[i].weights.Dims()
+			cache.actIn[i] = mat64.NewDense(samples, actSize, nil)
+			cache.errMx[i] = mat64.NewDense(samples, actSize, nil)
+			if layers[i].highway {
+				cache.carry[i] = mat64.NewDense(samples, size, nil)
+			}
+		}
+		// OUTPUT layer output does not feed any other layer so it doesn't need bias
+		if i == len(layers)-1 {
+			cache.out[i] = mat64.NewDense(samples, size, nil)
+			continue
+		}
+		biasOut := mat64.NewDense(samples, size+1, nil)
+		for j := 0; j < samples; j++ {
+			biasOut.Set(j, 0, 1.0)
+		}
+		cache.biasOut[i] = biasOut
+		cache.out[i] = biasOut.View(0, 1, samples, size).(*mat64.Dense)
+	}
+	return cache
+}
+
+// fits returns true if the cache is allocated for given number of samples of the network layers
+func (c *actCache) fits(layers []*Layer, samples int) bool {
+	if len(c.out) != len(layers) {
+		return false
+	}
+	for i, out := range c.out {
+		rows, cols := out.Dims()
+		// INPUT layer size is derived from the weights of the first HIDDEN layer
+		var size int
+		if i == 0 {
+			_, wCols := layers[1].weights.Dims()
+			size = wCols - 1
+		} else {
+			size = layers[i].size()
+		}
+		if rows != samples || cols != size {
+			return false
+		}
+	}
+	return true
+}
+
+// setInput copies the input matrix into the cache. It fails with error if the input
+// dimensions don't match the dimensions the cache has been allocated for.
+func (c *actCache) setInput(inMx mat64.Matrix) error {
+	inRows, inCols := inMx.Dims()
+	rows, cols := c.out[0].Dims()
+	if inRows != rows || inCols != cols {
+		return fmt.Errorf("Dimension mismatch. Cache: %d x %d Input: %d x %d\n",
+			rows, cols, inRows, inCols)
+	}
+	c.out[0].Copy(inMx)
+	return nil
+}
+
+// view returns activation cache whose matrices are views of the cached matrices rows from
+// the row from up to the row to. Views share the underlying data with the original cache.
+func (c *actCache) view(from, to int) *actCache {
+	rowsView := func(mxs []*mat64.Dense) []*mat64.Dense {
+		views := make([]*mat64.Dense, len(mxs))
+		for i, mx := range mxs {
+			if mx == nil {
+				continue
+			}
+			_, cols := mx.Dims()
+			views[i] = mx.View(from, 0, to-from, cols).(*mat64.Dense)
+		}
+		return views
+	}
+	return &actCache{
+		biasOut: rowsView(c.biasOut),
+		out:     rowsView(c.out),
+		actIn:   rowsView(c.actIn),
+		errMx:   rowsView(c.errMx),
+		carry:   rowsView(c.carry),
+	}
+}
+
+// workspace contains matrices which are reused across all cost and gradient evaluations
+// of a single training run so that the training does not allocate them in every iteration.
+type workspace struct {
+	// samples is the number of samples the workspace is allocated for
+	samples int
+	// concurrency is the number of requested gradient workers
+	concurrency int
+	// cache caches layer activations of all samples
+	cache *actCache
+	// workers contains activation caches of gradient workers: views of cache rows
+	workers []*actCache
+	// offsets contains the first sample row of each worker and the number of samples
+	offsets []int
+	// deltas contains deltas matrices of gradient workers: the first worker's deltas are views of the gradient
+	deltas [][]*mat64.Dense
+	// inMx is the input matrix loaded in cache
+	inMx *mat64.Dense
+	// labelsVec is the labels vector labelsMx has been built from
+	labelsVec *mat64.Vector
+	// labelsMx is one-of-N matrix of the loaded labels
+	labelsMx *mat64.Dense
+}
+
+// init allocates workspace matrices for given number of samples and gradient workers.
+// The matrices are only reallocated if the number of samples or workers has changed.
+func (ws *workspace) init(layers []*Layer, samples, workers int) {
+	if ws.cache != nil && ws.samples == samples && ws.concurrency == workers {
+		return
+	}
+	ws.samples = samples
+	ws.concurrency = workers
+	ws.cache = newActCache(layers, samples)
+	// split samples into equally sized batches
+	batch := (samples + workers - 1) / workers
+	ws.workers, ws.offsets, ws.deltas = nil, nil, nil
+	ws.inMx, ws.labelsVec, ws.labelsMx = nil, nil, nil
+	for from := 0; from < samples; from += batch {
+		to := from + batch
+		if to > samples {
+			to = samples
+		}
+		ws.workers = append(ws.workers, ws.cache.view(from, to))
+		ws.offsets = append(ws.offsets, from)
+		// every worker but the first one accumulates the errors in its own deltas
+		deltas := make([]*mat64.Dense, len(layers))
+		for i := 1; i < len(layers); i++ {
+			if len(ws.deltas) == 0 {
+				deltas[i] = new(mat64.Dense)
+				continue
+			}
+			r, c := layers[i].weights.Dims()
+			deltas[i] = mat64.NewDense(r, c, nil)
+		}
+		ws.deltas = append(ws.deltas, deltas)
+	}
+	ws.offsets = append(ws.offsets, samples)
+}
+
+// load loads the input matrix into the workspace cache and builds one-of-N labels matrix
+// from labels vector. Both are done only once per training run: input and labels are
+// only loaded again if they differ from the previously loaded ones, so their contents
+// must not change during training. load fails with error if the input dimensions don't
+// match the workspace dimensions or if the labels matrix can't be built.
+func (ws *workspace) load(inMx *mat64.Dense, labelsVec *mat64.Vector, labelCount int) error {
+	if ws.inMx != inMx {
+		if err := ws.cache.setInput(inMx); err != nil {
+			return err
+		}
+		ws.inMx = inMx
+	}
+	if ws.labelsVec != labelsVec {
+		// labelsMx is one-of-N matrix for each output label
+		// i.e. 3rd label would be: 0 0 1 0 0 etc.
+		labelsMx, err := matrix.MakeLabelsMx(labelsVec, labelCount)
+		if err != nil {
+			return err
+		}
+		ws.labelsVec = labelsVec
+		ws.labelsMx = labelsMx
+	}
+	return nil
+}
+
+// gradWorkers returns the number of goroutines calculating the gradient of given number of samples
+func gradWorkers(c *config.TrainConfig, samples int) int {
+	workers := c.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// number of workers can't be bigger than number of samples
+	if workers > samples {
+		workers = samples
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
@@ -0,0 +1,145 @@
+package neural
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActCache(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	layers := n.Layers()
+	samples := 5
+	cache := newActCache(layers, samples)
+	assert.Len(cache.biasOut, len(layers)-1)
+	// outputs of all but the OUTPUT layer are preceded by bias
+	for i := range cache.biasOut {
+		rows, cols := cache.biasOut[i].Dims()
+		assert.Equal(samples, rows)
+		for j := 0; j < rows; j++ {
+			assert.Equal(1.0, cache.biasOut[i].At(j, 0))
+		}
+		_, outCols := cache.out[i].Dims()
+		assert.Equal(cols-1, outCols)
+	}
+	// output layer matrices match the layer size
+	outRows, outCols := cache.out[len(layers)-1].Dims()
+	wRows, _ := layers[len(layers)-1].Weights().Dims()
+	assert.Equal(samples, outRows)
+	assert.Equal(wRows, outCols)
+	assert.Nil(cache.actIn[0])
+	assert.Nil(cache.errMx[0])
+	// views share the data with the cache
+	view := cache.view(2, 4)
+	rows, _ := view.out[1].Dims()
+	assert.Equal(2, rows)
+	view.out[1].Set(0, 0, 10.0)
+	assert.Equal(10.0, cache.out[1].At(2, 0))
+	assert.Equal(10.0, cache.biasOut[1].At(2, 1))
+}
+
+func TestWorkspace(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	// create new network
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	layers := n.Layers()
+	ws := new(workspace)
+	ws.init(layers, 10, 3)
+	assert.Len(ws.workers, 3)
+	assert.Equal([]int{0, 4, 8, 10}, ws.offsets)
+	// the first worker deltas are views of gradient
+	rows, cols := ws.deltas[0][1].Dims()
+	assert.Equal(0, rows*cols)
+	rows, cols = ws.deltas[1][1].Dims()
+	assert.NotEqual(0, rows*cols)
+	// workspace is not reallocated for the same dimensions
+	cache := ws.cache
+	ws.init(layers, 10, 3)
+	assert.True(cache == ws.cache)
+	// workspace is reallocated when the dimensions change
+	ws.init(layers, 5, 3)
+	assert.False(cache == ws.cache)
+	assert.Equal([]int{0, 2, 4, 5}, ws.offsets)
+	// input and labels are loaded only once
+	labelCount, _ := layers[len(layers)-1].Weights().Dims()
+	ws.init(layers, 5, 1)
+	err = ws.load(inMx, labelsVec, labelCount)
+	assert.NoError(err)
+	assert.True(mat64.Equal(inMx, ws.cache.out[0]))
+	labelsMx := ws.labelsMx
+	err = ws.load(inMx, labelsVec, labelCount)
+	assert.NoError(err)
+	assert.True(labelsMx == ws.labelsMx)
+	// different labels are loaded again
+	err = ws.load(inMx, mat64.NewVector(5, []float64{1, 1, 1, 1, 1}), labelCount)
+	assert.NoError(err)
+	assert.False(labelsMx == ws.labelsMx)
+	// incorrect input dimensions
+	err = ws.load(mat64.NewDense(2, 20, nil), labelsVec, labelCount)
+	assert.Error(err)
+	// number of workers can't exceed number of samples
+	c := &config.TrainConfig{Concurrency: 10}
+	assert.Equal(5, gradWorkers(c, 5))
+	c.Concurrency = 2
+	assert.Equal(2, gradWorkers(c, 5))
+	c.Concurrency = 0
+	assert.True(gradWorkers(c, 5) >= 1)
+}
+
+func TestBatchDeltasAllocs(t *testing.T) {
+	assert := assert.New(t)
+	// basic configuration settings
+	tmpPath := path.Join(os.TempDir(), fileName)
+	conf, err := config.New(tmpPath)
+	assert.NotNil(conf)
+	assert.NoError(err)
+	n, err := NewNetwork(conf.Network)
+	assert.NotNil(n)
+	assert.NoError(err)
+	layers := n.Layers()
+	labelCount, _ := layers[len(layers)-1].Weights().Dims()
+	// the first worker deltas are views of gradient: the second worker owns its deltas
+	ws := new(workspace)
+	ws.init(layers, 5, 2)
+	assert.NoError(ws.load(inMx, labelsVec, labelCount))
+	worker := ws.workers[1]
+	from, to := ws.offsets[1], ws.offsets[2]
+	batchLabelsMx := ws.labelsMx.View(from, 0, to-from, labelCount).(*mat64.Dense)
+	// output errors are written into the cache: a pass over the same batch does not allocate
+	for _, cost := range []string{"xentropy", "loglike"} {
+		c := &config.TrainConfig{Cost: cost}
+		allocs := testing.AllocsPerRun(10, func() {
+			n.batchDeltas(c, worker, batchLabelsMx, ws.deltas[1])
+		})
+		assert.Equal(0.0, allocs, cost)
+	}
+	// output errors match the difference of the network output and the labels
+	expMx := new(mat64.Dense)
+	outIdx := len(layers) - 1
+	expMx.Sub(worker.out[outIdx], batchLabelsMx)
+	assert.True(mat64.Equal(expMx, worker.errMx[outIdx]))
+	// exported cost delta returns the same errors in a new matrix
+	for _, cost := range []Cost{CrossEntropy{}, LogLikelihood{}} {
+		assert.True(mat64.Equal(expMx, cost.Delta(worker.out[outIdx], batchLabelsMx)))
+	}
+}
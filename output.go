@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// summary contains the results of a training run or of a cross-validation
+type summary struct {
+	// Iterations is the number of training iterations
+	Iterations int `json:"iterations,omitempty"`
+	// Cost is the cost of the training data set in the last iteration
+	Cost float64 `json:"cost,omitempty"`
+	// Accuracy is the percentage of successfully classified test samples.
+	// Cross-validation accuracy is the mean accuracy of all folds.
+	Accuracy float64 `json:"accuracy"`
+	// AccuracyStdDev is the standard deviation of cross-validation folds accuracy
+	AccuracyStdDev float64 `json:"accuracy_stddev,omitempty"`
+	// Folds contains accuracy of every cross-validation fold
+	Folds []float64 `json:"folds,omitempty"`
+	// Classification contains classification results of the first test sample
+	Classification []float64 `json:"classification,omitempty"`
+}
+
+// outputs maps output format names to functions which write training run summary
+var outputs = map[string]func(io.Writer, *summary) error{
+	"text": writeText,
+	"json": writeJSON,
+	"csv":  writeCSV,
+}
+
+// writeText writes summary to w in human readable text format
+func writeText(w io.Writer, s *summary) error {
+	// cross-validation does not classify any sample
+	if len(s.Folds) > 0 {
+		_, err := fmt.Fprintf(w, "\nCross-validation accuracy: %f ± %f\n\n", s.Accuracy, s.AccuracyStdDev)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "\nNeural net accuracy: %f\n", s.Accuracy); err != nil {
+		return err
+	}
+	classMx := mat64.NewDense(len(s.Classification), 1, s.Classification)
+	fa := mat64.Formatted(classMx, mat64.Prefix(""))
+	_, err := fmt.Fprintf(w, "\nClassification result:\n% v\n\n", fa)
+	return err
+}
+
+// writeJSON writes summary to w encoded in JSON format
+func writeJSON(w io.Writer, s *summary) error {
+	return json.NewEncoder(w).Encode(s)
+}
+
+// writeCSV writes summary to w in CSV format: one metric per record
+func writeCSV(w io.Writer, s *summary) error {
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	records := [][]string{{"metric", "value"}}
+	// cross-validation results are stored per fold
+	if len(s.Folds) > 0 {
+		records = append(records,
+			[]string{"accuracy", formatFloat(s.Accuracy)},
+			[]string{"accuracy_stddev", formatFloat(s.AccuracyStdDev)})
+		for i, a := range s.Folds {
+			records = append(records, []string{fmt.Sprintf("fold_%d", i+1), formatFloat(a)})
+		}
+	} else {
+		records = append(records,
+			[]string{"iterations", strconv.Itoa(s.Iterations)},
+			[]string{"cost", formatFloat(s.Cost)},
+			[]string{"accuracy", formatFloat(s.Accuracy)})
+	}
+	// classification results are stored per class
+	for i, p := range s.Classification {
+		records = append(records, []string{fmt.Sprintf("class_%d", i+1), formatFloat(p)})
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		return err
+	}
+	return cw.Error()
+}
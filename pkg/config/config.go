@@ -2,17 +2,19 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v1"
 )
 
 // Manifest is a data structure used to decode neural network configuration manifest
 type Manifest struct {
-	// Kind holds neural network Kind: feedfwd
+	// Kind holds neural network Kind: feedfwd, dbn, som
 	Kind string `yaml:"kind"`
-	// Task is neural network task: class, [cluster, predict]
+	// Task is neural network task: class, cluster, [predict]
 	Task string `yaml:"task"`
 	// Network provides neural network layer config and topology
 	Network struct {
@@ -27,6 +29,10 @@ type Manifest struct {
 			Size []int `yaml:"size"`
 			// Activation is neuron activation function
 			Activation string `yaml:"activation"`
+			// Highway marks highway layers: highway layer size must match its input size
+			Highway []bool `yaml:"highway,omitempty"`
+			// Init is weights initialization: xavier, he
+			Init string `yaml:"init,omitempty"`
 		} `yaml:"hidden,omitempty"`
 		// Output layer configuration
 		Output struct {
@@ -34,7 +40,16 @@ type Manifest struct {
 			Size int `yaml:"size"`
 			// Activation is neuron activation function
 			Activation string `yaml:"activation"`
+			// Init is weights initialization: xavier, he
+			Init string `yaml:"init,omitempty"`
 		} `yaml:"output"`
+		// Map is self-organizing map grid configuration: som only
+		Map struct {
+			// Rows is the number of grid rows
+			Rows int `yaml:"rows"`
+			// Cols is the number of grid columns
+			Cols int `yaml:"cols"`
+		} `yaml:"map,omitempty"`
 	} `yaml:"network"`
 	// Training holds neural network training configuration
 	Training struct {
@@ -46,13 +61,89 @@ type Manifest struct {
 		Params struct {
 			// Lambda is regualirzation parameter
 			Lambda float64 `yaml:"lambda"`
+			// Radius is the initial neighborhood radius: som only
+			Radius float64 `yaml:"radius,omitempty"`
 		} `yaml:"params"`
+		// Concurrency is the number of goroutines used to calculate gradient
+		Concurrency int `yaml:"concurrency,omitempty"`
+		// CheckFinite aborts training when cost or gradient become NaN or infinite
+		CheckFinite bool `yaml:"check_finite,omitempty"`
+		// Evolution contains evolutionary training configuration: evolution only
+		Evolution struct {
+			// Population is the number of weight vectors evolved in each generation
+			Population int `yaml:"population,omitempty"`
+			// Generations is the number of evolved generations
+			Generations int `yaml:"generations,omitempty"`
+			// Elite is the number of the best weight vectors copied to the next generation
+			Elite int `yaml:"elite,omitempty"`
+			// Tournament is the number of weight vectors competing to become a parent
+			Tournament int `yaml:"tournament,omitempty"`
+			// MutationRate is the probability of mutating a weight
+			MutationRate float64 `yaml:"mutation_rate,omitempty"`
+			// MutationScale is the standard deviation of weight mutations
+			MutationScale float64 `yaml:"mutation_scale,omitempty"`
+		} `yaml:"evolution,omitempty"`
+		// Pretrain contains RBM pretraining configuration of hidden layers
+		Pretrain struct {
+			// Epochs is a number of pretraining passes through the data set: 0 disables pretraining
+			Epochs int `yaml:"epochs,omitempty"`
+			// BatchSize is a number of samples in a mini-batch
+			BatchSize int `yaml:"batch_size,omitempty"`
+			// LearningRate is a contrastive divergence step size
+			LearningRate float64 `yaml:"learning_rate,omitempty"`
+			// CDSteps is a number of Gibbs sampling steps of contrastive divergence
+			CDSteps int `yaml:"cd_steps,omitempty"`
+		} `yaml:"pretrain,omitempty"`
+		// SWA contains stochastic weight averaging configuration
+		SWA struct {
+			// Start is the first iteration whose weights are averaged: 0 disables averaging
+			Start int `yaml:"start,omitempty"`
+			// Every is the number of iterations between two averaged weights samples
+			Every int `yaml:"every,omitempty"`
+		} `yaml:"swa,omitempty"`
 		// Optimize contains configuration for training optimization
 		Optimize struct {
 			// Method represents type of optimization
 			Method string `yaml:"method"`
 			// Iterations is a number of major optimization iterations
 			Iterations int `yaml:"iterations,omitempty"`
+			// Epochs is a number of passes through the training data set: sgd only
+			Epochs int `yaml:"epochs,omitempty"`
+			// BatchSize is a number of samples in a mini-batch: sgd only
+			BatchSize int `yaml:"batch_size,omitempty"`
+			// LearningRate is a gradient descent step size: sgd only
+			LearningRate float64 `yaml:"learning_rate,omitempty"`
+			// Momentum is a gradient descent momentum: sgd only
+			Momentum float64 `yaml:"momentum,omitempty"`
+			// Nesterov enables Nesterov momentum: sgd only
+			Nesterov bool `yaml:"nesterov,omitempty"`
+			// ClipNorm is the maximum norm of gradient steps: sgd only
+			ClipNorm float64 `yaml:"clip_norm,omitempty"`
+			// Schedule is a learning rate schedule: constant, triangular, triangular2: sgd only
+			Schedule string `yaml:"schedule,omitempty"`
+			// MaxLearningRate is the maximum learning rate of cyclical schedules: sgd only
+			MaxLearningRate float64 `yaml:"max_learning_rate,omitempty"`
+			// CycleLength is the number of gradient steps in one cycle of cyclical schedules: sgd only
+			CycleLength int `yaml:"cycle_length,omitempty"`
+			// FuncEvals is the maximum number of cost function evaluations
+			FuncEvals int `yaml:"func_evals,omitempty"`
+			// GradEvals is the maximum number of gradient evaluations
+			GradEvals int `yaml:"grad_evals,omitempty"`
+			// Runtime is the maximum optimization runtime e.g. 10m
+			Runtime string `yaml:"runtime,omitempty"`
+			// GradThreshold is the gradient infinity norm which stops the optimization
+			GradThreshold float64 `yaml:"grad_threshold,omitempty"`
+			// Converge stops the optimization if the cost does not decrease significantly
+			Converge struct {
+				// Absolute is the minimum significant absolute cost decrease
+				Absolute float64 `yaml:"absolute,omitempty"`
+				// Relative is the minimum significant relative cost decrease
+				Relative float64 `yaml:"relative,omitempty"`
+				// Iterations is the number of iterations without significant decrease
+				Iterations int `yaml:"iterations,omitempty"`
+			} `yaml:"converge,omitempty"`
+			// LineSearch is a line search method: bisection, backtracking, morethuente
+			LineSearch string `yaml:"line_search,omitempty"`
 		} `yaml:"optimize,omitempty"`
 	} `yaml:"training"`
 }
@@ -60,11 +151,47 @@ type Manifest struct {
 // network maps supported training and optimization parameters to a particular neural network
 var network = map[string]map[string][]string{
 	"feedfwd": {
+		"training": {"backprop", "evolution"},
+		"optim":    {"bfgs", "newton", "sgd"},
+	},
+	"dbn": {
 		"training": {"backprop"},
-		"optim":    {"bfgs"},
+		"optim":    {"bfgs", "newton", "sgd"},
+	},
+	"som": {
+		"training": {"competitive"},
 	},
 }
 
+// schedules contains supported learning rate schedules
+var schedules = []string{"constant", "triangular", "triangular2"}
+
+// lineSearches contains supported line search methods
+var lineSearches = []string{"bisection", "backtracking", "morethuente"}
+
+// weightInits contains supported weight initializations
+var weightInits = []string{"xavier", "he"}
+
+// AddOptimMethod adds optimization method name to the list of methods supported by
+// neural network kind so that manifests can request it. Adding already supported method
+// does nothing. It fails with error if the network kind is not supported or if the name is empty.
+// Optimization methods are normally added when registering their implementations with neural package.
+func AddOptimMethod(kind, name string) error {
+	if _, ok := network[kind]; !ok {
+		return fmt.Errorf("Unsupported network kind: %s\n", kind)
+	}
+	if name == "" {
+		return fmt.Errorf("Optimize method can not be empty!\n")
+	}
+	for _, optimizeMethod := range network[kind]["optim"] {
+		if optimizeMethod == name {
+			return nil
+		}
+	}
+	network[kind]["optim"] = append(network[kind]["optim"], name)
+	return nil
+}
+
 // NeuronConfig allows to specify neuron configuration
 type NeuronConfig struct {
 	// Activation is a neuron activation function
@@ -73,12 +200,21 @@ type NeuronConfig struct {
 
 // LayerConfig allows to specify neural network layer configuration
 type LayerConfig struct {
+	// ID is optional layer identifier which must be unique within network.
+	// If it is empty, network derives layer ID from the layer position.
+	ID string
 	// Kind is neural network layer kind: input, output, hidden
 	Kind string
 	// Size represents a number of neurons in the network layer
 	Size int
 	// NeurFn holds neuron configuration
 	NeurFn *NeuronConfig
+	// Highway makes HIDDEN layer a highway layer which carries its input to its output
+	// through a learned gate. Highway layer size must match the size of its input.
+	Highway bool
+	// Init is the distribution of initial layer weights: xavier or he.
+	// If it is empty, xavier is used
+	Init string
 }
 
 // NetArch specifies neural network architecture
@@ -93,6 +229,9 @@ type NetArch struct {
 
 // NetConfig allows to specify Neural Network parameters
 type NetConfig struct {
+	// ID is optional network identifier.
+	// If it is empty, network ID is derived from the network architecture.
+	ID string
 	// Kind is Neural Network type
 	Kind string
 	// Arch specifies network architecture
@@ -102,22 +241,142 @@ type NetConfig struct {
 // OptimConfig allows to specify advanced optimization configuration
 type OptimConfig struct {
 	// Method is an advanced optimization method
-	// Currently only bfgs algorithm is supported
+	// bfgs, newton and sgd (mini-batch gradient descent) are supported by default,
+	// other methods can be registered
 	Method string
 	// Iterations specifies the number of optimization iterations
 	Iterations int
+	// Epochs specifies the number of passes through the training data set.
+	// It is used by epoch based methods such as sgd instead of Iterations
+	Epochs int
+	// BatchSize is the number of samples used to calculate each gradient step.
+	// If it is 0, all training samples are used in each step
+	BatchSize int
+	// LearningRate is the gradient descent step size
+	LearningRate float64
+	// Momentum is the fraction of the previous step added to the current gradient step
+	Momentum float64
+	// Nesterov requests Nesterov momentum: the gradient is calculated at the lookahead
+	// weights, i.e. the current weights moved by the momentum fraction of the previous step
+	Nesterov bool
+	// ClipNorm is the maximum Euclidean norm of the gradient: larger gradients are rescaled
+	// to it before each gradient step. If it is 0, the gradient is not clipped
+	ClipNorm float64
+	// Schedule is a learning rate schedule: constant, triangular or triangular2.
+	// Cyclical triangular schedules vary the learning rate linearly between LearningRate
+	// and MaxLearningRate and back within every cycle; triangular2 halves the amplitude
+	// after each cycle. If it is empty, learning rate is constant
+	Schedule string
+	// MaxLearningRate is the maximum learning rate of cyclical schedules
+	MaxLearningRate float64
+	// CycleLength is the number of gradient steps in one cycle of cyclical schedules
+	CycleLength int
+	// FuncEvaluations is the maximum number of cost function evaluations.
+	// If it is 0, the number of evaluations is not limited
+	FuncEvaluations int
+	// GradEvaluations is the maximum number of gradient evaluations.
+	// If it is 0, the number of evaluations is not limited
+	GradEvaluations int
+	// Runtime is the maximum optimization runtime.
+	// If it is 0, the runtime is not limited
+	Runtime time.Duration
+	// GradientThreshold stops the optimization when the infinity norm of the gradient drops
+	// below it. If it is 0, the default threshold of the optimize package is used
+	GradientThreshold float64
+	// ConvergeAbsolute and ConvergeRelative specify the minimum significant decrease of the cost:
+	// the optimization stops if the cost does not decrease by more than
+	// ConvergeRelative * |cost| + ConvergeAbsolute in ConvergeIterations iterations.
+	// If ConvergeIterations is 0, cost convergence is not tested
+	ConvergeAbsolute   float64
+	ConvergeRelative   float64
+	ConvergeIterations int
+	// LineSearch is a line search method used by line search based optimization methods:
+	// bisection, backtracking or morethuente. If it is empty, the method default is used
+	LineSearch string
+}
+
+// EvolutionConfig allows to specify evolutionary training configuration.
+// Each generation contains Population network weight vectors. The next generation is created
+// by copying Elite weight vectors with the lowest cost and by crossing over parents selected
+// in tournaments of Tournament randomly chosen weight vectors. Every weight of the offspring
+// is mutated with MutationRate probability by normally distributed noise with MutationScale
+// standard deviation.
+type EvolutionConfig struct {
+	// Population is the number of weight vectors in each generation
+	Population int
+	// Generations is the number of evolved generations
+	Generations int
+	// Elite is the number of the best weight vectors copied to the next generation unchanged
+	Elite int
+	// Tournament is the number of weight vectors competing to become a parent
+	Tournament int
+	// MutationRate is the probability of mutating a weight
+	MutationRate float64
+	// MutationScale is the standard deviation of weight mutations
+	MutationScale float64
+}
+
+// PretrainConfig allows to specify unsupervised pretraining of hidden layers.
+// Hidden layers are pretrained one by one as restricted Boltzmann machines by contrastive
+// divergence: each machine is trained on the hidden unit probabilities of the previous one.
+type PretrainConfig struct {
+	// Epochs is the number of passes through the training data set per layer
+	Epochs int
+	// BatchSize is the number of samples used to calculate each update.
+	// If it is 0, all training samples are used in each update
+	BatchSize int
+	// LearningRate is the contrastive divergence step size
+	LearningRate float64
+	// CDSteps is the number of Gibbs sampling steps of contrastive divergence
+	CDSteps int
 }
 
 // TrainConfig allows to specify neural network training configuration
 type TrainConfig struct {
-	// Kind is a neural network training type: backprop
+	// Kind is a neural network training type: backprop or evolution
 	Kind string
 	// Cost is a neural network cost function
 	Cost string
 	// Lambda is regularizer parameter
 	Lambda float64
-	// Optimize holds training optimization parameters
+	// Concurrency is the number of goroutines used to calculate gradient.
+	// If it is 0, the number of goroutines is equal to GOMAXPROCS
+	Concurrency int
+	// CheckFinite aborts training with error when cost or gradient contain NaN or Inf values
+	CheckFinite bool
+	// SWAStart is the first training iteration whose weights are averaged by stochastic weight
+	// averaging. Averaged weights are set to the network when the training finishes.
+	// If it is 0, weights are not averaged
+	SWAStart int
+	// SWAEvery is the number of training iterations between two averaged weights samples.
+	// If it is 0, weights of every iteration since SWAStart are averaged
+	SWAEvery int
+	// Optimize holds training optimization parameters: backprop only
 	Optimize *OptimConfig
+	// Evolution holds evolutionary training parameters: evolution only
+	Evolution *EvolutionConfig
+	// Pretrain holds hidden layers pretraining parameters.
+	// If it is nil, hidden layers are not pretrained
+	Pretrain *PretrainConfig
+}
+
+// SOMConfig allows to specify self-organizing map configuration.
+// Self-organizing map is trained by competitive learning: the weights of the unit closest
+// to a sample and of its grid neighbors move towards the sample. Both the learning rate
+// and the neighborhood radius decay linearly during the training.
+type SOMConfig struct {
+	// Inputs is the number of features of clustered samples
+	Inputs int
+	// Rows is the number of map grid rows
+	Rows int
+	// Cols is the number of map grid columns
+	Cols int
+	// Epochs is the number of passes through the training data set
+	Epochs int
+	// LearningRate is the initial learning rate
+	LearningRate float64
+	// Radius is the initial neighborhood radius measured in grid units
+	Radius float64
 }
 
 // Config allows to specify neural network architecture and training configuration
@@ -126,12 +385,26 @@ type Config struct {
 	Network *NetConfig
 	// Training holds neural network training configuration
 	Training *TrainConfig
+	// SOM holds self-organizing map configuration: som networks only.
+	// Network and Training are nil for som networks.
+	SOM *SOMConfig
 }
 
 // New returns neural network config struct based on the supplied manifest file.
 // It accepts path to a config manifest file as a parameter. It returns error if the supplied
 // manifest file can't be open or if it can not be parsed into a valid configration object.
 func New(manPath string) (*Config, error) {
+	m, err := LoadManifest(manPath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(m)
+}
+
+// LoadManifest decodes the manifest stored in the file supplied as a parameter.
+// It returns error if the manifest file can't be open or if it can't be decoded.
+// Loaded manifest is not validated: use ParseManifest to turn it into valid Config.
+func LoadManifest(manPath string) (*Manifest, error) {
 	var m Manifest
 	// Open manifest file
 	f, err := os.Open(manPath)
@@ -148,7 +421,17 @@ func New(manPath string) (*Config, error) {
 	if err := yaml.Unmarshal(manData, &m); err != nil {
 		return nil, err
 	}
-	return ParseManifest(&m)
+	return &m, nil
+}
+
+// WriteManifest writes the manifest supplied as a parameter to w encoded in YAML format
+func WriteManifest(w io.Writer, m *Manifest) error {
+	manData, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(manData)
+	return err
 }
 
 // ParseManifest parses the manifest supplied as a parameter into Config or fails with error
@@ -161,6 +444,25 @@ func ParseManifest(m *Manifest) (*Config, error) {
 	if _, ok := network[m.Kind]; !ok {
 		return nil, fmt.Errorf("Unsupported network kind: %s\n", m.Kind)
 	}
+	// self-organizing maps are configured differently from the other networks
+	if m.Kind == "som" {
+		somConfig, err := parseSOMConfig(m)
+		if err != nil {
+			return nil, err
+		}
+		return &Config{SOM: somConfig}, nil
+	}
+	// deep belief network hidden layers are pretrained
+	if m.Kind == "dbn" {
+		if len(m.Network.Hidden.Size) == 0 {
+			return nil, fmt.Errorf("Network kind %s requires hidden layers\n", m.Kind)
+		}
+		// pretrained machines use sigmoid units
+		if m.Network.Hidden.Activation != "sigmoid" {
+			return nil, fmt.Errorf("Network kind %s requires sigmoid hidden layers: %s\n",
+				m.Kind, m.Network.Hidden.Activation)
+		}
+	}
 	// parse neural network layer configuration parameters
 	netConfig, err := parseNetConfig(m)
 	if err != nil {
@@ -179,16 +481,89 @@ func ParseManifest(m *Manifest) (*Config, error) {
 	}, nil
 }
 
+func parseSOMConfig(m *Manifest) (*SOMConfig, error) {
+	// self-organizing maps can only cluster
+	if m.Task != "cluster" {
+		return nil, fmt.Errorf("Unsupported som task: %s\n", m.Task)
+	}
+	if m.Training.Kind != "competitive" {
+		return nil, fmt.Errorf("Unsupported training requested: %s\n", m.Training.Kind)
+	}
+	if m.Network.Input.Size <= 0 {
+		return nil, fmt.Errorf("Incorrect input layer size: %d\n", m.Network.Input.Size)
+	}
+	if m.Network.Map.Rows <= 0 || m.Network.Map.Cols <= 0 {
+		return nil, fmt.Errorf("Incorrect map size: %d x %d\n", m.Network.Map.Rows, m.Network.Map.Cols)
+	}
+	// check number of epochs
+	epochs := m.Training.Optimize.Epochs
+	if epochs < 0 {
+		return nil, fmt.Errorf("Incorrect number of epochs: %d\n", epochs)
+	}
+	if epochs == 0 {
+		epochs = 10
+	}
+	// check learning rate
+	rate := m.Training.Optimize.LearningRate
+	if rate < 0 {
+		return nil, fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	if rate == 0 {
+		rate = 0.5
+	}
+	// check neighborhood radius: by default it covers half of the map
+	radius := m.Training.Params.Radius
+	if radius < 0 {
+		return nil, fmt.Errorf("Incorrect neighborhood radius: %f\n", radius)
+	}
+	if radius == 0 {
+		radius = float64(m.Network.Map.Rows)
+		if m.Network.Map.Cols > m.Network.Map.Rows {
+			radius = float64(m.Network.Map.Cols)
+		}
+		radius = radius / 2
+	}
+
+	return &SOMConfig{
+		Inputs:       m.Network.Input.Size,
+		Rows:         m.Network.Map.Rows,
+		Cols:         m.Network.Map.Cols,
+		Epochs:       epochs,
+		LearningRate: rate,
+		Radius:       radius,
+	}, nil
+}
+
 func parseNetConfig(m *Manifest) (*NetConfig, error) {
 	// INPUT layer configuration
 	if m.Network.Input.Size <= 0 {
 		return nil, fmt.Errorf("Incorrect input layer size: %d\n", m.Network.Input.Size)
 	}
 	inputLayer := &LayerConfig{Kind: "input", Size: m.Network.Input.Size}
+	// check weight initializations
+	for _, init := range []string{m.Network.Hidden.Init, m.Network.Output.Init} {
+		if init == "" {
+			continue
+		}
+		var validInit bool
+		for _, wi := range weightInits {
+			if wi == init {
+				validInit = true
+				break
+			}
+		}
+		if !validInit {
+			return nil, fmt.Errorf("Unsupported weight initialization: %s\n", init)
+		}
+	}
 	// HIDDEN network layer configuration
 	var hiddenLayers []*LayerConfig
+	if len(m.Network.Hidden.Highway) > len(m.Network.Hidden.Size) {
+		return nil, fmt.Errorf("Too many highway flags: %d\n", len(m.Network.Hidden.Highway))
+	}
 	if len(m.Network.Hidden.Size) != 0 {
 		hiddenLayers = make([]*LayerConfig, len(m.Network.Hidden.Size))
+		layerIn := m.Network.Input.Size
 		for i, size := range m.Network.Hidden.Size {
 			if size <= 0 {
 				return nil, fmt.Errorf("Incorrect hidden layer size: %d\n", size)
@@ -199,7 +574,16 @@ func parseNetConfig(m *Manifest) (*NetConfig, error) {
 				NeurFn: &NeuronConfig{
 					Activation: m.Network.Hidden.Activation,
 				},
+				Init: m.Network.Hidden.Init,
 			}
+			// highway layers carry their input so their size must match the input size
+			if i < len(m.Network.Hidden.Highway) && m.Network.Hidden.Highway[i] {
+				if size != layerIn {
+					return nil, fmt.Errorf("Incorrect highway layer size: %d, input: %d\n", size, layerIn)
+				}
+				hiddenLayers[i].Highway = true
+			}
+			layerIn = size
 		}
 	}
 	// OUTPUT layer configuration
@@ -212,6 +596,7 @@ func parseNetConfig(m *Manifest) (*NetConfig, error) {
 		NeurFn: &NeuronConfig{
 			Activation: m.Network.Output.Activation,
 		},
+		Init: m.Network.Output.Init,
 	}
 
 	return &NetConfig{
@@ -248,10 +633,117 @@ func parseOptimConfig(m *Manifest) (*OptimConfig, error) {
 	} else {
 		iters = m.Training.Optimize.Iterations
 	}
+	// check number of epochs
+	epochs := m.Training.Optimize.Epochs
+	if epochs <= 0 {
+		epochs = 10
+	}
+	// check mini-batch parameters
+	if m.Training.Optimize.BatchSize < 0 {
+		return nil, fmt.Errorf("Incorrect batch size: %d\n", m.Training.Optimize.BatchSize)
+	}
+	rate := m.Training.Optimize.LearningRate
+	if rate < 0 {
+		return nil, fmt.Errorf("Incorrect learning rate: %f\n", rate)
+	}
+	if rate == 0 {
+		rate = 0.1
+	}
+	if m.Training.Optimize.Momentum < 0 || m.Training.Optimize.Momentum >= 1 {
+		return nil, fmt.Errorf("Incorrect momentum: %f\n", m.Training.Optimize.Momentum)
+	}
+	if m.Training.Optimize.ClipNorm < 0 {
+		return nil, fmt.Errorf("Incorrect gradient clipping norm: %f\n", m.Training.Optimize.ClipNorm)
+	}
+	// check learning rate schedule
+	schedule := m.Training.Optimize.Schedule
+	if schedule == "" {
+		schedule = "constant"
+	}
+	var validSchedule bool
+	for _, s := range schedules {
+		if s == schedule {
+			validSchedule = true
+			break
+		}
+	}
+	if !validSchedule {
+		return nil, fmt.Errorf("Unsupported learning rate schedule: %s\n", schedule)
+	}
+	if schedule != "constant" {
+		if m.Training.Optimize.MaxLearningRate < rate {
+			return nil, fmt.Errorf("Incorrect maximum learning rate: %f\n",
+				m.Training.Optimize.MaxLearningRate)
+		}
+		if m.Training.Optimize.CycleLength < 2 {
+			return nil, fmt.Errorf("Incorrect cycle length: %d\n", m.Training.Optimize.CycleLength)
+		}
+	}
+
+	// check evaluation limits
+	if m.Training.Optimize.FuncEvals < 0 {
+		return nil, fmt.Errorf("Incorrect function evaluations limit: %d\n",
+			m.Training.Optimize.FuncEvals)
+	}
+	if m.Training.Optimize.GradEvals < 0 {
+		return nil, fmt.Errorf("Incorrect gradient evaluations limit: %d\n",
+			m.Training.Optimize.GradEvals)
+	}
+	// check convergence parameters
+	if m.Training.Optimize.GradThreshold < 0 {
+		return nil, fmt.Errorf("Incorrect gradient threshold: %f\n", m.Training.Optimize.GradThreshold)
+	}
+	conv := m.Training.Optimize.Converge
+	if conv.Absolute < 0 || conv.Relative < 0 || conv.Iterations < 0 {
+		return nil, fmt.Errorf("Incorrect convergence. Absolute: %f, Relative: %f, Iterations: %d\n",
+			conv.Absolute, conv.Relative, conv.Iterations)
+	}
+	// check line search method
+	if m.Training.Optimize.LineSearch != "" {
+		var validLineSearch bool
+		for _, ls := range lineSearches {
+			if ls == m.Training.Optimize.LineSearch {
+				validLineSearch = true
+				break
+			}
+		}
+		if !validLineSearch {
+			return nil, fmt.Errorf("Unsupported line search: %s\n", m.Training.Optimize.LineSearch)
+		}
+	}
+	// check runtime limit
+	var runtime time.Duration
+	if m.Training.Optimize.Runtime != "" {
+		var err error
+		runtime, err = time.ParseDuration(m.Training.Optimize.Runtime)
+		if err != nil {
+			return nil, fmt.Errorf("Incorrect runtime limit: %s\n", m.Training.Optimize.Runtime)
+		}
+		if runtime < 0 {
+			return nil, fmt.Errorf("Incorrect runtime limit: %s\n", m.Training.Optimize.Runtime)
+		}
+	}
 
 	return &OptimConfig{
-		Method:     m.Training.Optimize.Method,
-		Iterations: iters,
+		Method:             m.Training.Optimize.Method,
+		Iterations:         iters,
+		Epochs:             epochs,
+		BatchSize:          m.Training.Optimize.BatchSize,
+		LearningRate:       rate,
+		Momentum:           m.Training.Optimize.Momentum,
+		Nesterov:           m.Training.Optimize.Nesterov,
+		ClipNorm:           m.Training.Optimize.ClipNorm,
+		Schedule:           schedule,
+		MaxLearningRate:    m.Training.Optimize.MaxLearningRate,
+		CycleLength:        m.Training.Optimize.CycleLength,
+		FuncEvaluations:    m.Training.Optimize.FuncEvals,
+		GradEvaluations:    m.Training.Optimize.GradEvals,
+		Runtime:            runtime,
+		GradientThreshold:  m.Training.Optimize.GradThreshold,
+		ConvergeAbsolute:   conv.Absolute,
+		ConvergeRelative:   conv.Relative,
+		ConvergeIterations: conv.Iterations,
+		LineSearch:         m.Training.Optimize.LineSearch,
 	}, nil
 }
 
@@ -282,17 +774,126 @@ func parseTrainConfig(m *Manifest) (*TrainConfig, error) {
 		return nil, fmt.Errorf("Incorrect reg parameter: %f\n", m.Training.Params.Lambda)
 	}
 
-	// parse optimization config
-	optimize, err := parseOptimConfig(m)
+	// check concurrency parameter
+	if m.Training.Concurrency < 0 {
+		return nil, fmt.Errorf("Incorrect concurrency: %d\n", m.Training.Concurrency)
+	}
+
+	// check weight averaging parameters
+	if m.Training.SWA.Start < 0 {
+		return nil, fmt.Errorf("Incorrect SWA start: %d\n", m.Training.SWA.Start)
+	}
+	swaEvery := m.Training.SWA.Every
+	if swaEvery < 0 {
+		return nil, fmt.Errorf("Incorrect SWA every: %d\n", swaEvery)
+	}
+	if swaEvery == 0 {
+		swaEvery = 1
+	}
+
+	c := &TrainConfig{
+		Kind:        m.Training.Kind,
+		Cost:        m.Training.Cost,
+		Lambda:      m.Training.Params.Lambda,
+		Concurrency: m.Training.Concurrency,
+		CheckFinite: m.Training.CheckFinite,
+		SWAStart:    m.Training.SWA.Start,
+		SWAEvery:    swaEvery,
+	}
+	// check pretraining parameters
+	p := m.Training.Pretrain
+	if p.Epochs < 0 {
+		return nil, fmt.Errorf("Incorrect number of pretraining epochs: %d\n", p.Epochs)
+	}
+	// deep belief networks are always pretrained
+	if m.Kind == "dbn" && p.Epochs == 0 {
+		p.Epochs = 10
+	}
+	if p.Epochs > 0 {
+		// pretrained machines use sigmoid units
+		if m.Network.Hidden.Activation != "sigmoid" {
+			return nil, fmt.Errorf("Pretraining requires sigmoid hidden layers: %s\n",
+				m.Network.Hidden.Activation)
+		}
+		if p.BatchSize < 0 {
+			return nil, fmt.Errorf("Incorrect pretraining batch size: %d\n", p.BatchSize)
+		}
+		if p.LearningRate < 0 {
+			return nil, fmt.Errorf("Incorrect pretraining learning rate: %f\n", p.LearningRate)
+		}
+		if p.CDSteps < 0 {
+			return nil, fmt.Errorf("Incorrect number of CD steps: %d\n", p.CDSteps)
+		}
+		c.Pretrain = &PretrainConfig{
+			Epochs:       p.Epochs,
+			BatchSize:    p.BatchSize,
+			LearningRate: p.LearningRate,
+			CDSteps:      p.CDSteps,
+		}
+		if c.Pretrain.LearningRate == 0 {
+			c.Pretrain.LearningRate = 0.1
+		}
+		if c.Pretrain.CDSteps == 0 {
+			c.Pretrain.CDSteps = 1
+		}
+	}
+
+	var err error
+	// evolutionary training does not use optimization
+	if m.Training.Kind == "evolution" {
+		c.Evolution, err = parseEvolutionConfig(m)
+	} else {
+		c.Optimize, err = parseOptimConfig(m)
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	// return train config
-	return &TrainConfig{
-		Kind:     m.Training.Kind,
-		Cost:     m.Training.Cost,
-		Lambda:   m.Training.Params.Lambda,
-		Optimize: optimize,
-	}, nil
+	return c, nil
+}
+
+func parseEvolutionConfig(m *Manifest) (*EvolutionConfig, error) {
+	e := m.Training.Evolution
+	if e.Population < 0 || e.Generations < 0 || e.Elite < 0 || e.Tournament < 0 {
+		return nil, fmt.Errorf("Incorrect evolution. Population: %d, Generations: %d, Elite: %d, Tournament: %d\n",
+			e.Population, e.Generations, e.Elite, e.Tournament)
+	}
+	if e.MutationRate < 0 || e.MutationRate > 1 {
+		return nil, fmt.Errorf("Incorrect mutation rate: %f\n", e.MutationRate)
+	}
+	if e.MutationScale < 0 {
+		return nil, fmt.Errorf("Incorrect mutation scale: %f\n", e.MutationScale)
+	}
+	c := &EvolutionConfig{
+		Population:    e.Population,
+		Generations:   e.Generations,
+		Elite:         e.Elite,
+		Tournament:    e.Tournament,
+		MutationRate:  e.MutationRate,
+		MutationScale: e.MutationScale,
+	}
+	// set default values
+	if c.Population == 0 {
+		c.Population = 50
+	}
+	if c.Generations == 0 {
+		c.Generations = 100
+	}
+	if c.Elite == 0 {
+		c.Elite = 1
+	}
+	if c.Tournament == 0 {
+		c.Tournament = 3
+	}
+	if c.MutationRate == 0 {
+		c.MutationRate = 0.1
+	}
+	if c.MutationScale == 0 {
+		c.MutationScale = 0.1
+	}
+	if c.Elite >= c.Population {
+		return nil, fmt.Errorf("Incorrect elite size: %d\n", c.Elite)
+	}
+	return c, nil
 }
@@ -1,12 +1,14 @@
 package config
 
 import (
+	"bytes"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
 	"testing"
+	"time"
 
 	yaml "gopkg.in/yaml.v1"
 
@@ -95,6 +97,31 @@ func TestNewConfig(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestLoadWriteManifest(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpPath := path.Join(os.TempDir(), fileName)
+	m, err := LoadManifest(tmpPath)
+	assert.NotNil(m)
+	assert.NoError(err)
+	assert.Equal("feedfwd", m.Kind)
+	assert.Equal([]int{25}, m.Network.Hidden.Size)
+	assert.Equal(69, m.Training.Optimize.Iterations)
+	// written manifest can be loaded back
+	m.Training.Params.Lambda = 0.5
+	buf := new(bytes.Buffer)
+	err = WriteManifest(buf, m)
+	assert.NoError(err)
+	var written Manifest
+	err = yaml.Unmarshal(buf.Bytes(), &written)
+	assert.NoError(err)
+	assert.Equal(*m, written)
+	// nonexistent file
+	m, err = LoadManifest(filepath.Join(os.TempDir(), "random"))
+	assert.Nil(m)
+	assert.Error(err)
+}
+
 func TestParseManifest(t *testing.T) {
 	assert := assert.New(t)
 
@@ -162,6 +189,58 @@ func TestParseNetConfig(t *testing.T) {
 	assert.Nil(c)
 	assert.Error(err)
 	m.Network.Output.Size = origOutSize
+	// weight initialization
+	m.Network.Hidden.Init = "foo"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Network.Hidden.Init = "he"
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal("he", c.Network.Arch.Hidden[0].Init)
+	assert.Equal("", c.Network.Arch.Output.Init)
+	m.Network.Hidden.Init = ""
+}
+
+func TestParseHighway(t *testing.T) {
+	assert := assert.New(t)
+	var m Manifest
+	manifest := []byte(`kind: feedfwd
+task: class
+network:
+  input:
+    size: 4
+  hidden:
+    size: [3, 3, 3]
+    activation: relu
+    highway: [false, true, true]
+  output:
+    size: 2
+    activation: softmax
+training:
+  kind: backprop
+  cost: xentropy
+  optimize:
+    method: bfgs`)
+	assert.NoError(yaml.Unmarshal(manifest, &m))
+	c, err := ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	hidden := c.Network.Arch.Hidden
+	assert.False(hidden[0].Highway)
+	assert.True(hidden[1].Highway)
+	assert.True(hidden[2].Highway)
+	// highway layer size must match its input size
+	m.Network.Hidden.Highway = []bool{true}
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	// too many highway flags
+	m.Network.Hidden.Highway = []bool{false, true, true, true}
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
 }
 
 func TestParseOptimize(t *testing.T) {
@@ -191,6 +270,134 @@ func TestParseOptimize(t *testing.T) {
 	c, err = ParseManifest(&m)
 	assert.Nil(c)
 	assert.Error(err)
+	// added optimization method
+	assert.Error(AddOptimMethod("foo", "foobar"))
+	assert.Error(AddOptimMethod("feedfwd", ""))
+	assert.NoError(AddOptimMethod("feedfwd", "foobar"))
+	assert.NoError(AddOptimMethod("feedfwd", "foobar"))
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	m.Training.Optimize.Method = origOptimMethod
+	// incorrect evaluation limits
+	m.Training.Optimize.FuncEvals = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.FuncEvals = 100
+	m.Training.Optimize.GradEvals = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.GradEvals = 50
+	// incorrect runtime limit
+	m.Training.Optimize.Runtime = "foo"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Runtime = "-1m"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Runtime = "1m30s"
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(100, c.Training.Optimize.FuncEvaluations)
+	assert.Equal(50, c.Training.Optimize.GradEvaluations)
+	assert.Equal(90*time.Second, c.Training.Optimize.Runtime)
+	// incorrect convergence settings
+	m.Training.Optimize.GradThreshold = -1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.GradThreshold = 1e-5
+	m.Training.Optimize.Converge.Iterations = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Converge.Iterations = 10
+	m.Training.Optimize.Converge.Absolute = 1e-8
+	m.Training.Optimize.LineSearch = "foo"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.LineSearch = "morethuente"
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(1e-5, c.Training.Optimize.GradientThreshold)
+	assert.Equal(1e-8, c.Training.Optimize.ConvergeAbsolute)
+	assert.Equal(10, c.Training.Optimize.ConvergeIterations)
+	assert.Equal("morethuente", c.Training.Optimize.LineSearch)
+	// mini-batch gradient descent defaults
+	m.Training.Optimize.Method = "sgd"
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(10, c.Training.Optimize.Epochs)
+	assert.Equal(0, c.Training.Optimize.BatchSize)
+	assert.Equal(0.1, c.Training.Optimize.LearningRate)
+	// incorrect mini-batch parameters
+	m.Training.Optimize.BatchSize = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.BatchSize = 64
+	m.Training.Optimize.LearningRate = -0.1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.LearningRate = 0.05
+	m.Training.Optimize.Momentum = 1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Momentum = 0.9
+	m.Training.Optimize.ClipNorm = -1.0
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.ClipNorm = 5.0
+	m.Training.Optimize.Epochs = 30
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(30, c.Training.Optimize.Epochs)
+	assert.Equal(64, c.Training.Optimize.BatchSize)
+	assert.Equal(0.05, c.Training.Optimize.LearningRate)
+	assert.Equal(0.9, c.Training.Optimize.Momentum)
+	assert.Equal(5.0, c.Training.Optimize.ClipNorm)
+	assert.False(c.Training.Optimize.Nesterov)
+	m.Training.Optimize.Nesterov = true
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.True(c.Training.Optimize.Nesterov)
+	assert.Equal("constant", c.Training.Optimize.Schedule)
+	// incorrect learning rate schedule
+	m.Training.Optimize.Schedule = "foo"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.Schedule = "triangular"
+	m.Training.Optimize.MaxLearningRate = 0.01
+	m.Training.Optimize.CycleLength = 10
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.MaxLearningRate = 0.5
+	m.Training.Optimize.CycleLength = 1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Optimize.CycleLength = 10
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal("triangular", c.Training.Optimize.Schedule)
+	assert.Equal(0.5, c.Training.Optimize.MaxLearningRate)
+	assert.Equal(10, c.Training.Optimize.CycleLength)
 	m.Training.Optimize.Method = origOptimMethod
 }
 
@@ -243,8 +450,219 @@ func TestParseTraining(t *testing.T) {
 	assert.Nil(c)
 	assert.Error(err)
 	m.Training.Params.Lambda = origLambda
+	// incorrect concurrency
+	m.Training.Concurrency = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Concurrency = 4
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(c.Training.Concurrency, 4)
+	m.Training.Concurrency = 0
+	// non-finite values checks
+	m.Training.CheckFinite = true
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.True(c.Training.CheckFinite)
+	m.Training.CheckFinite = false
+	// weight averaging parameters
+	m.Training.SWA.Start = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.SWA.Start = 10
+	m.Training.SWA.Every = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.SWA.Every = 0
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(10, c.Training.SWAStart)
+	assert.Equal(1, c.Training.SWAEvery)
+	m.Training.SWA.Start = 0
+	// pretraining parameters
+	m.Training.Pretrain.Epochs = 5
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(&PretrainConfig{Epochs: 5, LearningRate: 0.1, CDSteps: 1}, c.Training.Pretrain)
+	// pretrained hidden layers must use sigmoid activation function
+	m.Network.Hidden.Activation = "relu"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Network.Hidden.Activation = "sigmoid"
+	m.Training.Pretrain.Epochs = 0
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Nil(c.Training.Pretrain)
+	// evolutionary training defaults
+	origKind := m.Training.Kind
+	m.Training.Kind = "evolution"
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Nil(c.Training.Optimize)
+	assert.Equal(&EvolutionConfig{
+		Population:    50,
+		Generations:   100,
+		Elite:         1,
+		Tournament:    3,
+		MutationRate:  0.1,
+		MutationScale: 0.1,
+	}, c.Training.Evolution)
+	// incorrect evolution parameters
+	m.Training.Evolution.Population = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Evolution.Population = 10
+	m.Training.Evolution.Elite = 10
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Evolution.Elite = 2
+	m.Training.Evolution.MutationRate = 1.5
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Evolution.MutationRate = 0.0
+	m.Training.Evolution.MutationScale = -0.1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Evolution.MutationScale = 0.0
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(10, c.Training.Evolution.Population)
+	assert.Equal(2, c.Training.Evolution.Elite)
+	m.Training.Kind = origKind
 	// correct parameters
 	c, err = ParseManifest(&m)
 	assert.NotNil(c)
 	assert.NoError(err)
 }
+
+func TestParseSOM(t *testing.T) {
+	assert := assert.New(t)
+	var m Manifest
+	manifest := []byte(`kind: som
+task: cluster
+network:
+  input:
+    size: 4
+  map:
+    rows: 3
+    cols: 5
+training:
+  kind: competitive`)
+	assert.NoError(yaml.Unmarshal(manifest, &m))
+	// default training parameters
+	c, err := ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Nil(c.Network)
+	assert.Nil(c.Training)
+	assert.Equal(&SOMConfig{
+		Inputs:       4,
+		Rows:         3,
+		Cols:         5,
+		Epochs:       10,
+		LearningRate: 0.5,
+		Radius:       2.5,
+	}, c.SOM)
+	// incorrect parameters
+	for _, set := range []func(*Manifest){
+		func(m *Manifest) { m.Task = "class" },
+		func(m *Manifest) { m.Training.Kind = "backprop" },
+		func(m *Manifest) { m.Network.Input.Size = 0 },
+		func(m *Manifest) { m.Network.Map.Rows = 0 },
+		func(m *Manifest) { m.Training.Optimize.Epochs = -1 },
+		func(m *Manifest) { m.Training.Optimize.LearningRate = -0.5 },
+		func(m *Manifest) { m.Training.Params.Radius = -1.0 },
+	} {
+		bad := m
+		set(&bad)
+		c, err = ParseManifest(&bad)
+		assert.Nil(c)
+		assert.Error(err)
+	}
+	// explicit training parameters
+	m.Training.Optimize.Epochs = 20
+	m.Training.Optimize.LearningRate = 0.2
+	m.Training.Params.Radius = 1.5
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(20, c.SOM.Epochs)
+	assert.Equal(0.2, c.SOM.LearningRate)
+	assert.Equal(1.5, c.SOM.Radius)
+}
+
+func TestParseDBN(t *testing.T) {
+	assert := assert.New(t)
+	var m Manifest
+	manifest := []byte(`kind: dbn
+task: class
+network:
+  input:
+    size: 4
+  hidden:
+    size: [3, 2]
+    activation: sigmoid
+  output:
+    size: 2
+    activation: softmax
+training:
+  kind: backprop
+  cost: xentropy
+  optimize:
+    method: bfgs`)
+	assert.NoError(yaml.Unmarshal(manifest, &m))
+	// deep belief networks are pretrained by default
+	c, err := ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal("dbn", c.Network.Kind)
+	assert.Equal(&PretrainConfig{Epochs: 10, LearningRate: 0.1, CDSteps: 1}, c.Training.Pretrain)
+	m.Training.Pretrain.Epochs = 3
+	c, err = ParseManifest(&m)
+	assert.NotNil(c)
+	assert.NoError(err)
+	assert.Equal(3, c.Training.Pretrain.Epochs)
+	// pretraining parameters
+	m.Training.Pretrain.Epochs = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Pretrain.Epochs = 5
+	m.Training.Pretrain.CDSteps = -1
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Pretrain.CDSteps = 0
+	// evolutionary training is not supported
+	m.Training.Kind = "evolution"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Training.Kind = "backprop"
+	// hidden layers must use sigmoid activation function
+	m.Network.Hidden.Activation = "relu"
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+	m.Network.Hidden.Activation = "sigmoid"
+	// hidden layers are required
+	m.Network.Hidden.Size = nil
+	c, err = ParseManifest(&m)
+	assert.Nil(c)
+	assert.Error(err)
+}
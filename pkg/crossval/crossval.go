@@ -0,0 +1,103 @@
+package crossval
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+// TrainFunc trains a model on the training samples and returns its accuracy on the test samples
+type TrainFunc func(trainInMx *mat64.Dense, trainLabels *mat64.Vector,
+	testInMx *mat64.Dense, testLabels *mat64.Vector) (float64, error)
+
+// Result contains the results of k-fold cross-validation
+type Result struct {
+	// Accuracy contains test accuracy of every fold
+	Accuracy []float64
+	// Mean is the mean accuracy of all folds
+	Mean float64
+	// StdDev is the standard deviation of the folds accuracy
+	StdDev float64
+}
+
+// Folds randomly assigns sample indices to k folds of (nearly) the same size.
+// If stratify is true, each label is distributed among the folds separately so every fold
+// contains the same proportions of labels as the whole data set.
+// It fails with error if k is smaller than 2 or if there are fewer samples than folds.
+func Folds(labels *mat64.Vector, k int, stratify bool) ([][]int, error) {
+	if k < 2 {
+		return nil, fmt.Errorf("Incorrect number of folds: %d\n", k)
+	}
+	samples := labels.Len()
+	if samples < k {
+		return nil, fmt.Errorf("Can't split %d samples into %d folds\n", samples, k)
+	}
+	perm := rand.Perm(samples)
+	// grouping the shuffled samples by label before dealing them to folds stratifies them
+	if stratify {
+		sort.Stable(byLabel{idx: perm, labels: labels})
+	}
+	folds := make([][]int, k)
+	for i, idx := range perm {
+		folds[i%k] = append(folds[i%k], idx)
+	}
+	return folds, nil
+}
+
+// KFold runs k-fold cross-validation: it splits the samples into k folds and calls train
+// k times, every time testing on a different fold and training on the remaining folds.
+// It returns accuracy of every fold along with their mean and standard deviation.
+// It fails with error if the number of features does not match the number of labels,
+// if the samples can't be split into k folds or if any of the training runs fails.
+func KFold(inMx *mat64.Dense, labels *mat64.Vector, k int, stratify bool, train TrainFunc) (*Result, error) {
+	if rows, _ := inMx.Dims(); rows != labels.Len() {
+		return nil, fmt.Errorf("Samples count mismatch. Features: %d, Labels: %d\n", rows, labels.Len())
+	}
+	folds, err := Folds(labels, k, stratify)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Accuracy: make([]float64, k)}
+	for i := range folds {
+		var trainIdx []int
+		for j, fold := range folds {
+			if j != i {
+				trainIdx = append(trainIdx, fold...)
+			}
+		}
+		trainInMx, trainLabels := selectRows(inMx, labels, trainIdx)
+		testInMx, testLabels := selectRows(inMx, labels, folds[i])
+		if res.Accuracy[i], err = train(trainInMx, trainLabels, testInMx, testLabels); err != nil {
+			return nil, fmt.Errorf("Fold %d training failed: %s\n", i+1, err)
+		}
+	}
+	res.Mean, res.StdDev = stat.MeanStdDev(res.Accuracy, nil)
+	return res, nil
+}
+
+// byLabel sorts sample indices by their labels
+type byLabel struct {
+	idx    []int
+	labels *mat64.Vector
+}
+
+func (b byLabel) Len() int      { return len(b.idx) }
+func (b byLabel) Swap(i, j int) { b.idx[i], b.idx[j] = b.idx[j], b.idx[i] }
+func (b byLabel) Less(i, j int) bool {
+	return b.labels.At(b.idx[i], 0) < b.labels.At(b.idx[j], 0)
+}
+
+// selectRows returns the rows of features matrix and labels vector stored at idx indices
+func selectRows(inMx *mat64.Dense, labels *mat64.Vector, idx []int) (*mat64.Dense, *mat64.Vector) {
+	_, cols := inMx.Dims()
+	mx := mat64.NewDense(len(idx), cols, nil)
+	vec := mat64.NewVector(len(idx), nil)
+	for i, j := range idx {
+		mx.SetRow(i, inMx.RawRowView(j))
+		vec.SetVec(i, labels.At(j, 0))
+	}
+	return mx, vec
+}
@@ -0,0 +1,80 @@
+package crossval
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFolds(t *testing.T) {
+	assert := assert.New(t)
+	// 10 samples: 6 labeled as 1, 4 labeled as 2
+	labels := mat64.NewVector(10, []float64{1, 1, 1, 1, 1, 1, 2, 2, 2, 2})
+	// incorrect number of folds
+	for _, k := range []int{-1, 0, 1, 11} {
+		_, err := Folds(labels, k, false)
+		assert.Error(err)
+	}
+	// every sample is placed into exactly one fold
+	folds, err := Folds(labels, 3, false)
+	assert.NoError(err)
+	assert.Len(folds, 3)
+	seen := make(map[int]bool)
+	for _, fold := range folds {
+		assert.True(len(fold) == 3 || len(fold) == 4)
+		for _, idx := range fold {
+			assert.False(seen[idx])
+			seen[idx] = true
+		}
+	}
+	assert.Len(seen, 10)
+	// stratified folds preserve label proportions
+	folds, err = Folds(labels, 2, true)
+	assert.NoError(err)
+	for _, fold := range folds {
+		count := 0
+		for _, idx := range fold {
+			if labels.At(idx, 0) == 1 {
+				count++
+			}
+		}
+		assert.Equal(3, count)
+		assert.Len(fold, 5)
+	}
+}
+
+func TestKFold(t *testing.T) {
+	assert := assert.New(t)
+	inMx := mat64.NewDense(4, 1, []float64{0, 1, 2, 3})
+	labels := mat64.NewVector(4, []float64{1, 2, 1, 2})
+	// samples count mismatch
+	_, err := KFold(inMx, mat64.NewVector(2, nil), 2, false, nil)
+	assert.Error(err)
+	// every fold is tested on samples it was not trained on
+	fold := 0
+	train := func(trainInMx *mat64.Dense, trainLabels *mat64.Vector,
+		testInMx *mat64.Dense, testLabels *mat64.Vector) (float64, error) {
+		trainRows, _ := trainInMx.Dims()
+		testRows, _ := testInMx.Dims()
+		assert.Equal(3, trainRows)
+		assert.Equal(1, testRows)
+		for i := 0; i < trainRows; i++ {
+			assert.NotEqual(testInMx.At(0, 0), trainInMx.At(i, 0))
+		}
+		fold++
+		return float64(fold * 10), nil
+	}
+	res, err := KFold(inMx, labels, 4, false, train)
+	assert.NoError(err)
+	assert.Equal([]float64{10, 20, 30, 40}, res.Accuracy)
+	assert.Equal(25.0, res.Mean)
+	assert.InDelta(12.9099, res.StdDev, 1e-4)
+	// failed training run
+	fail := func(*mat64.Dense, *mat64.Vector, *mat64.Dense, *mat64.Vector) (float64, error) {
+		return 0.0, errors.New("failed")
+	}
+	_, err = KFold(inMx, labels, 2, false, fail)
+	assert.Error(err)
+}
@@ -1,20 +1,45 @@
 package dataset
 
 import (
+	"bufio"
 	"encoding/csv"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 
+	"github.com/gonum/floats"
 	"github.com/gonum/matrix/mat64"
 	"github.com/gonum/stat"
+	"github.com/milosgajdos83/go-neural/pkg/logger"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
 )
 
+// dsLogger logs data set messages
+var dsLogger = logger.Discard
+
+// SetLogger sets the logger used to log data set messages.
+// Loaded data sets are logged at Debug level, scaling problems are logged at Info level.
+// Data set messages are discarded by default or if nil logger is passed in.
+func SetLogger(l logger.Logger) {
+	if l == nil {
+		l = logger.Discard
+	}
+	dsLogger = l
+}
+
 // load data funcs
 var loadFuncs = map[string]func(io.Reader) (*mat64.Dense, error){
-	".csv": LoadCSV,
+	".csv":    LoadCSV,
+	".txt":    LoadASCII,
+	".libsvm": LoadLibSVM,
+	".svm":    LoadLibSVM,
+	".json":   LoadJSON,
 }
 
 // DataSet represents training data set
@@ -26,8 +51,10 @@ type DataSet struct {
 // NewDataSet returns new data set or fails with error if either the path to data set
 // supplied as a parameter does not exist or if the data set file is encoded
 // in an unsupported format. File format is inferred from the file extension.
-// Currently only CSV files are supported. You can specify if the data set is labeled or not
-// In CSV context "labeled" means that the labels are the last column in the raw file
+// Supported formats are CSV (.csv), whitespace separated ASCII (.txt), LibSVM (.libsvm, .svm)
+// and JSON (.json). You can specify if the data set is labeled or not.
+// In CSV context "labeled" means that the labels are the last column in the raw file.
+// LibSVM data sets always contain labels which are placed in the last column.
 func NewDataSet(path string, labeled bool) (*DataSet, error) {
 	// Check if the training data file exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -50,6 +77,8 @@ func NewDataSet(path string, labeled bool) (*DataSet, error) {
 	if err != nil {
 		return nil, err
 	}
+	rows, cols := mx.Dims()
+	dsLogger.Debugf("Loaded data set %s: %d samples, %d columns", path, rows, cols)
 	// Return Data
 	return &DataSet{
 		mx:      mx,
@@ -146,25 +175,346 @@ func LoadCSV(r io.Reader) (*mat64.Dense, error) {
 	return mx, nil
 }
 
+// LoadASCII loads data matrix from the reader passed in as a parameter.
+// It expects the data to be stored in a whitespace separated ASCII format
+// as produced by Octave/MATLAB "save -ascii" command: each matrix row is stored on
+// a separate line. Empty lines and lines starting with '#' are ignored which allows to
+// read Octave text files that contain matrix metadata in comments.
+// It returns error if the data is corrupted or can not be converted to float numbers.
+func LoadASCII(r io.Reader) (*mat64.Dense, error) {
+	// data matrix dimensions: rows x cols
+	var rows, cols int
+	// mxData contains ALL data read field by field
+	var mxData []float64
+	scanner := bufio.NewScanner(r)
+	// matrix rows might be really long
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		// initialize cols on first iteration
+		if rows == 0 {
+			cols = len(fields)
+		}
+		// number of columns is not the same as in the read line
+		if cols != len(fields) {
+			return nil, fmt.Errorf("Inconsistent number of features: %d\n", len(fields))
+		}
+		for _, field := range fields {
+			f, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, err
+			}
+			mxData = append(mxData, f)
+		}
+		rows++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// no data found
+	if rows == 0 {
+		return nil, fmt.Errorf("No data found\n")
+	}
+	// Initialize data matrix with the read data
+	mx := mat64.NewDense(rows, cols, mxData)
+	return mx, nil
+}
+
 // Scale centers the data set to zero mean values and scales each column.
 // It modifies the data stored in the data set. If your data contains also
 // labeles in the last column, make sure you extract it before scaling.
 func Scale(mx mat64.Matrix) mat64.Matrix {
-	rows, cols := mx.Dims()
-	// mean/stdev store each column mean/stdev values
-	col := make([]float64, rows)
-	mean := make([]float64, cols)
-	stdev := make([]float64, cols)
-	for i := 0; i < cols; i++ {
-		// copy i-th column to col
-		mat64.Col(col, i, mx)
-		mean[i], stdev[i] = stat.MeanStdDev(col, nil)
-	}
-	scale := func(i, j int, x float64) float64 {
-		return (x - mean[j]) / stdev[j]
-	}
-	dataMx := new(mat64.Dense)
-	dataMx.Clone(mx)
-	dataMx.Apply(scale, dataMx)
+	// scaler has been created from the same matrix: dimensions always match
+	dataMx, _ := NewScaler(mx).Scale(mx)
 	return dataMx
 }
+
+// Scaler scales features using per column mean and standard deviation values
+// computed from a data set so that the same scaling can be applied to new samples.
+// Scaler has the same layout as matrix.Standardizer which does the actual scaling.
+type Scaler struct {
+	// Mean contains column mean values
+	Mean []float64 `json:"mean"`
+	// StdDev contains column standard deviation values
+	StdDev []float64 `json:"stddev"`
+}
+
+// NewScaler creates new Scaler which scales features the same way as Scale scales mx
+func NewScaler(mx mat64.Matrix) *Scaler {
+	s := new(matrix.Standardizer)
+	// nil matrix yields empty scaler which fails to scale any matrix
+	s.Fit(mx)
+	for i, stdDev := range s.StdDev {
+		if stdDev == 0.0 {
+			dsLogger.Infof("Column %d has zero standard deviation: its scaled values are not defined", i+1)
+		}
+	}
+	return (*Scaler)(s)
+}
+
+// Scale centers the columns of mx to the scaler mean values and scales them by the scaler
+// standard deviation values. It does not modify mx: it returns new scaled matrix instead.
+// It fails with error if mx does not have the same number of columns as the scaler.
+func (s *Scaler) Scale(mx mat64.Matrix) (mat64.Matrix, error) {
+	dataMx, err := (*matrix.Standardizer)(s).Transform(mx)
+	if err != nil {
+		return nil, err
+	}
+	return dataMx, nil
+}
+
+// LabelMap maps data set labels to network labels which start at 1.
+// Network label i corresponds to data set label stored at index i-1.
+type LabelMap []float64
+
+// NewLabelMap creates new LabelMap which maps distinct labels sorted in ascending order
+// to network labels 1, 2, ... If labels already start at 1 and have no gaps, they are
+// mapped to themselves.
+func NewLabelMap(labels *mat64.Vector) LabelMap {
+	seen := make(map[float64]bool)
+	var lm LabelMap
+	for i := 0; i < labels.Len(); i++ {
+		label := labels.At(i, 0)
+		if !seen[label] {
+			seen[label] = true
+			lm = append(lm, label)
+		}
+	}
+	sort.Float64s(lm)
+	return lm
+}
+
+// Encode maps data set labels to network labels.
+// It fails with error if any of the labels is not in the label map.
+func (lm LabelMap) Encode(labels *mat64.Vector) (*mat64.Vector, error) {
+	index := make(map[float64]int, len(lm))
+	for i, label := range lm {
+		index[label] = i + 1
+	}
+	encoded := mat64.NewVector(labels.Len(), nil)
+	for i := 0; i < labels.Len(); i++ {
+		idx, ok := index[labels.At(i, 0)]
+		if !ok {
+			return nil, fmt.Errorf("Unknown label: %g\n", labels.At(i, 0))
+		}
+		encoded.SetVec(i, float64(idx))
+	}
+	return encoded, nil
+}
+
+// Decode maps network label to data set label.
+// It fails with error if the network label is not in the label map.
+func (lm LabelMap) Decode(label float64) (float64, error) {
+	idx := int(label)
+	if float64(idx) != label || idx < 1 || idx > len(lm) {
+		return 0.0, fmt.Errorf("Incorrect network label: %g\n", label)
+	}
+	return lm[idx-1], nil
+}
+
+// Split randomly splits features matrix and labels vector into training and test data sets.
+// ratio specifies the fraction of samples which are placed into the training data set.
+// If stratify is true, each label is split separately so both data sets contain
+// the same proportions of labels as the original data set.
+// It returns training features and labels followed by test features and labels.
+// It fails with error if the ratio is not in (0, 1) interval, if the number of features
+// does not match the number of labels or if either of the data sets would be empty.
+func Split(inMx *mat64.Dense, labels *mat64.Vector, ratio float64, stratify bool) (
+	*mat64.Dense, *mat64.Vector, *mat64.Dense, *mat64.Vector, error) {
+	if ratio <= 0.0 || ratio >= 1.0 {
+		return nil, nil, nil, nil, fmt.Errorf("Incorrect split ratio: %f\n", ratio)
+	}
+	samples, _ := inMx.Dims()
+	if samples != labels.Len() {
+		return nil, nil, nil, nil, fmt.Errorf("Samples count mismatch. Features: %d, Labels: %d\n",
+			samples, labels.Len())
+	}
+	// groups contains indices of samples which are split together
+	var groups [][]int
+	if stratify {
+		byLabel := make(map[float64]int)
+		for i := 0; i < samples; i++ {
+			label := labels.At(i, 0)
+			g, ok := byLabel[label]
+			if !ok {
+				g = len(groups)
+				byLabel[label] = g
+				groups = append(groups, nil)
+			}
+			groups[g] = append(groups[g], i)
+		}
+	} else {
+		all := make([]int, samples)
+		for i := range all {
+			all[i] = i
+		}
+		groups = [][]int{all}
+	}
+	var trainIdx, testIdx []int
+	for _, group := range groups {
+		// shuffle group samples and place the leading ones into training data set
+		perm := rand.Perm(len(group))
+		n := int(math.Floor(ratio*float64(len(group)) + 0.5))
+		for i, p := range perm {
+			if i < n {
+				trainIdx = append(trainIdx, group[p])
+			} else {
+				testIdx = append(testIdx, group[p])
+			}
+		}
+	}
+	if len(trainIdx) == 0 || len(testIdx) == 0 {
+		return nil, nil, nil, nil, fmt.Errorf("Split produces empty data set. Train: %d, Test: %d\n",
+			len(trainIdx), len(testIdx))
+	}
+	trainMx, trainLabels := selectRows(inMx, labels, trainIdx)
+	testMx, testLabels := selectRows(inMx, labels, testIdx)
+	return trainMx, trainLabels, testMx, testLabels, nil
+}
+
+// GroupSplit randomly splits features matrix and labels vector into training and test data sets
+// keeping all samples which share the same group key on the same side of the split, so correlated
+// samples such as the samples of the same user don't leak from the training into the test data set.
+// groups contains the group key of every sample. ratio specifies the fraction of samples which are
+// placed into the training data set: whole groups are placed into the training data set until it
+// contains at least the requested fraction of samples, so the resulting fraction is approximate.
+// It returns training features and labels followed by test features and labels.
+// It fails with error if the ratio is not in (0, 1) interval, if the number of features does not
+// match the number of labels or group keys or if either of the data sets would be empty.
+func GroupSplit(inMx *mat64.Dense, labels *mat64.Vector, groups []float64, ratio float64) (
+	*mat64.Dense, *mat64.Vector, *mat64.Dense, *mat64.Vector, error) {
+	if ratio <= 0.0 || ratio >= 1.0 {
+		return nil, nil, nil, nil, fmt.Errorf("Incorrect split ratio: %f\n", ratio)
+	}
+	samples, _ := inMx.Dims()
+	if samples != labels.Len() || samples != len(groups) {
+		return nil, nil, nil, nil, fmt.Errorf("Samples count mismatch. Features: %d, Labels: %d, Groups: %d\n",
+			samples, labels.Len(), len(groups))
+	}
+	// members contains indices of samples of every group in the order the groups first appear
+	var members [][]int
+	byGroup := make(map[float64]int)
+	for i, key := range groups {
+		g, ok := byGroup[key]
+		if !ok {
+			g = len(members)
+			byGroup[key] = g
+			members = append(members, nil)
+		}
+		members[g] = append(members[g], i)
+	}
+	// shuffle groups and place the leading ones into training data set
+	n := int(math.Floor(ratio*float64(samples) + 0.5))
+	var trainIdx, testIdx []int
+	for _, p := range rand.Perm(len(members)) {
+		if len(trainIdx) < n {
+			trainIdx = append(trainIdx, members[p]...)
+		} else {
+			testIdx = append(testIdx, members[p]...)
+		}
+	}
+	if len(trainIdx) == 0 || len(testIdx) == 0 {
+		return nil, nil, nil, nil, fmt.Errorf("Split produces empty data set. Train: %d, Test: %d\n",
+			len(trainIdx), len(testIdx))
+	}
+	trainMx, trainLabels := selectRows(inMx, labels, trainIdx)
+	testMx, testLabels := selectRows(inMx, labels, testIdx)
+	return trainMx, trainLabels, testMx, testLabels, nil
+}
+
+// selectRows copies features matrix rows and labels stored at given indices
+func selectRows(inMx *mat64.Dense, labels *mat64.Vector, idx []int) (*mat64.Dense, *mat64.Vector) {
+	_, cols := inMx.Dims()
+	mx := mat64.NewDense(len(idx), cols, nil)
+	vec := mat64.NewVector(len(idx), nil)
+	for i, j := range idx {
+		mx.SetRow(i, inMx.RawRowView(j))
+		vec.SetVec(i, labels.At(j, 0))
+	}
+	return mx, vec
+}
+
+// FeatureStats contains statistics of a particular data set feature
+type FeatureStats struct {
+	// Mean is the mean of feature values
+	Mean float64
+	// StdDev is the standard deviation of feature values
+	StdDev float64
+	// Min is the minimum feature value
+	Min float64
+	// Max is the maximum feature value
+	Max float64
+	// Missing is the number of missing i.e. NaN feature values
+	Missing int
+}
+
+// ClassCount contains the number of samples labeled as a particular class
+type ClassCount struct {
+	// Label is class label
+	Label float64
+	// Count is the number of samples labeled as Label
+	Count int
+}
+
+// Description contains data set statistics
+type Description struct {
+	// Samples is the number of data set samples
+	Samples int
+	// Features contains statistics of all data set features
+	Features []FeatureStats
+	// Classes contains class distribution sorted by label.
+	// It is nil if the data set is not labeled.
+	Classes []ClassCount
+}
+
+// Describe returns data set statistics. Missing feature values are ignored
+// when calculating the feature statistics.
+func (ds DataSet) Describe() *Description {
+	features := ds.Features()
+	rows, cols := features.Dims()
+	d := &Description{
+		Samples:  rows,
+		Features: make([]FeatureStats, cols),
+	}
+	col := make([]float64, 0, rows)
+	for j := 0; j < cols; j++ {
+		// collect all non-missing feature values
+		col = col[:0]
+		for i := 0; i < rows; i++ {
+			if x := features.At(i, j); !math.IsNaN(x) {
+				col = append(col, x)
+			}
+		}
+		fs := FeatureStats{Missing: rows - len(col)}
+		if len(col) > 0 {
+			fs.Mean, fs.StdDev = stat.MeanStdDev(col, nil)
+			fs.Min, fs.Max = floats.Min(col), floats.Max(col)
+		}
+		d.Features[j] = fs
+	}
+	labels := ds.Labels()
+	if labels == nil {
+		return d
+	}
+	counts := make(map[float64]int)
+	for i := 0; i < rows; i++ {
+		counts[labels.At(i, 0)]++
+	}
+	for label, count := range counts {
+		d.Classes = append(d.Classes, ClassCount{Label: label, Count: count})
+	}
+	sort.Sort(byLabel(d.Classes))
+	return d
+}
+
+// byLabel sorts class counts by label
+type byLabel []ClassCount
+
+func (c byLabel) Len() int           { return len(c) }
+func (c byLabel) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c byLabel) Less(i, j int) bool { return c[i].Label < c[j].Label }
@@ -1,6 +1,7 @@
 package dataset
 
 import (
+	"bytes"
 	"io/ioutil"
 	"log"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/logger"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -142,6 +144,198 @@ func TestScale(t *testing.T) {
 	assert.True(mat64.Equal(scaledFeats, scaledMx))
 }
 
+func TestScaler(t *testing.T) {
+	assert := assert.New(t)
+
+	trainMx := mat64.NewDense(3, 2, []float64{
+		2.0, 3.5,
+		4.5, 5.5,
+		7.0, 9.0,
+	})
+	s := NewScaler(trainMx)
+	assert.Equal([]float64{4.5, 6.0}, s.Mean)
+	// scaler scales data the same way as Scale
+	scaled, err := s.Scale(trainMx)
+	assert.NoError(err)
+	assert.True(mat64.Equal(Scale(trainMx), scaled))
+	// new samples are scaled with the training data statistics
+	scaled, err = s.Scale(mat64.NewDense(1, 2, []float64{4.5, 6.0}))
+	assert.NoError(err)
+	assert.True(mat64.Equal(mat64.NewDense(1, 2, nil), scaled))
+	// dimension mismatch
+	_, err = s.Scale(mat64.NewDense(1, 3, nil))
+	assert.Error(err)
+}
+
+func TestSetLogger(t *testing.T) {
+	assert := assert.New(t)
+	defer SetLogger(nil)
+	buf := new(bytes.Buffer)
+	SetLogger(logger.New(buf, logger.Debug))
+	// loaded data sets are logged
+	tmpPath := path.Join(os.TempDir(), fileName)
+	_, err := NewDataSet(tmpPath, true)
+	assert.NoError(err)
+	assert.Contains(buf.String(), "3 samples, 2 columns")
+	// constant columns can't be scaled
+	NewScaler(mat64.NewDense(2, 2, []float64{1.0, 2.0, 1.0, 3.0}))
+	assert.Contains(buf.String(), "Column 1 has zero standard deviation")
+}
+
+func TestLabelMap(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := mat64.NewVector(4, []float64{5.0, 0.0, 5.0, 2.0})
+	lm := NewLabelMap(labels)
+	assert.Equal(LabelMap{0.0, 2.0, 5.0}, lm)
+	encoded, err := lm.Encode(labels)
+	assert.NoError(err)
+	assert.Equal([]float64{3.0, 1.0, 3.0, 2.0}, encoded.RawVector().Data)
+	for i := 0; i < labels.Len(); i++ {
+		label, err := lm.Decode(encoded.At(i, 0))
+		assert.NoError(err)
+		assert.Equal(labels.At(i, 0), label)
+	}
+	// unknown labels
+	_, err = lm.Encode(mat64.NewVector(1, []float64{1.0}))
+	assert.Error(err)
+	for _, label := range []float64{0.0, 4.0, 1.5} {
+		_, err = lm.Decode(label)
+		assert.Error(err)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	assert := assert.New(t)
+	// 10 samples: 6 labeled as 1, 4 labeled as 2
+	inMx := mat64.NewDense(10, 2, nil)
+	labels := mat64.NewVector(10, nil)
+	for i := 0; i < 10; i++ {
+		inMx.SetRow(i, []float64{float64(i), float64(i * 10)})
+		label := 1.0
+		if i >= 6 {
+			label = 2.0
+		}
+		labels.SetVec(i, label)
+	}
+	// incorrect ratio
+	for _, ratio := range []float64{0.0, 1.0, -0.5, 1.5} {
+		_, _, _, _, err := Split(inMx, labels, ratio, false)
+		assert.Error(err)
+	}
+	// samples count mismatch
+	_, _, _, _, err := Split(inMx, mat64.NewVector(2, nil), 0.5, false)
+	assert.Error(err)
+	// empty test data set
+	_, _, _, _, err = Split(inMx, labels, 0.99, false)
+	assert.Error(err)
+	// stratified split preserves label proportions
+	trainMx, trainLabels, testMx, testLabels, err := Split(inMx, labels, 0.5, true)
+	assert.NoError(err)
+	trainRows, _ := trainMx.Dims()
+	testRows, _ := testMx.Dims()
+	assert.Equal(5, trainRows)
+	assert.Equal(5, testRows)
+	count := func(v *mat64.Vector, label float64) int {
+		n := 0
+		for i := 0; i < v.Len(); i++ {
+			if v.At(i, 0) == label {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(3, count(trainLabels, 1.0))
+	assert.Equal(2, count(trainLabels, 2.0))
+	assert.Equal(3, count(testLabels, 1.0))
+	assert.Equal(2, count(testLabels, 2.0))
+	// every sample is placed into exactly one data set with its label
+	trainMx, trainLabels, testMx, testLabels, err = Split(inMx, labels, 0.8, false)
+	assert.NoError(err)
+	trainRows, _ = trainMx.Dims()
+	assert.Equal(8, trainRows)
+	assert.Equal(2, testLabels.Len())
+	seen := make(map[float64]bool)
+	check := func(mx *mat64.Dense, v *mat64.Vector) {
+		for i := 0; i < v.Len(); i++ {
+			idx := mx.At(i, 0)
+			assert.False(seen[idx])
+			seen[idx] = true
+			assert.Equal(idx*10, mx.At(i, 1))
+			assert.Equal(labels.At(int(idx), 0), v.At(i, 0))
+		}
+	}
+	check(trainMx, trainLabels)
+	check(testMx, testLabels)
+	assert.Len(seen, 10)
+}
+
+func TestGroupSplit(t *testing.T) {
+	assert := assert.New(t)
+	// 12 samples in 4 groups of 3 samples
+	inMx := mat64.NewDense(12, 1, nil)
+	labels := mat64.NewVector(12, nil)
+	groups := make([]float64, 12)
+	for i := 0; i < 12; i++ {
+		inMx.Set(i, 0, float64(i))
+		labels.SetVec(i, float64(i%2+1))
+		groups[i] = float64(i % 4)
+	}
+	// incorrect ratio
+	for _, ratio := range []float64{0.0, 1.0} {
+		_, _, _, _, err := GroupSplit(inMx, labels, groups, ratio)
+		assert.Error(err)
+	}
+	// samples count mismatch
+	_, _, _, _, err := GroupSplit(inMx, labels, groups[:5], 0.5)
+	assert.Error(err)
+	// single group can't be split
+	_, _, _, _, err = GroupSplit(inMx, labels, make([]float64, 12), 0.5)
+	assert.Error(err)
+	// groups are placed into data sets as a whole
+	trainMx, trainLabels, testMx, testLabels, err := GroupSplit(inMx, labels, groups, 0.5)
+	assert.NoError(err)
+	assert.Equal(6, trainLabels.Len())
+	assert.Equal(6, testLabels.Len())
+	side := make(map[float64]bool)
+	for i := 0; i < 6; i++ {
+		side[groups[int(trainMx.At(i, 0))]] = true
+		assert.Equal(labels.At(int(trainMx.At(i, 0)), 0), trainLabels.At(i, 0))
+	}
+	assert.Len(side, 2)
+	for i := 0; i < 6; i++ {
+		assert.False(side[groups[int(testMx.At(i, 0))]])
+		assert.Equal(labels.At(int(testMx.At(i, 0)), 0), testLabels.At(i, 0))
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	assert := assert.New(t)
+	// labeled data set: 2 features, the second one has a missing value
+	content := []byte("1.0,NaN,2\n3.0,4.0,1\n5.0,6.0,2")
+	tmpPath := filepath.Join(os.TempDir(), "describe.csv")
+	err := ioutil.WriteFile(tmpPath, content, 0666)
+	assert.NoError(err)
+	defer os.Remove(tmpPath)
+	ds, err := NewDataSet(tmpPath, true)
+	assert.NoError(err)
+	d := ds.Describe()
+	assert.Equal(3, d.Samples)
+	assert.Len(d.Features, 2)
+	assert.Equal(FeatureStats{Mean: 3.0, StdDev: 2.0, Min: 1.0, Max: 5.0}, d.Features[0])
+	assert.Equal(1, d.Features[1].Missing)
+	assert.Equal(5.0, d.Features[1].Mean)
+	assert.Equal(4.0, d.Features[1].Min)
+	assert.Equal(6.0, d.Features[1].Max)
+	assert.Equal([]ClassCount{{Label: 1, Count: 1}, {Label: 2, Count: 2}}, d.Classes)
+	// unlabeled data set has no classes
+	ds, err = NewDataSet(tmpPath, false)
+	assert.NoError(err)
+	d = ds.Describe()
+	assert.Len(d.Features, 3)
+	assert.Nil(d.Classes)
+}
+
 func TestLoadCSV(t *testing.T) {
 	assert := assert.New(t)
 
@@ -165,3 +359,34 @@ func TestLoadCSV(t *testing.T) {
 	assert.Error(err)
 	assert.Nil(mx)
 }
+
+func TestLoadASCII(t *testing.T) {
+	assert := assert.New(t)
+
+	// correct data with Octave comments and empty lines
+	tstRdr := strings.NewReader("# name: Theta1\n# rows: 2\n\n 1.0e+00 2.5 3\n-4 5 6.0\n")
+	mx, err := LoadASCII(tstRdr)
+	assert.NoError(err)
+	r, c := mx.Dims()
+	assert.Equal(r, 2)
+	assert.Equal(c, 3)
+	assert.Equal(mx.At(1, 0), -4.0)
+
+	// inconsistent data
+	tstRdr = strings.NewReader("1 2 3\n4 5")
+	mx, err = LoadASCII(tstRdr)
+	assert.Error(err)
+	assert.Nil(mx)
+
+	// corrupted data
+	tstRdr = strings.NewReader("1 sdfsdfd 3\n4 5 6")
+	mx, err = LoadASCII(tstRdr)
+	assert.Error(err)
+	assert.Nil(mx)
+
+	// no data
+	tstRdr = strings.NewReader("# only comment")
+	mx, err = LoadASCII(tstRdr)
+	assert.Error(err)
+	assert.Nil(mx)
+}
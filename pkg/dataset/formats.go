@@ -0,0 +1,181 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// write data funcs
+var writeFuncs = map[string]func(io.Writer, mat64.Matrix) error{
+	".csv":    WriteCSV,
+	".libsvm": WriteLibSVM,
+	".svm":    WriteLibSVM,
+	".json":   WriteJSON,
+}
+
+// LoadLibSVM loads data matrix from the reader passed in as a parameter.
+// It expects the data to be stored in LibSVM format: each sample is stored on a separate
+// line which starts with the sample label followed by index:value pairs of non-zero
+// features. Feature indices start at 1. Empty lines and lines starting with '#' are ignored.
+// It returns data matrix which contains features in columns followed by labels in the last
+// column. The number of features is equal to the largest feature index found in the data.
+// It returns error if the data is corrupted or can not be converted to float numbers.
+func LoadLibSVM(r io.Reader) (*mat64.Dense, error) {
+	var labels []float64
+	var samples []map[int]float64
+	var cols int
+	scanner := bufio.NewScanner(r)
+	// sample rows might be really long
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		label, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		sample := make(map[int]float64)
+		for _, field := range fields[1:] {
+			pair := strings.SplitN(field, ":", 2)
+			if len(pair) != 2 {
+				return nil, fmt.Errorf("Incorrect feature: %s\n", field)
+			}
+			idx, err := strconv.Atoi(pair[0])
+			if err != nil || idx <= 0 {
+				return nil, fmt.Errorf("Incorrect feature index: %s\n", pair[0])
+			}
+			val, err := strconv.ParseFloat(pair[1], 64)
+			if err != nil {
+				return nil, err
+			}
+			sample[idx-1] = val
+			if idx > cols {
+				cols = idx
+			}
+		}
+		labels = append(labels, label)
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// no data found
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("No data found\n")
+	}
+	// labels are stored in the last column
+	mx := mat64.NewDense(len(samples), cols+1, nil)
+	for i, sample := range samples {
+		for j, val := range sample {
+			mx.Set(i, j, val)
+		}
+		mx.Set(i, cols, labels[i])
+	}
+	return mx, nil
+}
+
+// LoadJSON loads data matrix from the reader passed in as a parameter.
+// It expects the data to be stored as JSON array of matrix rows: each row is an array of numbers.
+// It returns error if the data can't be decoded or if the rows have different lengths.
+func LoadJSON(r io.Reader) (*mat64.Dense, error) {
+	var rows [][]float64
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, err
+	}
+	// no data found
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, fmt.Errorf("No data found\n")
+	}
+	cols := len(rows[0])
+	mxData := make([]float64, 0, len(rows)*cols)
+	for _, row := range rows {
+		// number of columns is not the same as in the first row
+		if len(row) != cols {
+			return nil, fmt.Errorf("Inconsistent number of features: %d\n", len(row))
+		}
+		mxData = append(mxData, row...)
+	}
+	return mat64.NewDense(len(rows), cols, mxData), nil
+}
+
+// WriteCSV writes data matrix to w in CSV format: one matrix row per record
+func WriteCSV(w io.Writer, mx mat64.Matrix) error {
+	rows, cols := mx.Dims()
+	cw := csv.NewWriter(w)
+	record := make([]string, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			record[j] = strconv.FormatFloat(mx.At(i, j), 'g', -1, 64)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteLibSVM writes data matrix to w in LibSVM format. Labels are expected to be
+// stored in the last column of the data matrix. Zero features are omitted.
+// It returns error if the data matrix does not contain any features.
+func WriteLibSVM(w io.Writer, mx mat64.Matrix) error {
+	rows, cols := mx.Dims()
+	if cols < 2 {
+		return fmt.Errorf("Data matrix does not contain features: %d columns\n", cols)
+	}
+	bw := bufio.NewWriter(w)
+	for i := 0; i < rows; i++ {
+		bw.WriteString(strconv.FormatFloat(mx.At(i, cols-1), 'g', -1, 64))
+		for j := 0; j < cols-1; j++ {
+			if val := mx.At(i, j); val != 0 {
+				fmt.Fprintf(bw, " %d:%s", j+1, strconv.FormatFloat(val, 'g', -1, 64))
+			}
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// WriteJSON writes data matrix to w encoded as JSON array of matrix rows
+func WriteJSON(w io.Writer, mx mat64.Matrix) error {
+	rows, _ := mx.Dims()
+	data := make([][]float64, rows)
+	for i := range data {
+		data[i] = mat64.Row(nil, i, mx)
+	}
+	return json.NewEncoder(w).Encode(data)
+}
+
+// WriteFile writes data matrix to the file stored in path. File format is inferred
+// from the file extension. If the data matrix contains labels, they must be stored
+// in its last column. It fails with error if the file format is not supported
+// or if the file can not be written.
+func WriteFile(path string, mx mat64.Matrix) error {
+	fileType := filepath.Ext(path)
+	writeData, ok := writeFuncs[fileType]
+	if !ok {
+		return fmt.Errorf("Unsupported file type: %s\n", fileType)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeData(f, mx); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
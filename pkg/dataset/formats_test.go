@@ -0,0 +1,106 @@
+package dataset
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadLibSVM(t *testing.T) {
+	assert := assert.New(t)
+
+	// correct data with comments and empty lines
+	tstRdr := strings.NewReader("# comment\n2 1:0.5 3:1.5\n\n1 2:-1\n")
+	mx, err := LoadLibSVM(tstRdr)
+	assert.NoError(err)
+	expMx := mat64.NewDense(2, 4, []float64{
+		0.5, 0, 1.5, 2,
+		0, -1, 0, 1,
+	})
+	assert.True(mat64.Equal(expMx, mx))
+
+	// corrupted data
+	for _, data := range []string{"a 1:1", "1 1-1", "1 0:1", "1 x:1", "1 1:x", ""} {
+		mx, err = LoadLibSVM(strings.NewReader(data))
+		assert.Error(err)
+		assert.Nil(mx)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	// correct data
+	mx, err := LoadJSON(strings.NewReader("[[1, 2, 3], [4, 5, 6]]"))
+	assert.NoError(err)
+	assert.True(mat64.Equal(mat64.NewDense(2, 3, []float64{1, 2, 3, 4, 5, 6}), mx))
+
+	// corrupted data
+	for _, data := range []string{"[[1, 2], [3]]", "[]", "[[1, \"a\"]]", "{"} {
+		mx, err = LoadJSON(strings.NewReader(data))
+		assert.Error(err)
+		assert.Nil(mx)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	assert := assert.New(t)
+	mx := mat64.NewDense(2, 3, []float64{
+		0.5, 0, 1,
+		0, -1.5, 2,
+	})
+	testCases := []struct {
+		write func(*bytes.Buffer) error
+		load  func(*bytes.Buffer) (*mat64.Dense, error)
+		exp   string
+	}{
+		{
+			func(b *bytes.Buffer) error { return WriteCSV(b, mx) },
+			func(b *bytes.Buffer) (*mat64.Dense, error) { return LoadCSV(b) },
+			"0.5,0,1\n0,-1.5,2\n",
+		},
+		{
+			func(b *bytes.Buffer) error { return WriteLibSVM(b, mx) },
+			func(b *bytes.Buffer) (*mat64.Dense, error) { return LoadLibSVM(b) },
+			"1 1:0.5\n2 2:-1.5\n",
+		},
+		{
+			func(b *bytes.Buffer) error { return WriteJSON(b, mx) },
+			func(b *bytes.Buffer) (*mat64.Dense, error) { return LoadJSON(b) },
+			"[[0.5,0,1],[0,-1.5,2]]\n",
+		},
+	}
+	for _, tc := range testCases {
+		buf := new(bytes.Buffer)
+		assert.NoError(tc.write(buf))
+		assert.Equal(tc.exp, buf.String())
+		// written data can be loaded back
+		loaded, err := tc.load(buf)
+		assert.NoError(err)
+		assert.True(mat64.Equal(mx, loaded))
+	}
+	// LibSVM data must contain features
+	assert.Error(WriteLibSVM(new(bytes.Buffer), mat64.NewDense(1, 1, nil)))
+}
+
+func TestWriteFile(t *testing.T) {
+	assert := assert.New(t)
+	mx := mat64.NewDense(2, 2, []float64{1, 2, 3, 4})
+	for _, ext := range []string{".csv", ".libsvm", ".svm", ".json"} {
+		tmpPath := filepath.Join(os.TempDir(), "write"+ext)
+		err := WriteFile(tmpPath, mx)
+		assert.NoError(err)
+		ds, err := NewDataSet(tmpPath, true)
+		assert.NoError(err)
+		assert.True(mat64.Equal(mx, ds.Data()))
+		os.Remove(tmpPath)
+	}
+	// unsupported file type
+	err := WriteFile(filepath.Join(os.TempDir(), "write.foo"), mx)
+	assert.Error(err)
+}
@@ -0,0 +1,67 @@
+package dataset
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Hasher converts records of string or categorical features into fixed width feature vectors by
+// the hashing trick: each feature value is hashed together with its column into one of Width
+// vector columns, so no vocabulary of feature values is stored. Different values may collide in
+// the same column: the hash also decides the sign of the value, so that colliding values tend
+// to cancel out rather than accumulate.
+type Hasher struct {
+	// Width is the number of feature vector columns
+	Width int
+}
+
+// NewHasher creates new Hasher which produces feature vectors with width columns.
+// It fails with error if width is not positive.
+func NewHasher(width int) (*Hasher, error) {
+	if width <= 0 {
+		return nil, fmt.Errorf("Incorrect hashing width: %d\n", width)
+	}
+	return &Hasher{Width: width}, nil
+}
+
+// Transform converts records into feature matrix with a row per record and Width columns.
+// Value v of record field i adds +1 or -1 to the column selected by the hash of "i=v".
+// Empty fields are treated as missing and are skipped. Records may have different lengths.
+func (h *Hasher) Transform(records [][]string) (*mat64.Dense, error) {
+	if h.Width <= 0 {
+		return nil, fmt.Errorf("Incorrect hashing width: %d\n", h.Width)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("Incorrect number of records: %d\n", len(records))
+	}
+	mx := mat64.NewDense(len(records), h.Width, nil)
+	for i, record := range records {
+		row := mx.RawRowView(i)
+		for j, field := range record {
+			if field == "" {
+				continue
+			}
+			col, sign := h.hash(j, field)
+			row[col] += sign
+		}
+	}
+	return mx, nil
+}
+
+// hash returns feature vector column and sign of value of record field i
+func (h *Hasher) hash(i int, value string) (int, float64) {
+	f := fnv.New64a()
+	f.Write([]byte(strconv.Itoa(i)))
+	f.Write([]byte{'='})
+	f.Write([]byte(value))
+	sum := f.Sum64()
+	// the top bit decides the sign so that it is independent of the column
+	sign := 1.0
+	if sum>>63 == 1 {
+		sign = -1.0
+	}
+	return int(sum % uint64(h.Width)), sign
+}
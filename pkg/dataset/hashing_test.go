@@ -0,0 +1,58 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasher(t *testing.T) {
+	assert := assert.New(t)
+
+	h, err := NewHasher(16)
+	assert.NotNil(h)
+	assert.NoError(err)
+	records := [][]string{
+		{"red", "small", "berlin"},
+		{"red", "small", "berlin"},
+		{"blue", "", "london"},
+		{"small", "red"},
+	}
+	mx, err := h.Transform(records)
+	assert.NoError(err)
+	rows, cols := mx.Dims()
+	assert.Equal([2]int{4, 16}, [2]int{rows, cols})
+	// equal records produce equal vectors
+	assert.Equal(mx.RawRowView(0), mx.RawRowView(1))
+	// every non-empty field contributes a single signed unit
+	for i, expected := range []float64{3, 3, 2, 2} {
+		sum := 0.0
+		for _, x := range mx.RawRowView(i) {
+			if x < 0 {
+				x = -x
+			}
+			sum += x
+		}
+		// colliding values may cancel out
+		assert.True(sum <= expected)
+	}
+	// single value sets its hashed column to its sign
+	mx, err = h.Transform([][]string{{"red"}})
+	assert.NoError(err)
+	col, sign := h.hash(0, "red")
+	assert.Equal(sign, mx.At(0, col))
+	assert.True(sign == 1.0 || sign == -1.0)
+	// incorrect width
+	h, err = NewHasher(0)
+	assert.Nil(h)
+	assert.Error(err)
+	h = &Hasher{}
+	mx, err = h.Transform(records)
+	assert.Nil(mx)
+	assert.Error(err)
+	// no records
+	h = &Hasher{Width: 4}
+	mx, err = h.Transform(nil)
+	assert.Nil(mx)
+	assert.Error(err)
+}
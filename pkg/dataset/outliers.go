@@ -0,0 +1,122 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+// outlierBounds maps outlier detection methods to functions which compute the interval
+// of feature values which are not outliers from the sorted non-missing feature values
+var outlierBounds = map[string]func(sorted []float64, threshold float64) (float64, float64){
+	// zscore flags values which are more than threshold standard deviations away from the mean
+	"zscore": func(sorted []float64, threshold float64) (float64, float64) {
+		mean, std := stat.MeanStdDev(sorted, nil)
+		return mean - threshold*std, mean + threshold*std
+	},
+	// iqr flags values which are more than threshold interquartile ranges below the first
+	// quartile or above the third quartile
+	"iqr": func(sorted []float64, threshold float64) (float64, float64) {
+		q1 := stat.Quantile(0.25, stat.Empirical, sorted, nil)
+		q3 := stat.Quantile(0.75, stat.Empirical, sorted, nil)
+		return q1 - threshold*(q3-q1), q3 + threshold*(q3-q1)
+	},
+}
+
+// Outlier is a sample flagged as outlier
+type Outlier struct {
+	// Row is the sample row index
+	Row int
+	// Features contains indices of the sample features which exceed the threshold
+	Features []int
+}
+
+// FindOutliers flags the samples stored in rows of inMx which contain any feature value beyond
+// the threshold of its column computed by the given method: zscore flags values which are more
+// than threshold standard deviations away from the column mean, iqr flags values which are more
+// than threshold interquartile ranges outside of the column quartiles. Missing values and columns
+// with zero spread are ignored.
+// It returns the outliers sorted by row. It fails with error if the method is not supported
+// or if the threshold is not positive.
+func FindOutliers(inMx mat64.Matrix, method string, threshold float64) ([]Outlier, error) {
+	if inMx == nil {
+		return nil, fmt.Errorf("Incorrect matrix supplied: %v\n", inMx)
+	}
+	bounds, ok := outlierBounds[method]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported outlier detection method: %s\n", method)
+	}
+	if threshold <= 0.0 {
+		return nil, fmt.Errorf("Incorrect outlier threshold: %f\n", threshold)
+	}
+	rows, cols := inMx.Dims()
+	flagged := make(map[int][]int)
+	col := make([]float64, 0, rows)
+	for j := 0; j < cols; j++ {
+		col = col[:0]
+		for i := 0; i < rows; i++ {
+			if x := inMx.At(i, j); !math.IsNaN(x) {
+				col = append(col, x)
+			}
+		}
+		if len(col) == 0 {
+			continue
+		}
+		sort.Float64s(col)
+		// columns without any spread, e.g. sparse features, don't have outliers
+		lo, hi := bounds(col, threshold)
+		if lo == hi {
+			continue
+		}
+		for i := 0; i < rows; i++ {
+			if x := inMx.At(i, j); x < lo || x > hi {
+				flagged[i] = append(flagged[i], j)
+			}
+		}
+	}
+	outliers := make([]Outlier, 0, len(flagged))
+	for i := 0; i < rows; i++ {
+		if features, ok := flagged[i]; ok {
+			outliers = append(outliers, Outlier{Row: i, Features: features})
+		}
+	}
+	return outliers, nil
+}
+
+// RemoveOutliers removes the samples flagged by FindOutliers from features matrix and labels vector.
+// It returns the remaining features and labels followed by the removed outliers which can be reported.
+// It fails with error if the number of features does not match the number of labels,
+// if FindOutliers fails or if all samples are outliers.
+func RemoveOutliers(inMx *mat64.Dense, labels *mat64.Vector, method string, threshold float64) (
+	*mat64.Dense, *mat64.Vector, []Outlier, error) {
+	if inMx == nil || labels == nil {
+		return nil, nil, nil, fmt.Errorf("Incorrect data supplied: %v, %v\n", inMx, labels)
+	}
+	samples, _ := inMx.Dims()
+	if samples != labels.Len() {
+		return nil, nil, nil, fmt.Errorf("Samples count mismatch. Features: %d, Labels: %d\n",
+			samples, labels.Len())
+	}
+	outliers, err := FindOutliers(inMx, method, threshold)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(outliers) == samples {
+		return nil, nil, nil, fmt.Errorf("All %d samples are outliers\n", samples)
+	}
+	// keep the samples which have not been flagged
+	idx := make([]int, 0, samples-len(outliers))
+	k := 0
+	for i := 0; i < samples; i++ {
+		if k < len(outliers) && outliers[k].Row == i {
+			k++
+			continue
+		}
+		idx = append(idx, i)
+	}
+	outMx, outLabels := selectRows(inMx, labels, idx)
+	return outMx, outLabels, outliers, nil
+}
@@ -0,0 +1,71 @@
+package dataset
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindOutliers(t *testing.T) {
+	assert := assert.New(t)
+
+	inMx := mat64.NewDense(8, 2, []float64{
+		1, 1,
+		2, 2,
+		3, 3,
+		4, math.NaN(),
+		5, 2,
+		6, 1,
+		7, -50,
+		100, 3,
+	})
+	expected := []Outlier{{Row: 6, Features: []int{1}}, {Row: 7, Features: []int{0}}}
+	for method, threshold := range map[string]float64{"zscore": 2.0, "iqr": 1.5} {
+		outliers, err := FindOutliers(inMx, method, threshold)
+		assert.NoError(err)
+		assert.Equal(expected, outliers, method)
+	}
+	// larger threshold flags fewer samples
+	outliers, err := FindOutliers(inMx, "zscore", 3.0)
+	assert.NoError(err)
+	assert.Len(outliers, 0)
+	// columns without any spread don't have outliers
+	outliers, err = FindOutliers(mat64.NewDense(4, 1, []float64{0, 0, 0, 5}), "iqr", 1.5)
+	assert.NoError(err)
+	assert.Len(outliers, 0)
+	// unsupported method
+	outliers, err = FindOutliers(inMx, "foo", 1.0)
+	assert.Nil(outliers)
+	assert.Error(err)
+	// incorrect threshold
+	outliers, err = FindOutliers(inMx, "iqr", 0.0)
+	assert.Nil(outliers)
+	assert.Error(err)
+	// nil matrix
+	outliers, err = FindOutliers(nil, "iqr", 1.5)
+	assert.Nil(outliers)
+	assert.Error(err)
+}
+
+func TestRemoveOutliers(t *testing.T) {
+	assert := assert.New(t)
+
+	inMx := mat64.NewDense(5, 1, []float64{1, 2, 3, 2, 100})
+	labels := mat64.NewVector(5, []float64{1, 2, 1, 2, 1})
+	outMx, outLabels, outliers, err := RemoveOutliers(inMx, labels, "iqr", 1.5)
+	assert.NoError(err)
+	assert.True(mat64.Equal(mat64.NewDense(4, 1, []float64{1, 2, 3, 2}), outMx))
+	assert.True(mat64.Equal(mat64.NewVector(4, []float64{1, 2, 1, 2}), outLabels))
+	assert.Equal([]Outlier{{Row: 4, Features: []int{0}}}, outliers)
+	// samples count mismatch
+	outMx, outLabels, outliers, err = RemoveOutliers(inMx, mat64.NewVector(2, nil), "iqr", 1.5)
+	assert.Nil(outMx)
+	assert.Nil(outLabels)
+	assert.Nil(outliers)
+	assert.Error(err)
+	// unsupported method
+	_, _, _, err = RemoveOutliers(inMx, labels, "foo", 1.5)
+	assert.Error(err)
+}
@@ -0,0 +1,166 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Transform is a preprocessing step which is fitted on the training features and then
+// transforms any features the same way. Fitted transforms can be saved and loaded back.
+type Transform interface {
+	// Kind returns the kind the transform is registered with
+	Kind() string
+	// Fit fits the transform on features stored in rows of mx
+	Fit(mx mat64.Matrix) error
+	// Transform transforms features stored in rows of mx without modifying mx
+	Transform(mx mat64.Matrix) (*mat64.Dense, error)
+	// Save writes fitted transform to w
+	Save(w io.Writer) error
+	// Load reads fitted transform saved via Save from r
+	Load(r io.Reader) error
+}
+
+// transforms maps transform kinds to functions which create empty transforms
+var transforms = map[string]func() Transform{
+	"scale":  func() Transform { return new(Scaler) },
+	"onehot": func() Transform { return new(OneHotEncoder) },
+	"pca":    func() Transform { return new(PCA) },
+	"select": func() Transform { return new(Selector) },
+}
+
+// NewTransform creates new empty transform of the given kind which can be loaded via Load.
+// It fails with error if the kind is not supported.
+func NewTransform(kind string) (Transform, error) {
+	newTransform, ok := transforms[kind]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported transform: %s\n", kind)
+	}
+	return newTransform(), nil
+}
+
+// Pipeline chains transforms: each step transforms the output of the previous step.
+// Pipeline is saved as JSON, so it can be stored along with the model it preprocesses
+// features for and inference can apply identical preprocessing.
+type Pipeline struct {
+	steps []Transform
+}
+
+// savedStep is a serializable representation of pipeline step
+type savedStep struct {
+	// Kind is the step transform kind
+	Kind string `json:"kind"`
+	// State contains the saved step transform
+	State json.RawMessage `json:"state"`
+}
+
+// NewPipeline creates new pipeline which applies the steps in the order they are passed
+func NewPipeline(steps ...Transform) *Pipeline {
+	return &Pipeline{steps: steps}
+}
+
+// Steps returns pipeline steps
+func (p *Pipeline) Steps() []Transform {
+	return p.steps
+}
+
+// Kind returns pipeline transform kind
+func (p *Pipeline) Kind() string {
+	return "pipeline"
+}
+
+// Fit fits pipeline steps one by one: each step is fitted on the output of the previous
+// fitted step. It fails with error if any of the steps fails to fit or transform.
+func (p *Pipeline) Fit(mx mat64.Matrix) error {
+	if mx == nil {
+		return fmt.Errorf("Can't fit pipeline to %v\n", mx)
+	}
+	for i, step := range p.steps {
+		if err := step.Fit(mx); err != nil {
+			return fmt.Errorf("Step %d (%s) fit failed: %v\n", i+1, step.Kind(), err)
+		}
+		// the last step output is not needed
+		if i == len(p.steps)-1 {
+			break
+		}
+		out, err := step.Transform(mx)
+		if err != nil {
+			return fmt.Errorf("Step %d (%s) transform failed: %v\n", i+1, step.Kind(), err)
+		}
+		mx = out
+	}
+	return nil
+}
+
+// Transform transforms features stored in rows of mx by all pipeline steps. Empty pipeline
+// returns a copy of mx. It fails with error if any of the steps fails to transform.
+func (p *Pipeline) Transform(mx mat64.Matrix) (*mat64.Dense, error) {
+	if mx == nil {
+		return nil, fmt.Errorf("Can't transform %v\n", mx)
+	}
+	out := mat64.DenseCopyOf(mx)
+	for i, step := range p.steps {
+		var err error
+		if out, err = step.Transform(out); err != nil {
+			return nil, fmt.Errorf("Step %d (%s) transform failed: %v\n", i+1, step.Kind(), err)
+		}
+	}
+	return out, nil
+}
+
+// Save writes pipeline steps to w encoded in JSON format
+func (p *Pipeline) Save(w io.Writer) error {
+	data, err := p.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Load reads pipeline saved via Save from r. It replaces all pipeline steps.
+// It fails with error if the pipeline can't be decoded or contains unsupported transforms.
+func (p *Pipeline) Load(r io.Reader) error {
+	var data json.RawMessage
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return err
+	}
+	return p.UnmarshalJSON(data)
+}
+
+// MarshalJSON encodes pipeline steps in JSON format
+func (p *Pipeline) MarshalJSON() ([]byte, error) {
+	saved := make([]savedStep, len(p.steps))
+	for i, step := range p.steps {
+		var buf bytes.Buffer
+		if err := step.Save(&buf); err != nil {
+			return nil, err
+		}
+		saved[i] = savedStep{Kind: step.Kind(), State: bytes.TrimSpace(buf.Bytes())}
+	}
+	return json.Marshal(saved)
+}
+
+// UnmarshalJSON decodes pipeline steps encoded in JSON format
+func (p *Pipeline) UnmarshalJSON(data []byte) error {
+	var saved []savedStep
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	steps := make([]Transform, len(saved))
+	for i, s := range saved {
+		step, err := NewTransform(s.Kind)
+		if err != nil {
+			return err
+		}
+		if err := step.Load(bytes.NewReader(s.State)); err != nil {
+			return fmt.Errorf("Step %d (%s) load failed: %v\n", i+1, s.Kind, err)
+		}
+		steps[i] = step
+	}
+	p.steps = steps
+	return nil
+}
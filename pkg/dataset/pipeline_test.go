@@ -0,0 +1,152 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneHotEncoder(t *testing.T) {
+	assert := assert.New(t)
+
+	mx := mat64.NewDense(3, 3, []float64{
+		2, 0.5, 1,
+		1, 1.5, 3,
+		2, 2.5, 1,
+	})
+	e := NewOneHotEncoder(0, 2)
+	assert.NoError(e.Fit(mx))
+	assert.Equal([][]float64{{1, 2}, {1, 3}}, e.Values)
+	out, err := e.Transform(mx)
+	assert.NoError(err)
+	expected := mat64.NewDense(3, 5, []float64{
+		0, 1, 0.5, 1, 0,
+		1, 0, 1.5, 0, 1,
+		0, 1, 2.5, 1, 0,
+	})
+	assert.True(mat64.Equal(expected, out))
+	// unseen values are encoded as zeros
+	out, err = e.Transform(mat64.NewDense(1, 3, []float64{5, 1, 3}))
+	assert.NoError(err)
+	assert.Equal([]float64{0, 0, 1, 0, 1}, out.RawRowView(0))
+	// dimension mismatch
+	out, err = e.Transform(mat64.NewDense(1, 2, nil))
+	assert.Nil(out)
+	assert.Error(err)
+	// incorrect columns
+	assert.Error(NewOneHotEncoder(3).Fit(mx))
+	assert.Error(NewOneHotEncoder(1, 1).Fit(mx))
+	assert.Error(NewOneHotEncoder(0).Fit(nil))
+}
+
+func TestPCA(t *testing.T) {
+	assert := assert.New(t)
+
+	// samples lie on a line
+	mx := mat64.NewDense(4, 2, []float64{
+		1, 2,
+		2, 4,
+		3, 6,
+		4, 8,
+	})
+	p := NewPCA(1)
+	assert.NoError(p.Fit(mx))
+	assert.Equal([]float64{2.5, 5}, p.Mean)
+	out, err := p.Transform(mx)
+	assert.NoError(err)
+	rows, cols := out.Dims()
+	assert.Equal([2]int{4, 1}, [2]int{rows, cols})
+	// projections preserve distances along the line
+	step := out.At(1, 0) - out.At(0, 0)
+	for i := 1; i < rows; i++ {
+		assert.InDelta(step, out.At(i, 0)-out.At(i-1, 0), 1e-9)
+	}
+	assert.InDelta(5.0, step*step, 1e-9)
+	// incorrect number of components
+	assert.Error(NewPCA(0).Fit(mx))
+	assert.Error(NewPCA(3).Fit(mx))
+	// unfitted PCA
+	out, err = NewPCA(1).Transform(mx)
+	assert.Nil(out)
+	assert.Error(err)
+}
+
+func TestSelector(t *testing.T) {
+	assert := assert.New(t)
+
+	mx := mat64.NewDense(3, 3, []float64{
+		1, 5, 0,
+		2, 5, 10,
+		3, 5, 20,
+	})
+	// explicitly selected columns
+	s := NewSelector(2, 0)
+	assert.NoError(s.Fit(mx))
+	out, err := s.Transform(mx)
+	assert.NoError(err)
+	assert.True(mat64.Equal(mat64.NewDense(3, 2, []float64{0, 1, 10, 2, 20, 3}), out))
+	// constant column is dropped
+	s = NewVarianceSelector(0.0)
+	assert.NoError(s.Fit(mx))
+	assert.Equal([]int{0, 2}, s.Columns)
+	// no column exceeds threshold
+	assert.Error(NewVarianceSelector(1000).Fit(mx))
+	// incorrect column
+	assert.Error(NewSelector(3).Fit(mx))
+	// dimension mismatch
+	out, err = s.Transform(mat64.NewDense(1, 2, nil))
+	assert.Nil(out)
+	assert.Error(err)
+}
+
+func TestPipeline(t *testing.T) {
+	assert := assert.New(t)
+
+	mx := mat64.NewDense(4, 3, []float64{
+		1, 5, 1,
+		2, 5, 2,
+		3, 5, 1,
+		5, 5, 2,
+	})
+	p := NewPipeline(NewOneHotEncoder(2), NewVarianceSelector(0.0), new(Scaler), NewPCA(2))
+	assert.Len(p.Steps(), 4)
+	assert.NoError(p.Fit(mx))
+	out, err := p.Transform(mx)
+	assert.NoError(err)
+	rows, cols := out.Dims()
+	assert.Equal([2]int{4, 2}, [2]int{rows, cols})
+	// saved pipeline transforms features the same way
+	var buf bytes.Buffer
+	assert.NoError(p.Save(&buf))
+	loaded := new(Pipeline)
+	assert.NoError(loaded.Load(&buf))
+	assert.Len(loaded.Steps(), 4)
+	loadedOut, err := loaded.Transform(mx)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(out, loadedOut, 1e-12))
+	// pipeline can be embedded in JSON documents
+	data, err := json.Marshal(struct {
+		Pipeline *Pipeline `json:"pipeline"`
+	}{p})
+	assert.NoError(err)
+	assert.Contains(string(data), `"kind":"onehot"`)
+	// empty pipeline copies features
+	out, err = NewPipeline().Transform(mx)
+	assert.NoError(err)
+	assert.True(mat64.Equal(mx, out))
+	// failing step
+	assert.Error(NewPipeline(NewPCA(5)).Fit(mx))
+	assert.Error(p.Fit(nil))
+	out, err = p.Transform(mat64.NewDense(1, 2, nil))
+	assert.Nil(out)
+	assert.Error(err)
+	// unsupported transform
+	assert.Error(new(Pipeline).Load(strings.NewReader(`[{"kind":"foo","state":{}}]`)))
+	tr, err := NewTransform("foo")
+	assert.Nil(tr)
+	assert.Error(err)
+}
@@ -0,0 +1,43 @@
+package dataset
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Window converts time series stored in rows of series into sliding window samples: one row per
+// time step and one column per series variable. Each sample contains size consecutive time steps
+// of all variables, oldest first, and the last column contains the value of the target variable
+// horizon steps after the last time step of the window. Windows slide by a single time step, so
+// the returned data set contains rows - size - horizon + 1 samples labeled in the last column.
+// It fails with error if size or horizon are not positive, if target is not a series column
+// or if the series is too short to make a single sample.
+func Window(series mat64.Matrix, size, horizon, target int) (*mat64.Dense, error) {
+	if series == nil {
+		return nil, fmt.Errorf("Incorrect series supplied: %v\n", series)
+	}
+	if size <= 0 {
+		return nil, fmt.Errorf("Incorrect window size: %d\n", size)
+	}
+	if horizon <= 0 {
+		return nil, fmt.Errorf("Incorrect forecast horizon: %d\n", horizon)
+	}
+	steps, vars := series.Dims()
+	if target < 0 || target >= vars {
+		return nil, fmt.Errorf("Incorrect target column: %d\n", target)
+	}
+	samples := steps - size - horizon + 1
+	if samples <= 0 {
+		return nil, fmt.Errorf("Series too short. Steps: %d, Window: %d, Horizon: %d\n", steps, size, horizon)
+	}
+	out := mat64.NewDense(samples, size*vars+1, nil)
+	for i := 0; i < samples; i++ {
+		row := out.RawRowView(i)
+		for t := 0; t < size; t++ {
+			mat64.Row(row[t*vars:(t+1)*vars], i+t, series)
+		}
+		row[size*vars] = series.At(i+size-1+horizon, target)
+	}
+	return out, nil
+}
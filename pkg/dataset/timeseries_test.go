@@ -0,0 +1,56 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	// two variables, five time steps
+	series := mat64.NewDense(5, 2, []float64{
+		1, 10,
+		2, 20,
+		3, 30,
+		4, 40,
+		5, 50,
+	})
+	mx, err := Window(series, 2, 1, 1)
+	assert.NoError(err)
+	expected := mat64.NewDense(3, 5, []float64{
+		1, 10, 2, 20, 30,
+		2, 20, 3, 30, 40,
+		3, 30, 4, 40, 50,
+	})
+	assert.True(mat64.Equal(expected, mx))
+	// longer horizon forecasts further ahead
+	mx, err = Window(series, 3, 2, 0)
+	assert.NoError(err)
+	expected = mat64.NewDense(1, 7, []float64{1, 10, 2, 20, 3, 30, 5})
+	assert.True(mat64.Equal(expected, mx))
+	// windowed samples are labeled in the last column
+	ds := &DataSet{mx: mx, labeled: true}
+	rows, cols := ds.Features().Dims()
+	assert.Equal([2]int{1, 6}, [2]int{rows, cols})
+
+	// incorrect parameters
+	testCases := []struct {
+		series                mat64.Matrix
+		size, horizon, target int
+	}{
+		{nil, 1, 1, 0},
+		{series, 0, 1, 0},
+		{series, 1, 0, 0},
+		{series, 1, 1, -1},
+		{series, 1, 1, 2},
+		{series, 4, 2, 0},
+	}
+	for _, tc := range testCases {
+		mx, err := Window(tc.series, tc.size, tc.horizon, tc.target)
+		assert.Nil(mx)
+		assert.Error(err)
+	}
+}
@@ -0,0 +1,301 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// Kind returns scaler transform kind
+func (s *Scaler) Kind() string {
+	return "scale"
+}
+
+// Fit computes the mean and standard deviation of every column of mx
+func (s *Scaler) Fit(mx mat64.Matrix) error {
+	return (*matrix.Standardizer)(s).Fit(mx)
+}
+
+// Transform scales the columns of mx the same way as Scale does
+func (s *Scaler) Transform(mx mat64.Matrix) (*mat64.Dense, error) {
+	return (*matrix.Standardizer)(s).Transform(mx)
+}
+
+// Save writes scaler to w encoded in JSON format
+func (s *Scaler) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(s)
+}
+
+// Load reads scaler saved via Save from r
+func (s *Scaler) Load(r io.Reader) error {
+	return json.NewDecoder(r).Decode(s)
+}
+
+// OneHotEncoder encodes categorical features stored as numeric codes into indicator columns:
+// every encoded column is replaced by one column per distinct value seen by Fit, which contains 1
+// for the samples with the value and 0 otherwise. Values not seen by Fit are encoded as all zeros.
+type OneHotEncoder struct {
+	// Columns contains indices of the encoded columns
+	Columns []int `json:"columns"`
+	// Inputs is the number of columns of the fitted features
+	Inputs int `json:"inputs"`
+	// Values contains sorted distinct values of every encoded column
+	Values [][]float64 `json:"values"`
+}
+
+// NewOneHotEncoder creates new encoder which encodes the given columns
+func NewOneHotEncoder(columns ...int) *OneHotEncoder {
+	return &OneHotEncoder{Columns: columns}
+}
+
+// Kind returns encoder transform kind
+func (e *OneHotEncoder) Kind() string {
+	return "onehot"
+}
+
+// Fit collects distinct values of the encoded columns of mx.
+// It fails with error if any of the encoded columns is not a column of mx.
+func (e *OneHotEncoder) Fit(mx mat64.Matrix) error {
+	if mx == nil {
+		return fmt.Errorf("Can't fit matrix: %v\n", mx)
+	}
+	rows, cols := mx.Dims()
+	if err := checkColumns(e.Columns, cols); err != nil {
+		return err
+	}
+	e.Inputs = cols
+	e.Values = make([][]float64, len(e.Columns))
+	for k, j := range e.Columns {
+		seen := make(map[float64]bool)
+		for i := 0; i < rows; i++ {
+			if v := mx.At(i, j); !seen[v] {
+				seen[v] = true
+				e.Values[k] = append(e.Values[k], v)
+			}
+		}
+		sort.Float64s(e.Values[k])
+	}
+	return nil
+}
+
+// Transform replaces the encoded columns of mx by indicator columns placed at their position.
+// It fails with error if the encoder has not been fitted or if mx has different number of columns.
+func (e *OneHotEncoder) Transform(mx mat64.Matrix) (*mat64.Dense, error) {
+	if mx == nil {
+		return nil, fmt.Errorf("Can't transform matrix: %v\n", mx)
+	}
+	rows, cols := mx.Dims()
+	if len(e.Values) != len(e.Columns) || cols != e.Inputs {
+		return nil, fmt.Errorf("Dimension mismatch. Encoder: %d, Matrix: %d\n", e.Inputs, cols)
+	}
+	encoded := make(map[int][]float64, len(e.Columns))
+	outCols := cols
+	for k, j := range e.Columns {
+		encoded[j] = e.Values[k]
+		outCols += len(e.Values[k]) - 1
+	}
+	out := mat64.NewDense(rows, outCols, nil)
+	for i := 0; i < rows; i++ {
+		row := out.RawRowView(i)
+		pos := 0
+		for j := 0; j < cols; j++ {
+			v := mx.At(i, j)
+			values, ok := encoded[j]
+			if !ok {
+				row[pos] = v
+				pos++
+				continue
+			}
+			if k := sort.SearchFloat64s(values, v); k < len(values) && values[k] == v {
+				row[pos+k] = 1.0
+			}
+			pos += len(values)
+		}
+	}
+	return out, nil
+}
+
+// Save writes encoder to w encoded in JSON format
+func (e *OneHotEncoder) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(e)
+}
+
+// Load reads encoder saved via Save from r
+func (e *OneHotEncoder) Load(r io.Reader) error {
+	return json.NewDecoder(r).Decode(e)
+}
+
+// PCA projects features onto their principal components with the largest variance
+type PCA struct {
+	// Components is the number of principal components features are projected onto
+	Components int `json:"components"`
+	// Mean contains column mean values of the fitted features
+	Mean []float64 `json:"mean"`
+	// Vectors contains principal component direction vectors stored in columns row by row
+	Vectors []float64 `json:"vectors"`
+}
+
+// NewPCA creates new PCA which projects features onto the given number of principal components
+func NewPCA(components int) *PCA {
+	return &PCA{Components: components}
+}
+
+// Kind returns PCA transform kind
+func (p *PCA) Kind() string {
+	return "pca"
+}
+
+// Fit computes principal components of the features stored in rows of mx. It fails with error
+// if the number of components is not positive or if it exceeds the number of columns or rows of mx.
+func (p *PCA) Fit(mx mat64.Matrix) error {
+	if mx == nil {
+		return fmt.Errorf("Can't fit matrix: %v\n", mx)
+	}
+	rows, cols := mx.Dims()
+	if p.Components <= 0 || p.Components > cols || p.Components > rows {
+		return fmt.Errorf("Incorrect number of components: %d\n", p.Components)
+	}
+	vecs, _, ok := stat.PrincipalComponents(mx, nil)
+	if !ok {
+		return fmt.Errorf("Principal components decomposition failed\n")
+	}
+	col := make([]float64, rows)
+	p.Mean = make([]float64, cols)
+	for j := range p.Mean {
+		mat64.Col(col, j, mx)
+		p.Mean[j] = stat.Mean(col, nil)
+	}
+	p.Vectors = make([]float64, 0, cols*p.Components)
+	for j := 0; j < cols; j++ {
+		p.Vectors = append(p.Vectors, vecs.RawRowView(j)[:p.Components]...)
+	}
+	return nil
+}
+
+// Transform centers the features stored in rows of mx and projects them onto principal components.
+// It fails with error if PCA has not been fitted or if mx has different number of columns.
+func (p *PCA) Transform(mx mat64.Matrix) (*mat64.Dense, error) {
+	if mx == nil {
+		return nil, fmt.Errorf("Can't transform matrix: %v\n", mx)
+	}
+	_, cols := mx.Dims()
+	if cols != len(p.Mean) || p.Components <= 0 || len(p.Vectors) != cols*p.Components {
+		return nil, fmt.Errorf("Dimension mismatch. PCA: %d, Matrix: %d\n", len(p.Mean), cols)
+	}
+	centered := mat64.DenseCopyOf(mx)
+	centered.Apply(func(i, j int, x float64) float64 {
+		return x - p.Mean[j]
+	}, centered)
+	out := new(mat64.Dense)
+	out.Mul(centered, mat64.NewDense(cols, p.Components, p.Vectors))
+	return out, nil
+}
+
+// Save writes PCA to w encoded in JSON format
+func (p *PCA) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(p)
+}
+
+// Load reads PCA saved via Save from r
+func (p *PCA) Load(r io.Reader) error {
+	return json.NewDecoder(r).Decode(p)
+}
+
+// Selector keeps selected feature columns and drops the others. The columns are either selected
+// explicitly or by Fit which selects the columns whose variance exceeds the threshold.
+type Selector struct {
+	// Threshold is the variance the selected columns must exceed if no columns are selected explicitly
+	Threshold float64 `json:"threshold"`
+	// Columns contains indices of the selected columns
+	Columns []int `json:"columns"`
+	// Inputs is the number of columns of the fitted features
+	Inputs int `json:"inputs"`
+}
+
+// NewSelector creates new selector which keeps the given columns
+func NewSelector(columns ...int) *Selector {
+	return &Selector{Columns: columns}
+}
+
+// NewVarianceSelector creates new selector which keeps the columns whose variance exceeds threshold
+func NewVarianceSelector(threshold float64) *Selector {
+	return &Selector{Threshold: threshold}
+}
+
+// Kind returns selector transform kind
+func (s *Selector) Kind() string {
+	return "select"
+}
+
+// Fit selects the columns of mx whose variance exceeds the threshold unless the columns have been
+// selected explicitly. It fails with error if any of the selected columns is not a column of mx
+// or if no column is selected.
+func (s *Selector) Fit(mx mat64.Matrix) error {
+	if mx == nil {
+		return fmt.Errorf("Can't fit matrix: %v\n", mx)
+	}
+	rows, cols := mx.Dims()
+	if len(s.Columns) == 0 {
+		col := make([]float64, rows)
+		for j := 0; j < cols; j++ {
+			mat64.Col(col, j, mx)
+			if stat.Variance(col, nil) > s.Threshold {
+				s.Columns = append(s.Columns, j)
+			}
+		}
+	}
+	if len(s.Columns) == 0 {
+		return fmt.Errorf("No column exceeds variance threshold: %f\n", s.Threshold)
+	}
+	if err := checkColumns(s.Columns, cols); err != nil {
+		return err
+	}
+	s.Inputs = cols
+	return nil
+}
+
+// Transform returns the selected columns of mx.
+// It fails with error if the selector has not been fitted or if mx has different number of columns.
+func (s *Selector) Transform(mx mat64.Matrix) (*mat64.Dense, error) {
+	if mx == nil {
+		return nil, fmt.Errorf("Can't transform matrix: %v\n", mx)
+	}
+	rows, cols := mx.Dims()
+	if len(s.Columns) == 0 || cols != s.Inputs {
+		return nil, fmt.Errorf("Dimension mismatch. Selector: %d, Matrix: %d\n", s.Inputs, cols)
+	}
+	out := mat64.NewDense(rows, len(s.Columns), nil)
+	for i := 0; i < rows; i++ {
+		for k, j := range s.Columns {
+			out.Set(i, k, mx.At(i, j))
+		}
+	}
+	return out, nil
+}
+
+// Save writes selector to w encoded in JSON format
+func (s *Selector) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(s)
+}
+
+// Load reads selector saved via Save from r
+func (s *Selector) Load(r io.Reader) error {
+	return json.NewDecoder(r).Decode(s)
+}
+
+// checkColumns checks if all columns are distinct column indices of a matrix with cols columns
+func checkColumns(columns []int, cols int) error {
+	seen := make(map[int]bool, len(columns))
+	for _, j := range columns {
+		if j < 0 || j >= cols || seen[j] {
+			return fmt.Errorf("Incorrect column: %d\n", j)
+		}
+		seen[j] = true
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// Bin is a reliability diagram bin
+type Bin struct {
+	// Lower is the lower bound of bin confidence interval
+	Lower float64
+	// Upper is the upper bound of bin confidence interval
+	Upper float64
+	// Count is the number of samples whose confidence falls into the bin
+	Count int
+	// Confidence is the mean confidence of samples in the bin
+	Confidence float64
+	// Accuracy is the fraction of successfully classified samples in the bin
+	Accuracy float64
+}
+
+// Calibration classifies features using the network passed in as parameter and calculates
+// Brier score and reliability bins of the classification probabilities. See BrierScore and
+// ReliabilityBins for more details. It fails with error if the classification fails.
+func Calibration(net *neural.Network, features *mat64.Dense, labels *mat64.Vector,
+	bins int) (float64, []Bin, error) {
+	if net == nil {
+		return 0.0, nil, fmt.Errorf("Invalid neural network supplied: %v\n", net)
+	}
+	if features == nil {
+		return 0.0, nil, fmt.Errorf("Invalid features supplied: %v\n", features)
+	}
+	classMx, err := net.Classify(features)
+	if err != nil {
+		return 0.0, nil, err
+	}
+	// Classify returns percentages
+	probsMx := new(mat64.Dense)
+	probsMx.Scale(1/100.0, classMx)
+	brier, err := BrierScore(probsMx, labels)
+	if err != nil {
+		return 0.0, nil, err
+	}
+	rel, err := ReliabilityBins(probsMx, labels, bins)
+	if err != nil {
+		return 0.0, nil, err
+	}
+	return brier, rel, nil
+}
+
+// BrierScore calculates multi-class Brier score: mean squared difference between the predicted
+// class probabilities and the one-of-N encoded labels. Probabilities matrix contains class
+// probabilities of each sample in its rows. Labels are expected to start at 1.
+// It fails with error if either of the parameters is nil or if they have different dimensions.
+func BrierScore(probsMx mat64.Matrix, labels *mat64.Vector) (float64, error) {
+	if err := checkProbs(probsMx, labels); err != nil {
+		return 0.0, err
+	}
+	rows, cols := probsMx.Dims()
+	score := 0.0
+	for i := 0; i < rows; i++ {
+		label := int(labels.At(i, 0))
+		for j := 0; j < cols; j++ {
+			exp := 0.0
+			if j+1 == label {
+				exp = 1.0
+			}
+			diff := probsMx.At(i, j) - exp
+			score += diff * diff
+		}
+	}
+	return score / float64(rows), nil
+}
+
+// ReliabilityBins splits the [0,1] confidence interval into the requested number of equally sized
+// bins. Confidence of a sample is its highest class probability. For every bin it calculates
+// the mean confidence and the accuracy of the samples whose confidence falls into the bin.
+// Network probabilities are well calibrated if the bin confidences match bin accuracies.
+// It fails with error if the number of bins is not positive or if the supplied data is invalid.
+func ReliabilityBins(probsMx mat64.Matrix, labels *mat64.Vector, bins int) ([]Bin, error) {
+	if bins <= 0 {
+		return nil, fmt.Errorf("Incorrect number of bins: %d\n", bins)
+	}
+	if err := checkProbs(probsMx, labels); err != nil {
+		return nil, err
+	}
+	rel := make([]Bin, bins)
+	for i := range rel {
+		rel[i].Lower = float64(i) / float64(bins)
+		rel[i].Upper = float64(i+1) / float64(bins)
+	}
+	for i, maxIdx := range matrix.RowsArgMax(probsMx) {
+		conf := probsMx.At(i, maxIdx)
+		b := int(conf * float64(bins))
+		// confidence of 1.0 belongs to the last bin
+		if b >= bins {
+			b = bins - 1
+		}
+		if b < 0 {
+			b = 0
+		}
+		rel[b].Count++
+		rel[b].Confidence += conf
+		if maxIdx+1 == int(labels.At(i, 0)) {
+			rel[b].Accuracy++
+		}
+	}
+	for i := range rel {
+		if rel[i].Count > 0 {
+			rel[i].Confidence /= float64(rel[i].Count)
+			rel[i].Accuracy /= float64(rel[i].Count)
+		}
+	}
+	return rel, nil
+}
+
+// ExpectedCalibrationError calculates expected calibration error from reliability bins:
+// weighted average of absolute differences between bin accuracy and confidence.
+func ExpectedCalibrationError(bins []Bin) float64 {
+	total, ece := 0, 0.0
+	for _, b := range bins {
+		diff := b.Accuracy - b.Confidence
+		if diff < 0 {
+			diff = -diff
+		}
+		ece += float64(b.Count) * diff
+		total += b.Count
+	}
+	if total == 0 {
+		return 0.0
+	}
+	return ece / float64(total)
+}
+
+// checkProbs checks if probabilities matrix and labels are valid
+func checkProbs(probsMx mat64.Matrix, labels *mat64.Vector) error {
+	if probsMx == nil || labels == nil {
+		return fmt.Errorf("Can't evaluate. Probs: %v, Labels: %v\n", probsMx, labels)
+	}
+	rows, _ := probsMx.Dims()
+	if rows != labels.Len() {
+		return fmt.Errorf("Samples count mismatch. Probs: %d, Labels: %d\n", rows, labels.Len())
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrierScore(t *testing.T) {
+	assert := assert.New(t)
+	probsMx := mat64.NewDense(2, 2, []float64{
+		1.0, 0.0,
+		0.5, 0.5,
+	})
+	tstLabels := mat64.NewVector(2, []float64{1.0, 2.0})
+	score, err := BrierScore(probsMx, tstLabels)
+	assert.NoError(err)
+	assert.InDelta(0.25, score, 0.0001)
+	// nil probabilities
+	_, err = BrierScore(nil, tstLabels)
+	assert.Error(err)
+	// mismatched samples count
+	_, err = BrierScore(probsMx, mat64.NewVector(1, []float64{1.0}))
+	assert.Error(err)
+}
+
+func TestReliabilityBins(t *testing.T) {
+	assert := assert.New(t)
+	probsMx := mat64.NewDense(4, 2, []float64{
+		1.0, 0.0,
+		0.9, 0.1,
+		0.4, 0.6,
+		0.3, 0.7,
+	})
+	tstLabels := mat64.NewVector(4, []float64{1.0, 2.0, 2.0, 2.0})
+	bins, err := ReliabilityBins(probsMx, tstLabels, 2)
+	assert.NoError(err)
+	assert.Len(bins, 2)
+	// all confidences are above 0.5
+	assert.Equal(0, bins[0].Count)
+	assert.Equal(4, bins[1].Count)
+	assert.InDelta(0.8, bins[1].Confidence, 0.0001)
+	assert.InDelta(0.75, bins[1].Accuracy, 0.0001)
+	assert.InDelta(0.05, ExpectedCalibrationError(bins), 0.0001)
+	// incorrect number of bins
+	_, err = ReliabilityBins(probsMx, tstLabels, 0)
+	assert.Error(err)
+	// no samples
+	assert.Equal(0.0, ExpectedCalibrationError(nil))
+}
+
+func TestCalibration(t *testing.T) {
+	assert := assert.New(t)
+	net := makeTestNet(t)
+	brier, bins, err := Calibration(net, features, labels, 10)
+	assert.NoError(err)
+	assert.True(brier > 0.0)
+	assert.Len(bins, 10)
+	// nil network
+	_, _, err = Calibration(nil, features, labels, 10)
+	assert.Error(err)
+	// nil features
+	_, _, err = Calibration(net, nil, labels, 10)
+	assert.Error(err)
+}
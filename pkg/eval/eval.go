@@ -0,0 +1,85 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+)
+
+// ConfusionMatrix runs forward propagation of features through neural network passed in as
+// parameter and compares the network classification results with the expected labels.
+// It returns NxN confusion matrix where N is the number of network output classes.
+// Rows of the confusion matrix correspond to the expected labels, columns correspond to the
+// labels predicted by the network i.e. element (i, j) contains the number of samples labeled
+// as class i+1 which the network classified as class j+1.
+// It fails with error if any of the parameters is nil, if the number of features does not
+// match the number of labels or if any of the labels is outside the range of network classes.
+func ConfusionMatrix(net *neural.Network, features *mat64.Dense, labels *mat64.Vector) (*mat64.Dense, error) {
+	if net == nil {
+		return nil, fmt.Errorf("Invalid neural network supplied: %v\n", net)
+	}
+	predicted, classes, err := predictLabels(net, features, labels)
+	if err != nil {
+		return nil, err
+	}
+	confMx := mat64.NewDense(classes, classes, nil)
+	for i, p := range predicted {
+		l := int(labels.At(i, 0))
+		if l <= 0 || l > classes {
+			return nil, fmt.Errorf("Incorrect label: %d\n", l)
+		}
+		confMx.Set(l-1, p-1, confMx.At(l-1, p-1)+1)
+	}
+	return confMx, nil
+}
+
+// WriteConfusionMatrix writes confusion matrix passed in as parameter to w
+// in a human readable table format: expected classes in rows, predicted classes in columns
+func WriteConfusionMatrix(w io.Writer, confMx mat64.Matrix) error {
+	if confMx == nil {
+		return fmt.Errorf("Invalid confusion matrix supplied: %v\n", confMx)
+	}
+	rows, cols := confMx.Dims()
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', tabwriter.AlignRight)
+	// table header contains predicted classes
+	fmt.Fprint(tw, "true\\pred\t")
+	for j := 0; j < cols; j++ {
+		fmt.Fprintf(tw, "%d\t", j+1)
+	}
+	fmt.Fprintln(tw)
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(tw, "%d\t", i+1)
+		for j := 0; j < cols; j++ {
+			fmt.Fprintf(tw, "%d\t", int(confMx.At(i, j)))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// predictLabels runs forward propagation of features through the network and returns a slice of
+// predicted labels along with the number of network output classes. Labels start at 1.
+func predictLabels(net *neural.Network, features *mat64.Dense, labels *mat64.Vector) ([]int, int, error) {
+	if features == nil || labels == nil {
+		return nil, 0, fmt.Errorf("Can't evaluate data set. In: %v, Out: %v\n", features, labels)
+	}
+	samples, _ := features.Dims()
+	if samples != labels.Len() {
+		return nil, 0, fmt.Errorf("Samples count mismatch. Features: %d, Labels: %d\n",
+			samples, labels.Len())
+	}
+	predLabels, _, err := net.Predict(features)
+	if err != nil {
+		return nil, 0, err
+	}
+	layers := net.Layers()
+	classes, _ := layers[len(layers)-1].WeightsView().Dims()
+	predicted := make([]int, samples)
+	for i := range predicted {
+		predicted[i] = int(predLabels.At(i, 0))
+	}
+	return predicted, classes, nil
+}
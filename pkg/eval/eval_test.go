@@ -0,0 +1,98 @@
+package eval
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	features = mat64.NewDense(4, 2, []float64{
+		1.0, 0.0,
+		0.0, 1.0,
+		1.0, 0.0,
+		0.0, 1.0,
+	})
+	labels = mat64.NewVector(4, []float64{1.0, 2.0, 2.0, 2.0})
+)
+
+// makeTestNet creates a simple 2 class network which classifies
+// its input to the class with the largest feature value
+func makeTestNet(t *testing.T) *neural.Network {
+	c := &config.NetConfig{
+		Kind: "feedfwd",
+		Arch: &config.NetArch{
+			Input: &config.LayerConfig{
+				Kind: "input",
+				Size: 2,
+			},
+			Output: &config.LayerConfig{
+				Kind: "output",
+				Size: 2,
+				NeurFn: &config.NeuronConfig{
+					Activation: "softmax",
+				},
+			},
+		},
+	}
+	net, err := neural.NewNetwork(c)
+	assert.NoError(t, err)
+	weights := mat64.NewDense(2, 3, []float64{
+		0.0, 1.0, 0.0,
+		0.0, 0.0, 1.0,
+	})
+	assert.NoError(t, net.Layers()[1].SetWeights(weights))
+	return net
+}
+
+func TestConfusionMatrix(t *testing.T) {
+	assert := assert.New(t)
+	net := makeTestNet(t)
+	// correct confusion matrix
+	expMx := mat64.NewDense(2, 2, []float64{
+		1.0, 0.0,
+		1.0, 2.0,
+	})
+	confMx, err := ConfusionMatrix(net, features, labels)
+	assert.NoError(err)
+	assert.True(mat64.Equal(expMx, confMx))
+	// nil network
+	confMx, err = ConfusionMatrix(nil, features, labels)
+	assert.Error(err)
+	assert.Nil(confMx)
+	// nil features
+	confMx, err = ConfusionMatrix(net, nil, labels)
+	assert.Error(err)
+	assert.Nil(confMx)
+	// mismatched samples count
+	confMx, err = ConfusionMatrix(net, features, mat64.NewVector(2, []float64{1.0, 2.0}))
+	assert.Error(err)
+	assert.Nil(confMx)
+	// incorrect label
+	confMx, err = ConfusionMatrix(net, features, mat64.NewVector(4, []float64{1.0, 2.0, 3.0, 1.0}))
+	assert.Error(err)
+	assert.Nil(confMx)
+}
+
+func TestWriteConfusionMatrix(t *testing.T) {
+	assert := assert.New(t)
+	confMx := mat64.NewDense(2, 2, []float64{
+		1.0, 0.0,
+		1.0, 2.0,
+	})
+	var buf bytes.Buffer
+	err := WriteConfusionMatrix(&buf, confMx)
+	assert.NoError(err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(lines, 3)
+	assert.Equal([]string{"true\\pred", "1", "2"}, strings.Fields(lines[0]))
+	assert.Equal([]string{"2", "1", "2"}, strings.Fields(lines[2]))
+	// nil matrix
+	err = WriteConfusionMatrix(&buf, nil)
+	assert.Error(err)
+}
@@ -0,0 +1,79 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+)
+
+// Evaluator evaluates neural network on data set supplied in batches.
+// It accumulates classification results of all the batches added to it so that
+// large data sets can be evaluated without loading them into memory in one shot.
+type Evaluator struct {
+	net     *neural.Network
+	confMx  *mat64.Dense
+	samples int
+}
+
+// NewEvaluator creates new network evaluator and returns it.
+// It fails with error if the supplied network is nil.
+func NewEvaluator(net *neural.Network) (*Evaluator, error) {
+	if net == nil {
+		return nil, fmt.Errorf("Invalid neural network supplied: %v\n", net)
+	}
+	layers := net.Layers()
+	classes, _ := layers[len(layers)-1].WeightsView().Dims()
+	return &Evaluator{
+		net:    net,
+		confMx: mat64.NewDense(classes, classes, nil),
+	}, nil
+}
+
+// Add evaluates the network on the batch of samples passed in as parameter and
+// accumulates the results. It fails with error if the batch evaluation fails in which
+// case the results accumulated so far remain unchanged.
+func (e *Evaluator) Add(features *mat64.Dense, labels *mat64.Vector) error {
+	confMx, err := ConfusionMatrix(e.net, features, labels)
+	if err != nil {
+		return err
+	}
+	e.confMx.Add(e.confMx, confMx)
+	e.samples += labels.Len()
+	return nil
+}
+
+// Samples returns the number of evaluated samples
+func (e *Evaluator) Samples() int {
+	return e.samples
+}
+
+// Accuracy returns the percentage of successfully classified samples
+func (e *Evaluator) Accuracy() float64 {
+	if e.samples == 0 {
+		return 0.0
+	}
+	hits := 0.0
+	rows, _ := e.confMx.Dims()
+	for i := 0; i < rows; i++ {
+		hits += e.confMx.At(i, i)
+	}
+	return (hits / float64(e.samples)) * 100
+}
+
+// ConfusionMatrix returns a copy of the accumulated confusion matrix
+func (e *Evaluator) ConfusionMatrix() *mat64.Dense {
+	return mat64.DenseCopyOf(e.confMx)
+}
+
+// Report returns classification report of all evaluated samples.
+// It fails with error if no samples have been evaluated yet.
+func (e *Evaluator) Report() (*Report, error) {
+	return NewReport(e.confMx)
+}
+
+// Reset discards all the accumulated results
+func (e *Evaluator) Reset() {
+	e.confMx.Scale(0.0, e.confMx)
+	e.samples = 0
+}
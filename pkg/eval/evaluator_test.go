@@ -0,0 +1,46 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluator(t *testing.T) {
+	assert := assert.New(t)
+	net := makeTestNet(t)
+	// nil network
+	e, err := NewEvaluator(nil)
+	assert.Nil(e)
+	assert.Error(err)
+	// correct network
+	e, err = NewEvaluator(net)
+	assert.NotNil(e)
+	assert.NoError(err)
+	assert.Equal(0.0, e.Accuracy())
+	r, err := e.Report()
+	assert.Nil(r)
+	assert.Error(err)
+	// add data set in two batches
+	for i := 0; i < 2; i++ {
+		batchFeats := features.View(2*i, 0, 2, 2).(*mat64.Dense)
+		batchLabels := mat64.NewVector(2, []float64{labels.At(2*i, 0), labels.At(2*i+1, 0)})
+		assert.NoError(e.Add(batchFeats, batchLabels))
+	}
+	// invalid batch leaves the results untouched
+	assert.Error(e.Add(features, nil))
+	assert.Equal(4, e.Samples())
+	assert.InDelta(75.0, e.Accuracy(), 0.0001)
+	// results must be the same as evaluating the whole data set at once
+	confMx, err := ConfusionMatrix(net, features, labels)
+	assert.NoError(err)
+	assert.True(mat64.Equal(confMx, e.ConfusionMatrix()))
+	r, err = e.Report()
+	assert.NoError(err)
+	assert.InDelta(75.0, r.Accuracy, 0.0001)
+	// reset the evaluator
+	e.Reset()
+	assert.Equal(0, e.Samples())
+	assert.Equal(0.0, mat64.Sum(e.ConfusionMatrix()))
+}
@@ -0,0 +1,56 @@
+package eval
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+)
+
+// Misclassification contains details about a misclassified data sample
+type Misclassification struct {
+	// Row is the index of the sample row in the features matrix
+	Row int
+	// Label is the expected sample label
+	Label int
+	// Predicted is the label predicted by the network
+	Predicted int
+	// Confidence is the probability of the sample belonging to Predicted label class
+	Confidence float64
+}
+
+// Misclassified classifies features using the network passed in as parameter and returns
+// details about all the samples whose predicted labels differ from the expected labels.
+// It fails with error if any of the parameters is nil, if the number of features does not
+// match the number of labels or if the network classification fails.
+func Misclassified(net *neural.Network, features *mat64.Dense, labels *mat64.Vector) ([]Misclassification, error) {
+	if net == nil {
+		return nil, fmt.Errorf("Invalid neural network supplied: %v\n", net)
+	}
+	if features == nil || labels == nil {
+		return nil, fmt.Errorf("Can't evaluate data set. In: %v, Out: %v\n", features, labels)
+	}
+	samples, _ := features.Dims()
+	if samples != labels.Len() {
+		return nil, fmt.Errorf("Samples count mismatch. Features: %d, Labels: %d\n",
+			samples, labels.Len())
+	}
+	predicted, probs, err := net.Predict(features)
+	if err != nil {
+		return nil, err
+	}
+	var misses []Misclassification
+	for i := 0; i < samples; i++ {
+		label, pred := int(labels.At(i, 0)), int(predicted.At(i, 0))
+		if label == pred {
+			continue
+		}
+		misses = append(misses, Misclassification{
+			Row:        i,
+			Label:      label,
+			Predicted:  pred,
+			Confidence: probs.At(i, 0),
+		})
+	}
+	return misses, nil
+}
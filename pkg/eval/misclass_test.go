@@ -0,0 +1,33 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMisclassified(t *testing.T) {
+	assert := assert.New(t)
+	net := makeTestNet(t)
+	misses, err := Misclassified(net, features, labels)
+	assert.NoError(err)
+	assert.Len(misses, 1)
+	// third sample is labeled as 2, but classified as 1
+	assert.Equal(2, misses[0].Row)
+	assert.Equal(2, misses[0].Label)
+	assert.Equal(1, misses[0].Predicted)
+	assert.True(misses[0].Confidence > 0.5)
+	// nil network
+	misses, err = Misclassified(nil, features, labels)
+	assert.Nil(misses)
+	assert.Error(err)
+	// nil labels
+	misses, err = Misclassified(net, features, nil)
+	assert.Nil(misses)
+	assert.Error(err)
+	// mismatched samples count
+	misses, err = Misclassified(net, features, mat64.NewVector(1, []float64{1.0}))
+	assert.Nil(misses)
+	assert.Error(err)
+}
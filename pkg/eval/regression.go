@@ -0,0 +1,92 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// RMSE calculates root mean squared error of the network output with regards to expected values.
+// Output and expected values are supplied as matrices with samples stored in rows.
+// It fails with error if either of the matrices is nil or if their dimensions don't match.
+func RMSE(outMx, expMx mat64.Matrix) (float64, error) {
+	if err := checkRegDims(outMx, expMx); err != nil {
+		return 0.0, err
+	}
+	rows, cols := outMx.Dims()
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			diff := outMx.At(i, j) - expMx.At(i, j)
+			sum += diff * diff
+		}
+	}
+	return math.Sqrt(sum / float64(rows*cols)), nil
+}
+
+// MAE calculates mean absolute error of the network output with regards to expected values.
+// Output and expected values are supplied as matrices with samples stored in rows.
+// It fails with error if either of the matrices is nil or if their dimensions don't match.
+func MAE(outMx, expMx mat64.Matrix) (float64, error) {
+	if err := checkRegDims(outMx, expMx); err != nil {
+		return 0.0, err
+	}
+	rows, cols := outMx.Dims()
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			sum += math.Abs(outMx.At(i, j) - expMx.At(i, j))
+		}
+	}
+	return sum / float64(rows*cols), nil
+}
+
+// R2 calculates coefficient of determination (R squared) of the network output with regards
+// to expected values. If there are multiple outputs, i.e. matrices have more than one column,
+// R2 returns the average of R squared values calculated for each output.
+// It fails with error if either of the matrices is nil or if their dimensions don't match.
+func R2(outMx, expMx mat64.Matrix) (float64, error) {
+	if err := checkRegDims(outMx, expMx); err != nil {
+		return 0.0, err
+	}
+	rows, cols := outMx.Dims()
+	r2 := 0.0
+	for j := 0; j < cols; j++ {
+		// mean of expected values
+		mean := 0.0
+		for i := 0; i < rows; i++ {
+			mean += expMx.At(i, j)
+		}
+		mean /= float64(rows)
+		// residual and total sum of squares
+		ssRes, ssTot := 0.0, 0.0
+		for i := 0; i < rows; i++ {
+			res := expMx.At(i, j) - outMx.At(i, j)
+			tot := expMx.At(i, j) - mean
+			ssRes += res * res
+			ssTot += tot * tot
+		}
+		switch {
+		case ssTot != 0:
+			r2 += 1 - ssRes/ssTot
+		case ssRes == 0:
+			// constant expected values predicted perfectly
+			r2++
+		}
+	}
+	return r2 / float64(cols), nil
+}
+
+// checkRegDims checks if output and expected matrices are valid and have the same dimensions
+func checkRegDims(outMx, expMx mat64.Matrix) error {
+	if outMx == nil || expMx == nil {
+		return fmt.Errorf("Can't evaluate. Out: %v, Expected: %v\n", outMx, expMx)
+	}
+	or, oc := outMx.Dims()
+	er, ec := expMx.Dims()
+	if or != er || oc != ec {
+		return fmt.Errorf("Dimension mismatch. Out: %d x %d Expected: %d x %d\n", or, oc, er, ec)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package eval
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegressionMetrics(t *testing.T) {
+	assert := assert.New(t)
+	delta := 0.0001
+	outMx := mat64.NewDense(4, 1, []float64{2.5, 0.0, 2.0, 8.0})
+	expMx := mat64.NewDense(4, 1, []float64{3.0, -0.5, 2.0, 7.0})
+	// test cases
+	testCases := []struct {
+		metric   func(mat64.Matrix, mat64.Matrix) (float64, error)
+		expected float64
+		perfect  float64
+	}{
+		{RMSE, 0.612372, 0.0},
+		{MAE, 0.5, 0.0},
+		{R2, 0.948608, 1.0},
+	}
+	for _, tc := range testCases {
+		val, err := tc.metric(outMx, expMx)
+		assert.NoError(err)
+		assert.InDelta(tc.expected, val, delta)
+		// perfect predictions
+		val, err = tc.metric(expMx, expMx)
+		assert.NoError(err)
+		assert.InDelta(tc.perfect, val, delta)
+		// nil matrix
+		_, err = tc.metric(nil, expMx)
+		assert.Error(err)
+		// dimension mismatch
+		_, err = tc.metric(mat64.NewDense(2, 2, nil), expMx)
+		assert.Error(err)
+	}
+}
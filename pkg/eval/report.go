@@ -0,0 +1,120 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+)
+
+// ClassMetrics contains classification metrics of a particular class
+type ClassMetrics struct {
+	// Class is class label
+	Class int `json:"class"`
+	// Precision is a fraction of samples classified as Class which are labeled as Class
+	Precision float64 `json:"precision"`
+	// Recall is a fraction of samples labeled as Class which are classified as Class
+	Recall float64 `json:"recall"`
+	// F1 is harmonic mean of Precision and Recall
+	F1 float64 `json:"f1"`
+	// Support is the number of samples labeled as Class
+	Support int `json:"support"`
+}
+
+// Report is a classification report
+type Report struct {
+	// Accuracy is the percentage of successfully classified samples
+	Accuracy float64 `json:"accuracy"`
+	// Classes contains per class metrics
+	Classes []ClassMetrics `json:"classes"`
+	// Confusion is a confusion matrix stored by rows
+	Confusion [][]float64 `json:"confusion"`
+}
+
+// ClassificationReport evaluates the network on the data set passed in as parameter
+// and returns classification report. It fails with error if the evaluation fails.
+func ClassificationReport(net *neural.Network, features *mat64.Dense, labels *mat64.Vector) (*Report, error) {
+	confMx, err := ConfusionMatrix(net, features, labels)
+	if err != nil {
+		return nil, err
+	}
+	return NewReport(confMx)
+}
+
+// NewReport creates new classification report from the confusion matrix passed in as parameter.
+// It fails with error if the confusion matrix is nil, is not a square matrix or if it is empty.
+func NewReport(confMx mat64.Matrix) (*Report, error) {
+	if confMx == nil {
+		return nil, fmt.Errorf("Invalid confusion matrix supplied: %v\n", confMx)
+	}
+	rows, cols := confMx.Dims()
+	if rows != cols {
+		return nil, fmt.Errorf("Confusion matrix must be square: %d x %d\n", rows, cols)
+	}
+	total := mat64.Sum(confMx)
+	if total == 0 {
+		return nil, fmt.Errorf("Empty confusion matrix supplied\n")
+	}
+	r := &Report{
+		Classes:   make([]ClassMetrics, rows),
+		Confusion: make([][]float64, rows),
+	}
+	hits := 0.0
+	for i := 0; i < rows; i++ {
+		r.Confusion[i] = mat64.Row(nil, i, confMx)
+		// predicted and expected samples counts of i-th class
+		predicted, expected := 0.0, 0.0
+		for j := 0; j < cols; j++ {
+			predicted += confMx.At(j, i)
+			expected += confMx.At(i, j)
+		}
+		tp := confMx.At(i, i)
+		hits += tp
+		m := ClassMetrics{
+			Class:   i + 1,
+			Support: int(expected),
+		}
+		if predicted > 0 {
+			m.Precision = tp / predicted
+		}
+		if expected > 0 {
+			m.Recall = tp / expected
+		}
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		r.Classes[i] = m
+	}
+	r.Accuracy = (hits / total) * 100
+	return r, nil
+}
+
+// WriteText writes classification report to w in a human readable text format
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "class\tprecision\trecall\tf1\tsupport\t")
+	for _, m := range r.Classes {
+		fmt.Fprintf(tw, "%d\t%.4f\t%.4f\t%.4f\t%d\t\n",
+			m.Class, m.Precision, m.Recall, m.F1, m.Support)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "\nAccuracy: %f\n\nConfusion matrix:\n", r.Accuracy); err != nil {
+		return err
+	}
+	rows := len(r.Confusion)
+	confMx := mat64.NewDense(rows, rows, nil)
+	for i, row := range r.Confusion {
+		confMx.SetRow(i, row)
+	}
+	return WriteConfusionMatrix(w, confMx)
+}
+
+// WriteJSON writes classification report to w encoded in JSON format
+func (r *Report) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
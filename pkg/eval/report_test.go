@@ -0,0 +1,72 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReport(t *testing.T) {
+	assert := assert.New(t)
+	delta := 0.0001
+	confMx := mat64.NewDense(2, 2, []float64{
+		1.0, 0.0,
+		1.0, 2.0,
+	})
+	r, err := NewReport(confMx)
+	assert.NoError(err)
+	assert.NotNil(r)
+	assert.InDelta(75.0, r.Accuracy, delta)
+	assert.Len(r.Classes, 2)
+	// class 1
+	assert.Equal(1, r.Classes[0].Class)
+	assert.InDelta(0.5, r.Classes[0].Precision, delta)
+	assert.InDelta(1.0, r.Classes[0].Recall, delta)
+	assert.InDelta(0.6667, r.Classes[0].F1, delta)
+	assert.Equal(1, r.Classes[0].Support)
+	// class 2
+	assert.InDelta(1.0, r.Classes[1].Precision, delta)
+	assert.InDelta(0.6667, r.Classes[1].Recall, delta)
+	assert.Equal(3, r.Classes[1].Support)
+	// nil matrix
+	r, err = NewReport(nil)
+	assert.Nil(r)
+	assert.Error(err)
+	// non-square matrix
+	r, err = NewReport(mat64.NewDense(2, 3, nil))
+	assert.Nil(r)
+	assert.Error(err)
+	// empty matrix
+	r, err = NewReport(mat64.NewDense(2, 2, nil))
+	assert.Nil(r)
+	assert.Error(err)
+}
+
+func TestClassificationReport(t *testing.T) {
+	assert := assert.New(t)
+	net := makeTestNet(t)
+	r, err := ClassificationReport(net, features, labels)
+	assert.NoError(err)
+	assert.NotNil(r)
+	// text report
+	var buf bytes.Buffer
+	err = r.WriteText(&buf)
+	assert.NoError(err)
+	assert.True(strings.Contains(buf.String(), "Accuracy: 75.000000"))
+	assert.True(strings.Contains(buf.String(), "true\\pred"))
+	// JSON report
+	buf.Reset()
+	err = r.WriteJSON(&buf)
+	assert.NoError(err)
+	decoded := new(Report)
+	assert.NoError(json.Unmarshal(buf.Bytes(), decoded))
+	assert.Equal(r, decoded)
+	// nil network
+	r, err = ClassificationReport(nil, features, labels)
+	assert.Nil(r)
+	assert.Error(err)
+}
@@ -9,9 +9,19 @@ import (
 
 // PseudoRandString generates a pseudoandom string of specified size
 func PseudoRandString(size int) string {
+	return PseudoRandStringWithRand(nil, size)
+}
+
+// PseudoRandStringWithRand generates a pseudorandom string of specified size from rnd.
+// If rnd is nil, the default math/rand source is used.
+func PseudoRandStringWithRand(rnd *rand.Rand, size int) string {
 	alphanum := "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 	bytes := make([]byte, size)
-	rand.Read(bytes)
+	if rnd != nil {
+		rnd.Read(bytes)
+	} else {
+		rand.Read(bytes)
+	}
 	// iterate through all alphanum bytes
 	for i, b := range bytes {
 		bytes[i] = alphanum[b%byte(len(alphanum))]
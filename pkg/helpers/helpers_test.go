@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,6 +20,16 @@ func TestPseudoRandString(t *testing.T) {
 	}
 }
 
+func TestPseudoRandStringWithRand(t *testing.T) {
+	assert := assert.New(t)
+	length := 10
+	// the same source generates the same string
+	str := PseudoRandStringWithRand(rand.New(rand.NewSource(7)), length)
+	assert.Len(str, length)
+	assert.Equal(str, PseudoRandStringWithRand(rand.New(rand.NewSource(7)), length))
+	assert.Len(PseudoRandStringWithRand(nil, length), length)
+}
+
 func TestParseParams(t *testing.T) {
 	assert := assert.New(t)
 	testCases := []struct {
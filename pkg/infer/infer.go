@@ -0,0 +1,237 @@
+package infer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// activations maps activation functions to their implementations
+var activations = map[string]func(float64) float64{
+	"sigmoid": sigmoid,
+	"tanh":    math.Tanh,
+	"relu": func(x float64) float64 {
+		if x > 0 {
+			return x
+		}
+		return 0.1 * x
+	},
+	"plainrelu": func(x float64) float64 {
+		return math.Max(x, 0.0)
+	},
+	"softsign": func(x float64) float64 {
+		return x / (1.0 + math.Abs(x))
+	},
+	// softmax is applied to whole layer output
+	"softmax": math.Exp,
+}
+
+// sigmoid is logistic function
+func sigmoid(x float64) float64 {
+	return 1.0 / (1.0 + math.Exp(-x))
+}
+
+// model is a network saved by the neural package: it only contains the fields used in inference
+type model struct {
+	Layers []struct {
+		Kind       string `json:"kind"`
+		Size       int    `json:"size"`
+		Activation string `json:"activation"`
+		Highway    bool   `json:"highway"`
+		Weights    *struct {
+			Rows int       `json:"rows"`
+			Cols int       `json:"cols"`
+			Data []float64 `json:"data"`
+		} `json:"weights"`
+	} `json:"layers"`
+	Scaler *struct {
+		Mean   []float64 `json:"mean"`
+		StdDev []float64 `json:"stddev"`
+	} `json:"scaler"`
+	Labels   []float64       `json:"labels"`
+	Pipeline json.RawMessage `json:"pipeline"`
+}
+
+// layer is a network layer with weights
+type layer struct {
+	// weights contains a row of cols weights per neuron: the first column contains biases.
+	// Highway layer weights contain transform weights in the first half of rows and
+	// gate weights in the second half.
+	weights    []float64
+	cols       int
+	size       int
+	highway    bool
+	activation string
+	act        func(float64) float64
+}
+
+// Model is a trained neural network saved by the neural package which classifies samples.
+// It only implements forward propagation and it depends on the standard library only,
+// so it can be compiled to WASM and embedded targets such as TinyGo.
+type Model struct {
+	layers []layer
+	mean   []float64
+	stdDev []float64
+	labels []float64
+}
+
+// Load reads neural network saved via Save method of neural network from r and returns it.
+// It fails with error if the saved network can't be decoded or if it is not a valid network.
+func Load(r io.Reader) (*Model, error) {
+	m := new(model)
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, err
+	}
+	// network must contain at least INPUT and OUTPUT layers
+	if len(m.Layers) < 2 {
+		return nil, fmt.Errorf("Incorrect number of network layers: %d\n", len(m.Layers))
+	}
+	inputs := m.Layers[0].Size
+	if m.Layers[0].Kind != "input" || inputs <= 0 {
+		return nil, fmt.Errorf("Incorrect input layer: %s, %d\n", m.Layers[0].Kind, inputs)
+	}
+	// preprocessing pipelines depend on the dataset package
+	if m.Pipeline != nil {
+		return nil, fmt.Errorf("Unsupported features preprocessing pipeline\n")
+	}
+	model := new(Model)
+	in := inputs
+	for i, ml := range m.Layers[1:] {
+		act, ok := activations[ml.Activation]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported activation function: %s\n", ml.Activation)
+		}
+		rows := ml.Size
+		if ml.Highway {
+			rows = 2 * ml.Size
+		}
+		mw := ml.Weights
+		if mw == nil || mw.Rows != rows || mw.Cols != in+1 || len(mw.Data) != mw.Rows*mw.Cols {
+			return nil, fmt.Errorf("Incorrect weights of layer %d\n", i+1)
+		}
+		if ml.Highway && ml.Size != in {
+			return nil, fmt.Errorf("Highway layer %d size does not match its input: %d\n", i+1, in)
+		}
+		model.layers = append(model.layers, layer{
+			weights:    mw.Data,
+			cols:       mw.Cols,
+			size:       ml.Size,
+			highway:    ml.Highway,
+			activation: ml.Activation,
+			act:        act,
+		})
+		in = ml.Size
+	}
+	if s := m.Scaler; s != nil {
+		if len(s.Mean) != inputs || len(s.StdDev) != inputs {
+			return nil, fmt.Errorf("Incorrect scaler size: %d\n", len(s.Mean))
+		}
+		model.mean, model.stdDev = s.Mean, s.StdDev
+	}
+	if m.Labels != nil && len(m.Labels) != in {
+		return nil, fmt.Errorf("Incorrect label map size: %d\n", len(m.Labels))
+	}
+	model.labels = m.Labels
+	return model, nil
+}
+
+// Inputs returns the number of network inputs
+func (m *Model) Inputs() int {
+	return m.layers[0].cols - 1
+}
+
+// Outputs returns the number of network outputs: the number of label classes
+func (m *Model) Outputs() int {
+	return m.layers[len(m.layers)-1].size
+}
+
+// Classify classifies sample x. The sample is scaled by the scaler the network has been trained
+// with if the saved network contains one. It returns the percentages of the sample belonging
+// to each label class. It fails with error if the sample size does not match the network inputs.
+func (m *Model) Classify(x []float64) ([]float64, error) {
+	if len(x) != m.Inputs() {
+		return nil, fmt.Errorf("Dimension mismatch. Inputs: %d, Sample: %d\n", m.Inputs(), len(x))
+	}
+	out := make([]float64, len(x))
+	copy(out, x)
+	for i := range m.mean {
+		out[i] = (out[i] - m.mean[i]) / m.stdDev[i]
+	}
+	for i := range m.layers {
+		out = m.layers[i].forward(out, i == len(m.layers)-1)
+	}
+	// scale output to percentages
+	sum := 0.0
+	for _, y := range out {
+		sum += y
+	}
+	for j := range out {
+		out[j] *= 100.0 / sum
+	}
+	return out, nil
+}
+
+// Predict classifies sample x the same way as Classify does. It returns the predicted label and
+// the probability of the sample belonging to the predicted label class. Predicted labels start at 1
+// unless the saved network maps them to data set labels.
+// It fails with error if the sample size does not match the network inputs.
+func (m *Model) Predict(x []float64) (float64, float64, error) {
+	percents, err := m.Classify(x)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	best := 0
+	for j, p := range percents {
+		if p > percents[best] {
+			best = j
+		}
+	}
+	label := float64(best + 1)
+	if m.labels != nil {
+		label = m.labels[best]
+	}
+	return label, percents[best] / 100.0, nil
+}
+
+// forward calculates layer output from its input
+func (l *layer) forward(in []float64, output bool) []float64 {
+	rows := len(l.weights) / l.cols
+	actIn := make([]float64, rows)
+	for i := range actIn {
+		w := l.weights[i*l.cols : (i+1)*l.cols]
+		sum := w[0]
+		for j, x := range in {
+			sum += w[j+1] * x
+		}
+		actIn[i] = sum
+	}
+	out := make([]float64, l.size)
+	switch {
+	case l.highway:
+		// highway output is a sum of the transformed input and the carried input
+		for j := range out {
+			t := sigmoid(actIn[l.size+j])
+			out[j] = t*l.act(actIn[j]) + (1-t)*in[j]
+		}
+	case l.activation == "softmax":
+		max := actIn[0]
+		for _, x := range actIn {
+			max = math.Max(max, x)
+		}
+		for j, x := range actIn {
+			out[j] = math.Exp(x - max)
+		}
+	default:
+		for j, x := range actIn {
+			out[j] = l.act(x)
+		}
+		// tanh and softsign outputs are rescaled to (0, 1) in OUTPUT layer
+		if output && (l.activation == "tanh" || l.activation == "softsign") {
+			for j := range out {
+				out[j] = 0.5 * (out[j] + 1.0)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package infer
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModel(t *testing.T) {
+	assert := assert.New(t)
+	features := []float64{5.1, 3.5, 1.4, 0.1,
+		4.9, 3.0, 1.4, 0.2,
+		4.7, 3.2, 1.3, 0.3,
+		4.6, 3.1, 1.5, 0.4}
+	inMx := mat64.NewDense(4, 4, features)
+	for _, output := range []string{"softmax", "sigmoid", "tanh", "softsign"} {
+		net, err := neural.NewFeedForward().Input(4).Hidden(4, "relu").Highway("sigmoid").
+			Hidden(4, "plainrelu").Hidden(3, "softsign").Output(5, output).Rand(rand.New(rand.NewSource(1))).Build()
+		assert.NoError(err)
+		net.SetScaler(dataset.NewScaler(inMx))
+		net.SetLabelMap(dataset.LabelMap{10, 20, 30, 40, 50})
+		var buf bytes.Buffer
+		assert.NoError(net.Save(&buf))
+		m, err := Load(&buf)
+		assert.NotNil(m)
+		assert.NoError(err)
+		assert.Equal(4, m.Inputs())
+		assert.Equal(5, m.Outputs())
+		// model classifies raw samples the same way as the network classifies scaled samples
+		scaled, err := net.Scaler().Scale(inMx)
+		assert.NoError(err)
+		classMx, err := net.Classify(scaled)
+		assert.NoError(err)
+		labels, probs, err := net.Predict(scaled)
+		assert.NoError(err)
+		for i := 0; i < 4; i++ {
+			percents, err := m.Classify(inMx.RawRowView(i))
+			assert.NoError(err)
+			for j, p := range percents {
+				assert.InDelta(classMx.At(i, j), p, 1e-9, output)
+			}
+			label, prob, err := m.Predict(inMx.RawRowView(i))
+			assert.NoError(err)
+			assert.Equal(10*labels.At(i, 0), label)
+			assert.InDelta(probs.At(i, 0), prob, 1e-9)
+		}
+		// sample size must match network inputs
+		_, err = m.Classify([]float64{1.0})
+		assert.Error(err)
+		_, _, err = m.Predict(nil)
+		assert.Error(err)
+	}
+	// invalid models
+	for _, saved := range []string{
+		`{`,
+		`{"layers":[{"kind":"input","size":2}]}`,
+		`{"layers":[{"kind":"input","size":2},{"kind":"output","size":1,"activation":"foo",` +
+			`"weights":{"rows":1,"cols":3,"data":[1,2,3]}}]}`,
+		`{"layers":[{"kind":"input","size":2},{"kind":"output","size":1,"activation":"sigmoid",` +
+			`"weights":{"rows":1,"cols":2,"data":[1,2]}}]}`,
+		`{"layers":[{"kind":"input","size":2},{"kind":"output","size":1,"activation":"sigmoid",` +
+			`"weights":{"rows":1,"cols":3,"data":[1,2,3]}}],"scaler":{"mean":[1],"stddev":[1]}}`,
+		`{"layers":[{"kind":"input","size":2},{"kind":"output","size":1,"activation":"sigmoid",` +
+			`"weights":{"rows":1,"cols":3,"data":[1,2,3]}}],"labels":[1,2]}`,
+		`{"layers":[{"kind":"input","size":2},{"kind":"output","size":1,"activation":"sigmoid",` +
+			`"weights":{"rows":1,"cols":3,"data":[1,2,3]}}],"pipeline":[]}`,
+	} {
+		m, err := Load(strings.NewReader(saved))
+		assert.Nil(m)
+		assert.Error(err, saved)
+	}
+}
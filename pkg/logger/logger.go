@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"io"
+	"log"
+)
+
+// Level is logging verbosity level
+type Level int
+
+const (
+	// Quiet suppresses all messages but errors
+	Quiet Level = iota
+	// Info logs informational messages
+	Info
+	// Debug logs detailed messages such as cost traces
+	Debug
+)
+
+// String implements Stringer interface
+func (l Level) String() string {
+	switch l {
+	case Quiet:
+		return "QUIET"
+	case Info:
+		return "INFO"
+	case Debug:
+		return "DEBUG"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Logger logs messages of library packages. Packages which log messages accept
+// any Logger implementation and discard all messages by default.
+type Logger interface {
+	// Errorf logs error message
+	Errorf(format string, v ...interface{})
+	// Infof logs informational message
+	Infof(format string, v ...interface{})
+	// Debugf logs debug message
+	Debugf(format string, v ...interface{})
+}
+
+// Discard is a Logger which discards all messages
+var Discard Logger = discard{}
+
+// discard implements Logger which discards all messages
+type discard struct{}
+
+func (discard) Errorf(format string, v ...interface{}) {}
+func (discard) Infof(format string, v ...interface{})  {}
+func (discard) Debugf(format string, v ...interface{}) {}
+
+// Leveled is a leveled Logger. Messages above the logger level are discarded.
+// Leveled logger can be used from multiple goroutines simultaneously.
+type Leveled struct {
+	level Level
+	log   *log.Logger
+}
+
+// New creates new leveled logger which writes messages up to the given level to w
+func New(w io.Writer, level Level) *Leveled {
+	return &Leveled{
+		level: level,
+		log:   log.New(w, "", log.LstdFlags),
+	}
+}
+
+// Level returns logger level
+func (l *Leveled) Level() Level {
+	return l.level
+}
+
+// Errorf logs error message. Error messages are logged at every level.
+func (l *Leveled) Errorf(format string, v ...interface{}) {
+	l.logf("ERROR", format, v...)
+}
+
+// Infof logs informational message if the logger level is at least Info
+func (l *Leveled) Infof(format string, v ...interface{}) {
+	if l.level >= Info {
+		l.logf("INFO", format, v...)
+	}
+}
+
+// Debugf logs debug message if the logger level is Debug
+func (l *Leveled) Debugf(format string, v ...interface{}) {
+	if l.level >= Debug {
+		l.logf("DEBUG", format, v...)
+	}
+}
+
+// logf logs message prefixed with the level name
+func (l *Leveled) logf(prefix, format string, v ...interface{}) {
+	l.log.Printf(prefix+" "+format, v...)
+}
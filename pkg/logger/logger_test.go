@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelString(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("QUIET", Quiet.String())
+	assert.Equal("INFO", Info.String())
+	assert.Equal("DEBUG", Debug.String())
+	assert.Equal("UNKNOWN", Level(10).String())
+}
+
+func TestDiscard(t *testing.T) {
+	assert := assert.New(t)
+	assert.NotPanics(func() {
+		Discard.Errorf("error: %d", 1)
+		Discard.Infof("info: %d", 2)
+		Discard.Debugf("debug: %d", 3)
+	})
+}
+
+func TestLogger(t *testing.T) {
+	assert := assert.New(t)
+	testCases := []struct {
+		level Level
+		lines int
+	}{
+		{Quiet, 1},
+		{Info, 2},
+		{Debug, 3},
+	}
+	for _, tc := range testCases {
+		buf := new(bytes.Buffer)
+		l := New(buf, tc.level)
+		assert.Implements((*Logger)(nil), l)
+		assert.Equal(tc.level, l.Level())
+		l.Errorf("error: %d", 1)
+		l.Infof("info: %d", 2)
+		l.Debugf("debug: %d", 3)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(lines, tc.lines)
+		assert.Contains(lines[0], "ERROR error: 1")
+		if tc.lines > 1 {
+			assert.Contains(lines[1], "INFO info: 2")
+		}
+		if tc.lines > 2 {
+			assert.Contains(lines[2], "DEBUG debug: 3")
+		}
+	}
+}
@@ -69,6 +69,23 @@ func TanhOutMx(i, j int, x float64) float64 {
 	return 0.5 * (math.Tanh(x) + 1.0)
 }
 
+// SoftsignMx allows to apply softsign function x/(1+|x|) to all matrix elements
+func SoftsignMx(i, j int, x float64) float64 {
+	return x / (1.0 + math.Abs(x))
+}
+
+// SoftsignGradMx provides softsign derivation used in backpropagation algorithm
+func SoftsignGradMx(i, j int, x float64) float64 {
+	d := 1.0 + math.Abs(x)
+	return 1.0 / (d * d)
+}
+
+// SoftsignOutMx re-scales softsign function so that it can be used in
+// Output layer for neural network classifiers
+func SoftsignOutMx(i, j int, x float64) float64 {
+	return 0.5 * (x/(1.0+math.Abs(x)) + 1.0)
+}
+
 // ReluMx allows to apply Relu to all matrix elements
 func ReluMx(i, j int, x float64) float64 {
 	if x > 0 {
@@ -84,3 +101,37 @@ func ReluGradMx(i, j int, x float64) float64 {
 	}
 	return 0.1
 }
+
+// PlainReluMx allows to apply Relu without leak to all matrix elements
+func PlainReluMx(i, j int, x float64) float64 {
+	if x > 0 {
+		return x
+	}
+	return 0.0
+}
+
+// PlainReluGradMx provides Relu without leak a "derivation" used in backpropagation algorithm
+func PlainReluGradMx(i, j int, x float64) float64 {
+	if x > 0.0 {
+		return 1.0
+	}
+	return 0.0
+}
+
+// Clip limits x to interval [min, max]
+func Clip(x, min, max float64) float64 {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
+
+// ClipMx allows to limit all matrix elements to interval [min, max]
+func ClipMx(min, max float64) func(int, int, float64) float64 {
+	return func(i, j int, x float64) float64 {
+		return Clip(x, min, max)
+	}
+}
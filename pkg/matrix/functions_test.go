@@ -200,6 +200,33 @@ func TestTanhGradMx(t *testing.T) {
 	}
 }
 
+func TestSoftsignMx(t *testing.T) {
+	assert := assert.New(t)
+
+	inData := []float64{0.0, 3.0, -1.0}
+	inMx := mat64.NewDense(1, len(inData), inData)
+	assert.NotNil(inMx)
+	// test cases
+	testCases := []struct {
+		fn       func(int, int, float64) float64
+		data     []float64
+		expected bool
+	}{
+		{SoftsignMx, []float64{0.0, 0.75, -0.5}, true},
+		{SoftsignMx, []float64{0.0, 1.0, -1.0}, false},
+		{SoftsignGradMx, []float64{1.0, 0.0625, 0.25}, true},
+		{SoftsignOutMx, []float64{0.5, 0.875, 0.25}, true},
+	}
+
+	for _, tc := range testCases {
+		tstMx := mat64.NewDense(1, len(tc.data), tc.data)
+		assert.NotNil(tstMx)
+		softsignMx := new(mat64.Dense)
+		softsignMx.Apply(tc.fn, inMx)
+		assert.True(tc.expected == mat64.EqualApprox(softsignMx, tstMx, 1e-12))
+	}
+}
+
 func TestReluMx(t *testing.T) {
 	assert := assert.New(t)
 
@@ -247,3 +274,26 @@ func TestReluGradMx(t *testing.T) {
 		assert.True(tc.expected == mat64.Equal(reluGradMx, tstMx))
 	}
 }
+
+func TestPlainReluMx(t *testing.T) {
+	assert := assert.New(t)
+
+	inMx := mat64.NewDense(1, 3, []float64{0.0, 20.0, -1.0})
+	reluMx := new(mat64.Dense)
+	reluMx.Apply(PlainReluMx, inMx)
+	assert.True(mat64.Equal(mat64.NewDense(1, 3, []float64{0.0, 20.0, 0.0}), reluMx))
+	reluGradMx := new(mat64.Dense)
+	reluGradMx.Apply(PlainReluGradMx, inMx)
+	assert.True(mat64.Equal(mat64.NewDense(1, 3, []float64{0.0, 1.0, 0.0}), reluGradMx))
+}
+
+func TestClipMx(t *testing.T) {
+	assert := assert.New(t)
+
+	inMx := mat64.NewDense(1, 4, []float64{-2.0, 0.0, 0.5, 3.0})
+	clipMx := new(mat64.Dense)
+	clipMx.Apply(ClipMx(0.0, 1.0), inMx)
+	expMx := mat64.NewDense(1, 4, []float64{0.0, 0.0, 0.5, 1.0})
+	assert.True(mat64.Equal(expMx, clipMx))
+	assert.Equal(1.0, Clip(math.Inf(1), 0.0, 1.0))
+}
@@ -0,0 +1,71 @@
+package matrix
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// denseHeaderSize is the size of the binary dense matrix header: number of rows and columns
+const denseHeaderSize = 16
+
+// denseChunkSize is the number of matrix elements read at once
+const denseChunkSize = 4096
+
+// WriteDense writes matrix m to w in binary form: the number of rows and columns stored as
+// little-endian int64 values followed by the matrix elements stored row by row as little-endian
+// float64 values. The format is the same as the one produced by mat64.Dense.MarshalBinary.
+// It returns error if m is nil or if writing to w fails.
+func WriteDense(w io.Writer, m mat64.Matrix) error {
+	if m == nil {
+		return fmt.Errorf("Can't write matrix: %v\n", m)
+	}
+	d, ok := m.(*mat64.Dense)
+	if !ok {
+		d = mat64.DenseCopyOf(m)
+	}
+	bw := bufio.NewWriter(w)
+	if _, err := d.MarshalBinaryTo(bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ReadDense reads matrix written by WriteDense from r. It reads exactly as many bytes as the
+// matrix occupies, so multiple matrices can be read from the same reader one after another.
+// Matrix elements are read in chunks, so the allocated memory grows with the data actually read
+// rather than with the dimensions stored in the header.
+// It returns error if the matrix can't be read or if its header contains invalid dimensions.
+func ReadDense(r io.Reader) (*mat64.Dense, error) {
+	header := make([]byte, denseHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	rows := int64(binary.LittleEndian.Uint64(header[:8]))
+	cols := int64(binary.LittleEndian.Uint64(header[8:]))
+	if rows <= 0 || cols <= 0 || rows > math.MaxInt32 || cols > math.MaxInt32 ||
+		rows*cols > math.MaxInt32 {
+		return nil, fmt.Errorf("Incorrect dimensions: %d x %d\n", rows, cols)
+	}
+	// corrupted header can't make the reader allocate a huge matrix upfront
+	size := int(rows * cols)
+	chunk := make([]byte, denseChunkSize*8)
+	data := make([]float64, 0, denseChunkSize)
+	for len(data) < size {
+		n := size - len(data)
+		if n > denseChunkSize {
+			n = denseChunkSize
+		}
+		if _, err := io.ReadFull(r, chunk[:n*8]); err != nil {
+			return nil, err
+		}
+		for i := 0; i < n; i++ {
+			data = append(data, math.Float64frombits(binary.LittleEndian.Uint64(chunk[i*8:])))
+		}
+	}
+	return mat64.NewDense(int(rows), int(cols), data), nil
+}
@@ -0,0 +1,62 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/binary"
+	"runtime"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteReadDense(t *testing.T) {
+	assert := assert.New(t)
+
+	mx := mat64.NewDense(2, 3, []float64{1.2, 3.4, 4.5,
+		6.7, 8.9, 10.0})
+	var buf bytes.Buffer
+	// nil matrix can't be written
+	assert.Error(WriteDense(&buf, nil))
+	// multiple matrices, including views, are written to the same stream
+	assert.NoError(WriteDense(&buf, mx))
+	view := mx.View(0, 1, 2, 2)
+	assert.NoError(WriteDense(&buf, view))
+	// binary format is compatible with mat64
+	expBytes, err := mx.MarshalBinary()
+	assert.NoError(err)
+	assert.Equal(expBytes, buf.Bytes()[:len(expBytes)])
+	// matrices are read back in the same order
+	readMx, err := ReadDense(&buf)
+	assert.NoError(err)
+	assert.True(mat64.Equal(mx, readMx))
+	readMx, err = ReadDense(&buf)
+	assert.NoError(err)
+	assert.True(mat64.Equal(view, readMx))
+	// empty stream
+	readMx, err = ReadDense(&buf)
+	assert.Nil(readMx)
+	assert.Error(err)
+	// truncated data
+	readMx, err = ReadDense(bytes.NewReader(expBytes[:len(expBytes)-1]))
+	assert.Nil(readMx)
+	assert.Error(err)
+	// invalid dimensions
+	badBytes := make([]byte, len(expBytes))
+	copy(badBytes, expBytes)
+	badBytes[7] = 0xff
+	readMx, err = ReadDense(bytes.NewReader(badBytes))
+	assert.Nil(readMx)
+	assert.Error(err)
+	// huge dimensions with short data fail without allocating the whole matrix
+	hugeBytes := make([]byte, denseHeaderSize+64)
+	binary.LittleEndian.PutUint64(hugeBytes[:8], 46340)
+	binary.LittleEndian.PutUint64(hugeBytes[8:], 46340)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	readMx, err = ReadDense(bytes.NewReader(hugeBytes))
+	runtime.ReadMemStats(&after)
+	assert.Nil(readMx)
+	assert.Error(err)
+	assert.True(after.TotalAlloc-before.TotalAlloc < 1<<20)
+}
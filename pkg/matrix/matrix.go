@@ -5,7 +5,10 @@ import (
 	"math"
 	"math/rand"
 
+	"github.com/gonum/blas/blas64"
+	"github.com/gonum/floats"
 	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
 )
 
 // Ones returns a matrix of rows x cols filled with 1.0
@@ -55,96 +58,213 @@ func MakeLabelsMx(labels *mat64.Vector, expLabels int) (*mat64.Dense, error) {
 	return mx, nil
 }
 
+// LabelsFromMx creates a vector of labels from the supplied 1-of-N matrix: it is the inverse
+// of MakeLabelsMx. Every row of the matrix may also contain class probabilities in which case
+// the label of the class with the highest probability is returned. Labels start at 1.
+// It returns error if the supplied matrix is nil or has no columns.
+func LabelsFromMx(m mat64.Matrix) (*mat64.Vector, error) {
+	if m == nil {
+		return nil, fmt.Errorf("Can't decode labels from matrix: %v\n", m)
+	}
+	rows, cols := m.Dims()
+	if cols == 0 {
+		return nil, fmt.Errorf("Incorrect number of labels: %d\n", cols)
+	}
+	labels := mat64.NewVector(rows, nil)
+	for i, maxIdx := range RowsArgMax(m) {
+		labels.SetVec(i, float64(maxIdx+1))
+	}
+	return labels, nil
+}
+
 // MakeRandMx creates a new matrix with of size rows x cols that is initialized
-// to random number uniformly distributed in interval (min, max)
+// to random number uniformly distributed in interval (min, max).
+// Random numbers are drawn from the default math/rand source: seed it via rand.Seed
+// to make the generated matrices reproducible. Use MakeRandMxFrom to choose the distribution.
 func MakeRandMx(rows, cols int, min, max float64) (*mat64.Dense, error) {
+	return MakeRandMxWithRand(nil, rows, cols, min, max)
+}
+
+// MakeRandMxWithRand creates a new random matrix the same way as MakeRandMx does, but it draws
+// random numbers from rnd. If rnd is nil, the default math/rand source is used.
+func MakeRandMxWithRand(rnd *rand.Rand, rows, cols int, min, max float64) (*mat64.Dense, error) {
+	randFloat := rand.Float64
+	if rnd != nil {
+		randFloat = rnd.Float64
+	}
 	if rows <= 0 || cols <= 0 {
 		return nil, fmt.Errorf("Incorrect dimensions supplied: %d x %dd\n", rows, cols)
 	}
-	// set random seed
-	rand.Seed(55)
 	// empirically this is supposed to be the best value
 	epsilon := math.Sqrt(6.0) / math.Sqrt(float64(rows+cols))
 	// allocate data slice
 	randVals := make([]float64, rows*cols)
 	for i := range randVals {
 		// we need value between 0 and 1.0
-		randVals[i] = rand.Float64()*(max-min) + min
+		randVals[i] = randFloat()*(max-min) + min
 		randVals[i] = randVals[i]*(2*epsilon) - epsilon
 	}
 	return mat64.NewDense(rows, cols, randVals), nil
 }
 
-// Mx2Vec unrolls all elements of matrix into a slice and returns it.
-// Matrix elements can be unrolled either by row or by a column.
-func Mx2Vec(m *mat64.Dense, byRow bool) []float64 {
-	if byRow {
-		return mx2VecByRow(m)
+// Outer adds the outer product of x and y to addTo, i.e. it performs the rank-1 update
+// addTo += x * y' in place via BLAS Ger without allocating any temporary matrices.
+// It returns error if addTo is not len(x) x len(y) matrix.
+func Outer(addTo *mat64.Dense, x, y []float64) error {
+	rows, cols := addTo.Dims()
+	if rows != len(x) || cols != len(y) {
+		return fmt.Errorf("Dimension mismatch. Matrix: %d x %d, Outer: %d x %d\n", rows, cols, len(x), len(y))
+	}
+	blas64.Ger(1.0, blas64.Vector{Inc: 1, Data: x}, blas64.Vector{Inc: 1, Data: y}, addTo.RawMatrix())
+	return nil
+}
+
+// ClipNorm rescales matrix m in place so that its Frobenius norm does not exceed max.
+// Matrices whose norm does not exceed max are left intact. It returns the norm of m before clipping.
+// It returns error if max is negative.
+func ClipNorm(m *mat64.Dense, max float64) (float64, error) {
+	if max < 0 {
+		return 0.0, fmt.Errorf("Incorrect norm: %f\n", max)
 	}
-	return mx2VecByCol(m)
+	rows, _ := m.Dims()
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		for _, x := range m.RawRowView(i) {
+			sum += x * x
+		}
+	}
+	norm := math.Sqrt(sum)
+	if norm > max {
+		m.Scale(max/norm, m)
+	}
+	return norm, nil
 }
 
-// mx2VecByRow rolls matrix into a slice by rows
-func mx2VecByRow(m *mat64.Dense) []float64 {
+// HasNaN returns true if any element of matrix m is NaN
+func HasNaN(m mat64.Matrix) bool {
 	rows, cols := m.Dims()
-	vec := make([]float64, rows*cols)
 	for i := 0; i < rows; i++ {
-		view := m.RowView(i)
-		for j := 0; j < view.Len(); j++ {
-			vec[i*cols+j] = view.At(j, 0)
+		for j := 0; j < cols; j++ {
+			if math.IsNaN(m.At(i, j)) {
+				return true
+			}
 		}
 	}
-	return vec
+	return false
 }
 
-// mx2VecByCol rolls matrix into a slice by columns
-func mx2VecByCol(m *mat64.Dense) []float64 {
+// HasInf returns true if any element of matrix m is either positive or negative infinity
+func HasInf(m mat64.Matrix) bool {
 	rows, cols := m.Dims()
-	vec := make([]float64, rows*cols)
-	for i := 0; i < cols; i++ {
-		view := m.ColView(i)
-		for j := 0; j < view.Len(); j++ {
-			vec[i*rows+j] = view.At(j, 0)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if math.IsInf(m.At(i, j), 0) {
+				return true
+			}
 		}
 	}
-	return vec
+	return false
 }
 
-// SetMx2Vec sets all elements of a matrix to values stored in a slice
-// passed in as a parameter. It fails with error if number of elements
-// of the matrix is bigger than number of elements of the slice.
-func SetMx2Vec(mx *mat64.Dense, vec []float64, byRow bool) (err error) {
-	r, c := mx.Dims()
-	if r*c != len(vec) {
-		err = fmt.Errorf("Elements count mismatch: Vec: %d, Matrix: %d\n", len(vec), r*c)
-		return
+// Order defines the order in which matrix elements are laid out in a slice
+type Order int
+
+const (
+	// RowMajor lays out matrix elements row by row
+	RowMajor Order = iota + 1
+	// ColMajor lays out matrix elements column by column
+	ColMajor
+)
+
+// String implements Stringer interface
+func (o Order) String() string {
+	switch o {
+	case RowMajor:
+		return "RowMajor"
+	case ColMajor:
+		return "ColMajor"
+	default:
+		return "Unknown"
 	}
-	if byRow {
-		setMx2VecByRow(mx, vec)
-		return
+}
+
+// Flatten unrolls all elements of matrix into a new slice in the requested order and returns it.
+// It returns error if the matrix is nil or if the order is not supported.
+func Flatten(m mat64.Matrix, o Order) ([]float64, error) {
+	if m == nil {
+		return nil, fmt.Errorf("Can't flatten matrix: %v\n", m)
 	}
-	setMx2VecByCol(mx, vec)
-	return
+	rows, cols := m.Dims()
+	vec := make([]float64, rows*cols)
+	switch o {
+	case RowMajor:
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				vec[i*cols+j] = m.At(i, j)
+			}
+		}
+	case ColMajor:
+		for j := 0; j < cols; j++ {
+			for i := 0; i < rows; i++ {
+				vec[j*rows+i] = m.At(i, j)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported order: %s\n", o)
+	}
+	return vec, nil
 }
 
-// setMxByRowVec sets elements of mx from vec by rows
-func setMx2VecByRow(mx *mat64.Dense, vec []float64) {
+// Unflatten sets all elements of matrix to the values stored in vec laid out in the requested order.
+// It is the inverse of Flatten. It returns error if the matrix is nil, if the number of matrix
+// elements differs from the number of elements of the slice or if the order is not supported.
+func Unflatten(mx *mat64.Dense, vec []float64, o Order) error {
+	if mx == nil {
+		return fmt.Errorf("Can't unflatten into matrix: %v\n", mx)
+	}
 	rows, cols := mx.Dims()
-	acc := 0
-	for i := 0; i < rows; i++ {
-		mx.SetRow(i, vec[acc:(acc+cols)])
-		acc += cols
+	if rows*cols != len(vec) {
+		return fmt.Errorf("Elements count mismatch: Vec: %d, Matrix: %d\n", len(vec), rows*cols)
 	}
+	switch o {
+	case RowMajor:
+		for i := 0; i < rows; i++ {
+			mx.SetRow(i, vec[i*cols:(i+1)*cols])
+		}
+	case ColMajor:
+		for j := 0; j < cols; j++ {
+			mx.SetCol(j, vec[j*rows:(j+1)*rows])
+		}
+	default:
+		return fmt.Errorf("Unsupported order: %s\n", o)
+	}
+	return nil
 }
 
-// setMxByColVec sets elements of mx from vec by columns
-func setMx2VecByCol(mx *mat64.Dense, vec []float64) {
-	rows, cols := mx.Dims()
-	acc := 0
-	for i := 0; i < cols; i++ {
-		mx.SetCol(i, vec[acc:(acc+rows)])
-		acc += rows
+// Mx2Vec unrolls all elements of matrix into a slice and returns it.
+// Matrix elements can be unrolled either by row or by a column.
+//
+// Deprecated: use Flatten instead.
+func Mx2Vec(m *mat64.Dense, byRow bool) []float64 {
+	vec, _ := Flatten(m, order(byRow))
+	return vec
+}
+
+// SetMx2Vec sets all elements of a matrix to values stored in a slice
+// passed in as a parameter. It fails with error if number of elements
+// of the matrix is bigger than number of elements of the slice.
+//
+// Deprecated: use Unflatten instead.
+func SetMx2Vec(mx *mat64.Dense, vec []float64, byRow bool) error {
+	return Unflatten(mx, vec, order(byRow))
+}
+
+// order translates byRow flag to Order
+func order(byRow bool) Order {
+	if byRow {
+		return RowMajor
 	}
+	return ColMajor
 }
 
 // RowsMax returns a slice of max values per each matrix row
@@ -175,6 +295,72 @@ func ColsMax(m *mat64.Dense) []float64 {
 	return max
 }
 
+// RowsMin returns a slice of min values per each matrix row
+// It returns nil if passed in matrix is nil or has zero elements
+func RowsMin(m *mat64.Dense) []float64 {
+	if m == nil {
+		return nil
+	}
+	rows, _ := m.Dims()
+	min := make([]float64, rows)
+	for i := 0; i < rows; i++ {
+		min[i] = mat64.Min(m.RowView(i))
+	}
+	return min
+}
+
+// ColsMin returns a slice of min values per each matrix column
+// It returns nil if passed in matrix is nil or has zero elements
+func ColsMin(m *mat64.Dense) []float64 {
+	if m == nil {
+		return nil
+	}
+	_, cols := m.Dims()
+	min := make([]float64, cols)
+	for i := 0; i < cols; i++ {
+		min[i] = mat64.Min(m.ColView(i))
+	}
+	return min
+}
+
+// RowsArgMax returns a slice of column indices of max values per each matrix row.
+// If a row contains multiple max values, the index of the first one is returned.
+// It returns nil if passed in matrix is nil
+func RowsArgMax(m mat64.Matrix) []int {
+	if m == nil {
+		return nil
+	}
+	rows, cols := m.Dims()
+	argMax := make([]int, rows)
+	for i := 0; i < rows; i++ {
+		for j := 1; j < cols; j++ {
+			if m.At(i, j) > m.At(i, argMax[i]) {
+				argMax[i] = j
+			}
+		}
+	}
+	return argMax
+}
+
+// ColsArgMax returns a slice of row indices of max values per each matrix column.
+// If a column contains multiple max values, the index of the first one is returned.
+// It returns nil if passed in matrix is nil
+func ColsArgMax(m mat64.Matrix) []int {
+	if m == nil {
+		return nil
+	}
+	rows, cols := m.Dims()
+	argMax := make([]int, cols)
+	for j := 0; j < cols; j++ {
+		for i := 1; i < rows; i++ {
+			if m.At(i, j) > m.At(argMax[j], j) {
+				argMax[j] = i
+			}
+		}
+	}
+	return argMax
+}
+
 // RowSums returns a slice of sums of all elemnts in each matrix row
 // It returns nil if passed in matrix is nil or has zero elements
 func RowSums(m *mat64.Dense) []float64 {
@@ -202,3 +388,61 @@ func ColSums(m *mat64.Dense) []float64 {
 	}
 	return sum
 }
+
+// RowMeans returns a slice of mean values of all elements in each matrix row
+// It returns nil if passed in matrix is nil or has zero elements
+func RowMeans(m *mat64.Dense) []float64 {
+	if m == nil {
+		return nil
+	}
+	_, cols := m.Dims()
+	mean := RowSums(m)
+	floats.Scale(1/float64(cols), mean)
+	return mean
+}
+
+// ColMeans returns a slice of mean values of all elements in each matrix column
+// It returns nil if passed in matrix is nil or has zero elements
+func ColMeans(m *mat64.Dense) []float64 {
+	if m == nil {
+		return nil
+	}
+	rows, _ := m.Dims()
+	mean := ColSums(m)
+	floats.Scale(1/float64(rows), mean)
+	return mean
+}
+
+// RowStds returns a slice of sample standard deviations of all elements in each matrix row.
+// Standard deviations are normalized by the number of row elements minus one.
+// It returns nil if passed in matrix is nil or has zero elements
+func RowStds(m *mat64.Dense) []float64 {
+	if m == nil {
+		return nil
+	}
+	rows, cols := m.Dims()
+	std := make([]float64, rows)
+	row := make([]float64, cols)
+	for i := 0; i < rows; i++ {
+		mat64.Row(row, i, m)
+		std[i] = stat.StdDev(row, nil)
+	}
+	return std
+}
+
+// ColStds returns a slice of sample standard deviations of all elements in each matrix column.
+// Standard deviations are normalized by the number of column elements minus one.
+// It returns nil if passed in matrix is nil or has zero elements
+func ColStds(m *mat64.Dense) []float64 {
+	if m == nil {
+		return nil
+	}
+	rows, cols := m.Dims()
+	std := make([]float64, cols)
+	col := make([]float64, rows)
+	for i := 0; i < cols; i++ {
+		mat64.Col(col, i, m)
+		std[i] = stat.StdDev(col, nil)
+	}
+	return std
+}
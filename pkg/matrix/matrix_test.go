@@ -1,6 +1,8 @@
 package matrix
 
 import (
+	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/gonum/matrix/mat64"
@@ -62,9 +64,33 @@ func TestMakeLabelsMx(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestLabelsFromMx(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := mat64.NewVector(4, []float64{2.0, 1.0, 3.0, 2.0})
+	labelsMx, err := MakeLabelsMx(labels, 3)
+	assert.NoError(err)
+	// LabelsFromMx is the inverse of MakeLabelsMx
+	decoded, err := LabelsFromMx(labelsMx)
+	assert.NoError(err)
+	assert.True(mat64.Equal(labels, decoded))
+	// probabilities are decoded to the most probable labels
+	probsMx := mat64.NewDense(2, 3, []float64{0.2, 0.5, 0.3,
+		0.7, 0.2, 0.1})
+	decoded, err = LabelsFromMx(probsMx)
+	assert.NoError(err)
+	assert.EqualValues([]float64{2.0, 1.0}, decoded.RawVector().Data)
+	// nil matrix
+	decoded, err = LabelsFromMx(nil)
+	assert.Nil(decoded)
+	assert.Error(err)
+}
+
 func TestMakeRandMx(t *testing.T) {
 	assert := assert.New(t)
 
+	// seed the default source to get reproducible matrix
+	rand.Seed(55)
 	// create new matrix
 	rows, cols := 2, 3
 	min, max := 0.0, 1.0
@@ -78,6 +104,11 @@ func TestMakeRandMx(t *testing.T) {
 		col := randMx.ColView(i)
 		assert.True(max >= mat64.Max(col))
 	}
+	// the same seed generates the same matrix
+	rand.Seed(55)
+	sameMx, err := MakeRandMx(rows, cols, min, max)
+	assert.NoError(err)
+	assert.True(mat64.Equal(randMx, sameMx))
 
 	// Can't create new matrix
 	randMx, err = MakeRandMx(rows, -6, min, max)
@@ -85,6 +116,138 @@ func TestMakeRandMx(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestMakeRandMxWithRand(t *testing.T) {
+	assert := assert.New(t)
+
+	rows, cols := 2, 3
+	min, max := 0.0, 1.0
+	// the same source generates the same matrix
+	randMx, err := MakeRandMxWithRand(rand.New(rand.NewSource(7)), rows, cols, min, max)
+	assert.NoError(err)
+	sameMx, err := MakeRandMxWithRand(rand.New(rand.NewSource(7)), rows, cols, min, max)
+	assert.NoError(err)
+	assert.True(mat64.Equal(randMx, sameMx))
+	// the default source is not used
+	rand.Seed(55)
+	expMx, err := MakeRandMx(rows, cols, min, max)
+	assert.NoError(err)
+	rand.Seed(55)
+	_, err = MakeRandMxWithRand(rand.New(rand.NewSource(7)), rows, cols, min, max)
+	assert.NoError(err)
+	globalMx, err := MakeRandMx(rows, cols, min, max)
+	assert.NoError(err)
+	assert.True(mat64.Equal(expMx, globalMx))
+	// nil source falls back to the default source
+	rand.Seed(55)
+	nilMx, err := MakeRandMxWithRand(nil, rows, cols, min, max)
+	assert.NoError(err)
+	assert.True(mat64.Equal(expMx, nilMx))
+}
+
+func TestOuter(t *testing.T) {
+	assert := assert.New(t)
+
+	x := []float64{1.0, 2.0}
+	y := []float64{3.0, 4.0, 5.0}
+	addTo := mat64.NewDense(2, 3, []float64{1.0, 1.0, 1.0,
+		1.0, 1.0, 1.0})
+	assert.NoError(Outer(addTo, x, y))
+	expMx := mat64.NewDense(2, 3, []float64{4.0, 5.0, 6.0,
+		7.0, 9.0, 11.0})
+	assert.True(mat64.Equal(expMx, addTo))
+	// matrix views are updated too
+	mx := mat64.NewDense(3, 4, nil)
+	view := mx.View(1, 1, 2, 3).(*mat64.Dense)
+	assert.NoError(Outer(view, x, y))
+	assert.Equal(3.0, mx.At(1, 1))
+	assert.Equal(10.0, mx.At(2, 3))
+	assert.Equal(0.0, mx.At(0, 0))
+	// dimension mismatch
+	assert.Error(Outer(addTo, y, x))
+}
+
+func TestClipNorm(t *testing.T) {
+	assert := assert.New(t)
+
+	mx := mat64.NewDense(2, 2, []float64{3.0, 0.0,
+		0.0, 4.0})
+	// norm within limit leaves matrix intact
+	norm, err := ClipNorm(mx, 10.0)
+	assert.NoError(err)
+	assert.Equal(5.0, norm)
+	assert.Equal(4.0, mx.At(1, 1))
+	// norm beyond limit rescales matrix
+	norm, err = ClipNorm(mx, 1.0)
+	assert.NoError(err)
+	assert.Equal(5.0, norm)
+	expMx := mat64.NewDense(2, 2, []float64{0.6, 0.0,
+		0.0, 0.8})
+	assert.True(mat64.EqualApprox(expMx, mx, 1e-12))
+	// negative limit
+	_, err = ClipNorm(mx, -1.0)
+	assert.Error(err)
+}
+
+func TestHasNaNInf(t *testing.T) {
+	assert := assert.New(t)
+
+	mx := mat64.NewDense(2, 2, []float64{1.0, 2.0,
+		3.0, 4.0})
+	assert.False(HasNaN(mx))
+	assert.False(HasInf(mx))
+	mx.Set(1, 0, math.NaN())
+	assert.True(HasNaN(mx))
+	assert.False(HasInf(mx))
+	mx.Set(1, 0, math.Inf(-1))
+	assert.False(HasNaN(mx))
+	assert.True(HasInf(mx))
+}
+
+func TestFlatten(t *testing.T) {
+	assert := assert.New(t)
+
+	byRow := []float64{1.2, 3.4, 4.5, 6.7, 8.9, 10.0}
+	byCol := []float64{1.2, 4.5, 8.9, 3.4, 6.7, 10.0}
+	tstMx := mat64.NewDense(3, 2, byRow)
+	// flatten by rows and columns
+	vec, err := Flatten(tstMx, RowMajor)
+	assert.NoError(err)
+	assert.EqualValues(byRow, vec)
+	vec, err = Flatten(tstMx, ColMajor)
+	assert.NoError(err)
+	assert.EqualValues(byCol, vec)
+	// transposed matrix is flattened too
+	vec, err = Flatten(tstMx.T(), RowMajor)
+	assert.NoError(err)
+	assert.EqualValues(byCol, vec)
+	// nil matrix and unsupported order
+	vec, err = Flatten(nil, RowMajor)
+	assert.Nil(vec)
+	assert.Error(err)
+	vec, err = Flatten(tstMx, Order(10))
+	assert.Nil(vec)
+	assert.Error(err)
+}
+
+func TestUnflatten(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []float64{1.2, 3.4, 4.5, 6.7, 8.9, 10.0}
+	mx := mat64.NewDense(3, 2, nil)
+	// Unflatten is the inverse of Flatten
+	for _, o := range []Order{RowMajor, ColMajor} {
+		err := Unflatten(mx, data, o)
+		assert.NoError(err)
+		vec, err := Flatten(mx, o)
+		assert.NoError(err)
+		assert.EqualValues(data, vec)
+	}
+	// nil matrix, short slice and unsupported order
+	assert.Error(Unflatten(nil, data, RowMajor))
+	assert.Error(Unflatten(mx, data[:2], RowMajor))
+	assert.Error(Unflatten(mx, data, Order(10)))
+}
+
 func TestMx2Vec(t *testing.T) {
 	assert := assert.New(t)
 
@@ -157,6 +320,20 @@ func TestRowColMax(t *testing.T) {
 
 }
 
+func TestRowColArgMax(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []float64{1.2, 3.4, 8.9, 6.7, 8.9, 10.0}
+	mx := mat64.NewDense(3, 2, data)
+	assert.EqualValues([]int{1, 0, 1}, RowsArgMax(mx))
+	// the first of equal max values is returned
+	assert.EqualValues([]int{1, 2}, ColsArgMax(mx))
+	assert.EqualValues([]int{1, 2}, RowsArgMax(mx.T()))
+	// should get nil back
+	assert.Nil(RowsArgMax(nil))
+	assert.Nil(ColsArgMax(nil))
+}
+
 func TestRowColSums(t *testing.T) {
 	data := []float64{1.2, 3.4, 4.5, 6.7, 8.9, 10.0}
 	rowSums := []float64{4.6, 11.2, 18.9}
@@ -178,3 +355,30 @@ func TestRowColSums(t *testing.T) {
 	tst = ColSums(nil)
 	assert.Nil(t, tst)
 }
+
+func TestRowColMin(t *testing.T) {
+	assert := assert.New(t)
+
+	data := []float64{1.2, 3.4, 4.5, 6.7, 8.9, 10.0}
+	mx := mat64.NewDense(3, 2, data)
+	assert.EqualValues([]float64{1.2, 4.5, 8.9}, RowsMin(mx))
+	assert.EqualValues([]float64{1.2, 3.4}, ColsMin(mx))
+	// should get nil back
+	assert.Nil(RowsMin(nil))
+	assert.Nil(ColsMin(nil))
+}
+
+func TestRowColMeansStds(t *testing.T) {
+	data := []float64{1.2, 3.4, 4.5, 6.7, 8.9, 10.0}
+	delta := 0.001
+	mx := mat64.NewDense(3, 2, data)
+	assert.InDeltaSlice(t, []float64{2.3, 5.6, 9.45}, RowMeans(mx), delta)
+	assert.InDeltaSlice(t, []float64{4.8667, 6.7}, ColMeans(mx), delta)
+	assert.InDeltaSlice(t, []float64{1.5556, 1.5556, 0.7778}, RowStds(mx), delta)
+	assert.InDeltaSlice(t, []float64{3.8631, 3.3}, ColStds(mx), delta)
+	// should get nil back
+	assert.Nil(t, RowMeans(nil))
+	assert.Nil(t, ColMeans(nil))
+	assert.Nil(t, RowStds(nil))
+	assert.Nil(t, ColStds(nil))
+}
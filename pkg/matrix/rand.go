@@ -0,0 +1,143 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Dist is a probability distribution of random matrix elements
+type Dist int
+
+const (
+	// Uniform is uniform distribution in interval [Min, Max)
+	Uniform Dist = iota + 1
+	// Normal is normal distribution with Mean and StdDev
+	Normal
+	// TruncNormal is normal distribution with Mean and StdDev truncated to two standard deviations
+	TruncNormal
+	// Xavier is uniform distribution scaled by the matrix dimensions: sqrt(6/(rows+cols))
+	Xavier
+	// He is normal distribution scaled by the number of matrix columns: sqrt(2/cols)
+	He
+)
+
+// String implements Stringer interface
+func (d Dist) String() string {
+	switch d {
+	case Uniform:
+		return "Uniform"
+	case Normal:
+		return "Normal"
+	case TruncNormal:
+		return "TruncNormal"
+	case Xavier:
+		return "Xavier"
+	case He:
+		return "He"
+	default:
+		return "Unknown"
+	}
+}
+
+// DistParams contains distribution parameters.
+// Min and Max are used by Uniform, Mean and StdDev by Normal and TruncNormal distribution.
+// Xavier and He distributions derive their parameters from matrix dimensions.
+type DistParams struct {
+	// Min is the lower bound of uniform distribution
+	Min float64
+	// Max is the upper bound of uniform distribution
+	Max float64
+	// Mean is the mean of normal distribution
+	Mean float64
+	// StdDev is the standard deviation of normal distribution
+	StdDev float64
+}
+
+// ShuffleRows randomly permutes the rows of matrix m and the elements of labels vector in place
+// using the same permutation, so every row stays aligned with its label. labels can be nil in
+// which case only the matrix rows are permuted. Random numbers are drawn from src.
+// If src is nil, the default math/rand source is used.
+// It returns error if m is nil or if the number of labels differs from the number of rows.
+func ShuffleRows(m *mat64.Dense, labels *mat64.Vector, src *rand.Rand) error {
+	if m == nil {
+		return fmt.Errorf("Can't shuffle matrix: %v\n", m)
+	}
+	rows, cols := m.Dims()
+	if labels != nil && labels.Len() != rows {
+		return fmt.Errorf("Samples count mismatch. Matrix: %d, Labels: %d\n", rows, labels.Len())
+	}
+	randIntn := rand.Intn
+	if src != nil {
+		randIntn = src.Intn
+	}
+	tmp := make([]float64, cols)
+	// Fisher-Yates shuffle
+	for i := rows - 1; i > 0; i-- {
+		j := randIntn(i + 1)
+		if i == j {
+			continue
+		}
+		copy(tmp, m.RawRowView(i))
+		copy(m.RawRowView(i), m.RawRowView(j))
+		copy(m.RawRowView(j), tmp)
+		if labels != nil {
+			li, lj := labels.At(i, 0), labels.At(j, 0)
+			labels.SetVec(i, lj)
+			labels.SetVec(j, li)
+		}
+	}
+	return nil
+}
+
+// MakeRandMxFrom creates a new matrix of size rows x cols whose elements are drawn from
+// distribution dist with parameters params. Random numbers are drawn from src.
+// If src is nil, the default math/rand source is used.
+// It returns error if the dimensions, the distribution or its parameters are invalid.
+func MakeRandMxFrom(dist Dist, params DistParams, rows, cols int, src *rand.Rand) (*mat64.Dense, error) {
+	if rows <= 0 || cols <= 0 {
+		return nil, fmt.Errorf("Incorrect dimensions supplied: %d x %d\n", rows, cols)
+	}
+	randFloat, randNorm := rand.Float64, rand.NormFloat64
+	if src != nil {
+		randFloat, randNorm = src.Float64, src.NormFloat64
+	}
+	var gen func() float64
+	switch dist {
+	case Uniform:
+		if params.Min > params.Max {
+			return nil, fmt.Errorf("Incorrect uniform interval: [%f, %f)\n", params.Min, params.Max)
+		}
+		gen = func() float64 { return randFloat()*(params.Max-params.Min) + params.Min }
+	case Normal, TruncNormal:
+		if params.StdDev < 0 {
+			return nil, fmt.Errorf("Incorrect standard deviation: %f\n", params.StdDev)
+		}
+		gen = func() float64 { return randNorm()*params.StdDev + params.Mean }
+		if dist == TruncNormal {
+			gen = func() float64 {
+				// redraw samples which are more than two standard deviations away from mean
+				x := randNorm()
+				for math.Abs(x) > 2.0 {
+					x = randNorm()
+				}
+				return x*params.StdDev + params.Mean
+			}
+		}
+	case Xavier:
+		epsilon := math.Sqrt(6.0) / math.Sqrt(float64(rows+cols))
+		gen = func() float64 { return randFloat()*(2*epsilon) - epsilon }
+	case He:
+		stdDev := math.Sqrt(2.0 / float64(cols))
+		gen = func() float64 { return randNorm() * stdDev }
+	default:
+		return nil, fmt.Errorf("Unsupported distribution: %s\n", dist)
+	}
+	randVals := make([]float64, rows*cols)
+	for i := range randVals {
+		randVals[i] = gen()
+	}
+	return mat64.NewDense(rows, cols, randVals), nil
+}
@@ -0,0 +1,97 @@
+package matrix
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeRandMxFrom(t *testing.T) {
+	assert := assert.New(t)
+
+	rows, cols := 100, 50
+	// Xavier distribution matches the default random matrices
+	expMx, err := MakeRandMxWithRand(rand.New(rand.NewSource(55)), rows, cols, 0.0, 1.0)
+	assert.NoError(err)
+	mx, err := MakeRandMxFrom(Xavier, DistParams{}, rows, cols, rand.New(rand.NewSource(55)))
+	assert.NoError(err)
+	assert.True(mat64.Equal(expMx, mx))
+	// uniform distribution stays within its interval
+	mx, err = MakeRandMxFrom(Uniform, DistParams{Min: -2.0, Max: -1.0}, rows, cols, rand.New(rand.NewSource(55)))
+	assert.NoError(err)
+	assert.True(mat64.Min(mx) >= -2.0)
+	assert.True(mat64.Max(mx) < -1.0)
+	// normal distribution has requested mean and standard deviation
+	params := DistParams{Mean: 3.0, StdDev: 0.5}
+	mx, err = MakeRandMxFrom(Normal, params, rows, cols, rand.New(rand.NewSource(55)))
+	assert.NoError(err)
+	mean, stdDev := stat.MeanStdDev(mx.RawMatrix().Data, nil)
+	assert.InDelta(3.0, mean, 0.05)
+	assert.InDelta(0.5, stdDev, 0.05)
+	// truncated normal distribution is within two standard deviations
+	mx, err = MakeRandMxFrom(TruncNormal, params, rows, cols, rand.New(rand.NewSource(55)))
+	assert.NoError(err)
+	assert.True(mat64.Min(mx) >= 2.0)
+	assert.True(mat64.Max(mx) <= 4.0)
+	// He distribution is scaled by the number of columns
+	mx, err = MakeRandMxFrom(He, DistParams{}, rows, cols, rand.New(rand.NewSource(55)))
+	assert.NoError(err)
+	_, stdDev = stat.MeanStdDev(mx.RawMatrix().Data, nil)
+	assert.InDelta(math.Sqrt(2.0/float64(cols)), stdDev, 0.02)
+	// incorrect dimensions, parameters and distributions
+	testCases := []struct {
+		dist   Dist
+		params DistParams
+		rows   int
+	}{
+		{Uniform, DistParams{}, 0},
+		{Uniform, DistParams{Min: 1.0, Max: 0.0}, rows},
+		{Normal, DistParams{StdDev: -1.0}, rows},
+		{Dist(10), DistParams{}, rows},
+	}
+	for _, tc := range testCases {
+		mx, err = MakeRandMxFrom(tc.dist, tc.params, tc.rows, cols, nil)
+		assert.Nil(mx)
+		assert.Error(err)
+	}
+}
+
+func TestShuffleRows(t *testing.T) {
+	assert := assert.New(t)
+
+	rows := 20
+	mx := mat64.NewDense(rows, 2, nil)
+	labels := mat64.NewVector(rows, nil)
+	for i := 0; i < rows; i++ {
+		mx.SetRow(i, []float64{float64(i), float64(-i)})
+		labels.SetVec(i, float64(i))
+	}
+	assert.NoError(ShuffleRows(mx, labels, rand.New(rand.NewSource(55))))
+	// rows stay aligned with their labels and every row is preserved
+	moved, seen := 0, make(map[float64]bool)
+	for i := 0; i < rows; i++ {
+		label := labels.At(i, 0)
+		assert.Equal(label, mx.At(i, 0))
+		assert.Equal(-label, mx.At(i, 1))
+		seen[label] = true
+		if label != float64(i) {
+			moved++
+		}
+	}
+	assert.Len(seen, rows)
+	assert.True(moved > 0)
+	// the same source produces the same permutation
+	other := mat64.NewDense(rows, 1, nil)
+	for i := 0; i < rows; i++ {
+		other.Set(i, 0, float64(i))
+	}
+	assert.NoError(ShuffleRows(other, nil, rand.New(rand.NewSource(55))))
+	assert.True(mat64.Equal(labels, other))
+	// nil matrix and mismatched labels
+	assert.Error(ShuffleRows(nil, labels, nil))
+	assert.Error(ShuffleRows(mx, mat64.NewVector(rows-1, nil), nil))
+}
@@ -0,0 +1,187 @@
+package matrix
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// SliceFunc calculates a function of each element of src slice and stores the results in dst slice
+type SliceFunc func(dst, src []float64)
+
+// ApplySlice applies slice function f to all elements of src matrix and stores the results in dst.
+// Unlike mat64.Dense.Apply it does not call a function per matrix element: contiguous matrices
+// are processed in a single call of f, matrix views are processed row by row.
+// dst and src may be the same matrix. It returns error if the matrices dimensions don't match.
+func ApplySlice(f SliceFunc, dst, src *mat64.Dense) error {
+	rows, cols, err := checkDims(dst, src)
+	if err != nil {
+		return err
+	}
+	dMx, sMx := dst.RawMatrix(), src.RawMatrix()
+	if dMx.Stride == cols && sMx.Stride == cols {
+		f(dMx.Data[:rows*cols], sMx.Data[:rows*cols])
+		return nil
+	}
+	for i := 0; i < rows; i++ {
+		f(dMx.Data[i*dMx.Stride:i*dMx.Stride+cols], sMx.Data[i*sMx.Stride:i*sMx.Stride+cols])
+	}
+	return nil
+}
+
+// checkDims returns the dimensions of src matrix or error if they don't match the dimensions of dst
+func checkDims(dst, src *mat64.Dense) (int, int, error) {
+	rows, cols := src.Dims()
+	dRows, dCols := dst.Dims()
+	if rows != dRows || cols != dCols {
+		return 0, 0, fmt.Errorf("Dimension mismatch. Dst: %d x %d, Src: %d x %d\n", dRows, dCols, rows, cols)
+	}
+	return rows, cols, nil
+}
+
+// ExpSlice calculates exponential of all slice elements
+func ExpSlice(dst, src []float64) {
+	for i, x := range src {
+		dst[i] = math.Exp(x)
+	}
+}
+
+// SigmoidSlice applies sigmoid function to all slice elements
+func SigmoidSlice(dst, src []float64) {
+	for i, x := range src {
+		dst[i] = Sigmoid(x)
+	}
+}
+
+// SigmoidGradSlice applies sigmoid derivation to all slice elements
+func SigmoidGradSlice(dst, src []float64) {
+	for i, x := range src {
+		s := Sigmoid(x)
+		dst[i] = s * (1 - s)
+	}
+}
+
+// TanhSlice applies tanh function to all slice elements
+func TanhSlice(dst, src []float64) {
+	for i, x := range src {
+		dst[i] = math.Tanh(x)
+	}
+}
+
+// TanhGradSlice applies tanh derivation to all slice elements
+func TanhGradSlice(dst, src []float64) {
+	for i, x := range src {
+		t := math.Tanh(x)
+		dst[i] = 1.0 - t*t
+	}
+}
+
+// TanhOutSlice applies re-scaled tanh function used in OUTPUT layer to all slice elements
+func TanhOutSlice(dst, src []float64) {
+	for i, x := range src {
+		dst[i] = 0.5 * (math.Tanh(x) + 1.0)
+	}
+}
+
+// SoftsignSlice applies softsign function to all slice elements
+func SoftsignSlice(dst, src []float64) {
+	for i, x := range src {
+		dst[i] = x / (1.0 + math.Abs(x))
+	}
+}
+
+// SoftsignGradSlice applies softsign derivation to all slice elements
+func SoftsignGradSlice(dst, src []float64) {
+	for i, x := range src {
+		d := 1.0 + math.Abs(x)
+		dst[i] = 1.0 / (d * d)
+	}
+}
+
+// SoftsignOutSlice applies re-scaled softsign function used in OUTPUT layer to all slice elements
+func SoftsignOutSlice(dst, src []float64) {
+	for i, x := range src {
+		dst[i] = 0.5 * (x/(1.0+math.Abs(x)) + 1.0)
+	}
+}
+
+// ReluSlice applies Relu to all slice elements
+func ReluSlice(dst, src []float64) {
+	for i, x := range src {
+		if x > 0 {
+			dst[i] = x
+			continue
+		}
+		dst[i] = 0.1 * x
+	}
+}
+
+// ReluGradSlice applies Relu "derivation" to all slice elements
+func ReluGradSlice(dst, src []float64) {
+	for i, x := range src {
+		if x > 0 {
+			dst[i] = 1.0
+			continue
+		}
+		dst[i] = 0.1
+	}
+}
+
+// PlainReluSlice applies Relu without leak to all slice elements
+func PlainReluSlice(dst, src []float64) {
+	for i, x := range src {
+		if x > 0 {
+			dst[i] = x
+			continue
+		}
+		dst[i] = 0.0
+	}
+}
+
+// PlainReluGradSlice applies Relu without leak "derivation" to all slice elements
+func PlainReluGradSlice(dst, src []float64) {
+	for i, x := range src {
+		if x > 0 {
+			dst[i] = 1.0
+			continue
+		}
+		dst[i] = 0.0
+	}
+}
+
+// SoftmaxRows calculates softmax of every row of matrix m and returns the results in a new matrix.
+// The elements of each row of the returned matrix are non-negative and sum up to 1.0.
+// It returns error if m is nil.
+func SoftmaxRows(m mat64.Matrix) (*mat64.Dense, error) {
+	if m == nil {
+		return nil, fmt.Errorf("Can't calculate softmax of matrix: %v\n", m)
+	}
+	out := mat64.DenseCopyOf(m)
+	if err := ApplySoftmaxRows(out, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ApplySoftmaxRows calculates softmax of every row of src matrix and stores the results in dst.
+// Row maximum is subtracted from all row elements before exponentiation so large inputs don't overflow.
+// dst and src may be the same matrix. It returns error if the matrices dimensions don't match.
+func ApplySoftmaxRows(dst, src *mat64.Dense) error {
+	rows, _, err := checkDims(dst, src)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < rows; i++ {
+		dRow, sRow := dst.RawRowView(i), src.RawRowView(i)
+		max := floats.Max(sRow)
+		sum := 0.0
+		for j, x := range sRow {
+			dRow[j] = math.Exp(x - max)
+			sum += dRow[j]
+		}
+		floats.Scale(1/sum, dRow)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package matrix
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSliceFuncs(t *testing.T) {
+	assert := assert.New(t)
+
+	inData := []float64{-2.0, -0.5, 0.0, 0.5, 2.0, 10.0}
+	inMx := mat64.NewDense(2, 3, inData)
+	// slice functions must match their per element counterparts
+	testCases := []struct {
+		slice SliceFunc
+		elem  func(int, int, float64) float64
+	}{
+		{ExpSlice, ExpMx},
+		{SigmoidSlice, SigmoidMx},
+		{SigmoidGradSlice, SigmoidGradMx},
+		{TanhSlice, TanhMx},
+		{TanhGradSlice, TanhGradMx},
+		{TanhOutSlice, TanhOutMx},
+		{SoftsignSlice, SoftsignMx},
+		{SoftsignGradSlice, SoftsignGradMx},
+		{SoftsignOutSlice, SoftsignOutMx},
+		{ReluSlice, ReluMx},
+		{ReluGradSlice, ReluGradMx},
+		{PlainReluSlice, PlainReluMx},
+		{PlainReluGradSlice, PlainReluGradMx},
+	}
+
+	for _, tc := range testCases {
+		expMx := new(mat64.Dense)
+		expMx.Apply(tc.elem, inMx)
+		outMx := mat64.NewDense(2, 3, nil)
+		assert.NoError(ApplySlice(tc.slice, outMx, inMx))
+		assert.True(mat64.EqualApprox(expMx, outMx, 1e-12))
+	}
+}
+
+func TestApplySlice(t *testing.T) {
+	assert := assert.New(t)
+
+	inData := []float64{1.0, 2.0, 3.0,
+		4.0, 5.0, 6.0,
+		7.0, 8.0, 9.0}
+	inMx := mat64.NewDense(3, 3, inData)
+	double := func(dst, src []float64) {
+		for i := range src {
+			dst[i] = 2 * src[i]
+		}
+	}
+	// matrix view is processed row by row
+	view := inMx.View(1, 1, 2, 2).(*mat64.Dense)
+	assert.NoError(ApplySlice(double, view, view))
+	expMx := mat64.NewDense(3, 3, []float64{1.0, 2.0, 3.0,
+		4.0, 10.0, 12.0,
+		7.0, 16.0, 18.0})
+	assert.True(mat64.Equal(expMx, inMx))
+	// contiguous matrix is processed at once
+	outMx := mat64.NewDense(3, 3, nil)
+	assert.NoError(ApplySlice(double, outMx, inMx))
+	expMx.Scale(2.0, expMx)
+	assert.True(mat64.Equal(expMx, outMx))
+	// dimension mismatch
+	assert.Error(ApplySlice(double, mat64.NewDense(2, 2, nil), inMx))
+}
+
+func TestSoftmaxRows(t *testing.T) {
+	assert := assert.New(t)
+
+	inMx := mat64.NewDense(2, 3, []float64{1.0, 2.0, 3.0,
+		1000.0, 1000.0, 1000.0})
+	outMx, err := SoftmaxRows(inMx)
+	assert.NoError(err)
+	// input is not modified
+	assert.Equal(1000.0, inMx.At(1, 0))
+	// softmax is invariant to shifting the row and does not overflow
+	e1, e2, e3 := math.Exp(-2.0), math.Exp(-1.0), 1.0
+	sum := e1 + e2 + e3
+	expMx := mat64.NewDense(2, 3, []float64{e1 / sum, e2 / sum, e3 / sum,
+		1.0 / 3, 1.0 / 3, 1.0 / 3})
+	assert.True(mat64.EqualApprox(expMx, outMx, 1e-12))
+	// softmax can be calculated in place
+	assert.NoError(ApplySoftmaxRows(inMx, inMx))
+	assert.True(mat64.EqualApprox(expMx, inMx, 1e-12))
+	// dimension mismatch
+	assert.Error(ApplySoftmaxRows(mat64.NewDense(2, 2, nil), inMx))
+	_, err = SoftmaxRows(nil)
+	assert.Error(err)
+}
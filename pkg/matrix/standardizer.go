@@ -0,0 +1,74 @@
+package matrix
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+// Standardizer standardizes matrix columns to zero mean and unit standard deviation.
+// Column statistics are computed once by Fit and then applied to any number of matrices
+// via Transform, so that new samples are standardized the same way as the fitted data.
+type Standardizer struct {
+	// Mean contains column mean values
+	Mean []float64 `json:"mean"`
+	// StdDev contains column standard deviation values
+	StdDev []float64 `json:"stddev"`
+}
+
+// Fit computes mean and standard deviation of every column of m and stores them in standardizer.
+// It returns error if m is nil.
+func (s *Standardizer) Fit(m mat64.Matrix) error {
+	if m == nil {
+		return fmt.Errorf("Can't fit matrix: %v\n", m)
+	}
+	rows, cols := m.Dims()
+	col := make([]float64, rows)
+	s.Mean = make([]float64, cols)
+	s.StdDev = make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		mat64.Col(col, j, m)
+		s.Mean[j], s.StdDev[j] = stat.MeanStdDev(col, nil)
+	}
+	return nil
+}
+
+// Transform centers the columns of m to the standardizer mean values and scales them by the
+// standardizer standard deviation values. It does not modify m: it returns new matrix instead.
+// It fails with error if m is nil or does not have the same number of columns as the standardizer.
+func (s *Standardizer) Transform(m mat64.Matrix) (*mat64.Dense, error) {
+	if err := s.check(m); err != nil {
+		return nil, err
+	}
+	out := mat64.DenseCopyOf(m)
+	out.Apply(func(i, j int, x float64) float64 {
+		return (x - s.Mean[j]) / s.StdDev[j]
+	}, out)
+	return out, nil
+}
+
+// Inverse reverts Transform: it scales the columns of m by the standardizer standard deviation
+// values and shifts them by the standardizer mean values. It does not modify m.
+// It fails with error if m is nil or does not have the same number of columns as the standardizer.
+func (s *Standardizer) Inverse(m mat64.Matrix) (*mat64.Dense, error) {
+	if err := s.check(m); err != nil {
+		return nil, err
+	}
+	out := mat64.DenseCopyOf(m)
+	out.Apply(func(i, j int, x float64) float64 {
+		return x*s.StdDev[j] + s.Mean[j]
+	}, out)
+	return out, nil
+}
+
+// check checks if m can be transformed by standardizer
+func (s *Standardizer) check(m mat64.Matrix) error {
+	if m == nil {
+		return fmt.Errorf("Can't standardize matrix: %v\n", m)
+	}
+	if _, cols := m.Dims(); cols != len(s.Mean) || cols != len(s.StdDev) {
+		return fmt.Errorf("Dimension mismatch. Standardizer: %d, Input: %d\n", len(s.Mean), cols)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package matrix
+
+import (
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandardizer(t *testing.T) {
+	assert := assert.New(t)
+
+	mx := mat64.NewDense(3, 2, []float64{1.0, 10.0,
+		2.0, 20.0,
+		3.0, 30.0})
+	s := new(Standardizer)
+	// nil matrix can't be fitted
+	assert.Error(s.Fit(nil))
+	assert.NoError(s.Fit(mx))
+	assert.EqualValues([]float64{2.0, 20.0}, s.Mean)
+	assert.EqualValues([]float64{1.0, 10.0}, s.StdDev)
+	// standardized columns have zero mean and unit standard deviation
+	outMx, err := s.Transform(mx)
+	assert.NoError(err)
+	expMx := mat64.NewDense(3, 2, []float64{-1.0, -1.0,
+		0.0, 0.0,
+		1.0, 1.0})
+	assert.True(mat64.EqualApprox(expMx, outMx, 1e-12))
+	// input is not modified
+	assert.Equal(1.0, mx.At(0, 0))
+	// Inverse reverts Transform
+	invMx, err := s.Inverse(outMx)
+	assert.NoError(err)
+	assert.True(mat64.EqualApprox(mx, invMx, 1e-12))
+	// nil matrix and dimension mismatch
+	outMx, err = s.Transform(nil)
+	assert.Nil(outMx)
+	assert.Error(err)
+	outMx, err = s.Transform(mat64.NewDense(3, 3, nil))
+	assert.Nil(outMx)
+	assert.Error(err)
+	invMx, err = s.Inverse(mat64.NewDense(3, 1, nil))
+	assert.Nil(invMx)
+	assert.Error(err)
+}
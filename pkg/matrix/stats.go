@@ -0,0 +1,39 @@
+package matrix
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+)
+
+// Cov calculates covariance matrix of the columns of matrix m: every row of m is a sample,
+// every column is a feature. Covariances are normalized by the number of samples minus one.
+// It returns error if m is nil or if it contains less than two samples.
+func Cov(m mat64.Matrix) (*mat64.SymDense, error) {
+	if err := checkSamples(m); err != nil {
+		return nil, err
+	}
+	return stat.CovarianceMatrix(nil, m, nil), nil
+}
+
+// Corr calculates Pearson correlation matrix of the columns of matrix m: every row of m
+// is a sample, every column is a feature. Correlations of constant columns are NaN.
+// It returns error if m is nil or if it contains less than two samples.
+func Corr(m mat64.Matrix) (*mat64.SymDense, error) {
+	if err := checkSamples(m); err != nil {
+		return nil, err
+	}
+	return stat.CorrelationMatrix(nil, m, nil), nil
+}
+
+// checkSamples checks if m contains enough samples to calculate its column statistics
+func checkSamples(m mat64.Matrix) error {
+	if m == nil {
+		return fmt.Errorf("Can't calculate statistics of matrix: %v\n", m)
+	}
+	if rows, _ := m.Dims(); rows < 2 {
+		return fmt.Errorf("Insufficient number of samples: %d\n", rows)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package matrix
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCovCorr(t *testing.T) {
+	assert := assert.New(t)
+
+	// the second column is twice the first one, the third one is its opposite
+	mx := mat64.NewDense(3, 3, []float64{1.0, 2.0, -1.0,
+		2.0, 4.0, -2.0,
+		3.0, 6.0, -3.0})
+	cov, err := Cov(mx)
+	assert.NoError(err)
+	expCov := mat64.NewSymDense(3, []float64{1.0, 2.0, -1.0,
+		2.0, 4.0, -2.0,
+		-1.0, -2.0, 1.0})
+	assert.True(mat64.EqualApprox(expCov, cov, 1e-12))
+	corr, err := Corr(mx)
+	assert.NoError(err)
+	expCorr := mat64.NewSymDense(3, []float64{1.0, 1.0, -1.0,
+		1.0, 1.0, -1.0,
+		-1.0, -1.0, 1.0})
+	assert.True(mat64.EqualApprox(expCorr, corr, 1e-12))
+	// correlation of constant column is not defined
+	mx.SetCol(2, []float64{5.0, 5.0, 5.0})
+	corr, err = Corr(mx)
+	assert.NoError(err)
+	assert.True(math.IsNaN(corr.At(0, 2)))
+	// nil matrix and insufficient number of samples
+	cov, err = Cov(nil)
+	assert.Nil(cov)
+	assert.Error(err)
+	corr, err = Corr(mat64.NewDense(1, 3, nil))
+	assert.Nil(corr)
+	assert.Error(err)
+}
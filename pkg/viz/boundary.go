@@ -0,0 +1,191 @@
+package viz
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// palette contains colors of classes: classes are assigned colors in the order of their labels
+var palette = []color.RGBA{
+	{R: 31, G: 119, B: 180, A: 255},
+	{R: 255, G: 127, B: 14, A: 255},
+	{R: 44, G: 160, B: 44, A: 255},
+	{R: 214, G: 39, B: 40, A: 255},
+	{R: 148, G: 103, B: 189, A: 255},
+	{R: 140, G: 86, B: 75, A: 255},
+	{R: 227, G: 119, B: 194, A: 255},
+	{R: 127, G: 127, B: 127, A: 255},
+	{R: 188, G: 189, B: 34, A: 255},
+	{R: 23, G: 190, B: 207, A: 255},
+}
+
+// margin is the fraction of feature range the grid extends beyond the samples on every side
+const margin = 0.05
+
+// Classifier returns class labels of the samples stored in rows of mx
+type Classifier func(mx *mat64.Dense) ([]float64, error)
+
+// Boundary contains decision regions of a classifier of two features:
+// the classes of the points of a regular grid spanning the feature space
+type Boundary struct {
+	// Min contains the minimum values of both features covered by the grid
+	Min [2]float64
+	// Max contains the maximum values of both features covered by the grid
+	Max [2]float64
+	// Steps is the number of grid points along each feature
+	Steps int
+	// Classes contains classes of the grid points stored row by row: the first row
+	// contains the points with the minimum value of the second feature
+	Classes []float64
+}
+
+// NewBoundary creates decision boundary of classify on a grid of steps×steps points spanning
+// the range of both features of the samples stored in rows of mx extended by 5% on every side.
+// It fails with error if mx does not contain two features, if steps is smaller than 2
+// or if the classification fails.
+func NewBoundary(mx mat64.Matrix, steps int, classify Classifier) (*Boundary, error) {
+	if mx == nil {
+		return nil, fmt.Errorf("Incorrect matrix supplied: %v\n", mx)
+	}
+	rows, cols := mx.Dims()
+	if rows == 0 || cols != 2 {
+		return nil, fmt.Errorf("Decision boundary requires 2 features: %d\n", cols)
+	}
+	if steps < 2 {
+		return nil, fmt.Errorf("Incorrect number of grid steps: %d\n", steps)
+	}
+	b := &Boundary{Steps: steps}
+	for j := 0; j < 2; j++ {
+		b.Min[j], b.Max[j] = mx.At(0, j), mx.At(0, j)
+		for i := 1; i < rows; i++ {
+			if v := mx.At(i, j); v < b.Min[j] {
+				b.Min[j] = v
+			} else if v > b.Max[j] {
+				b.Max[j] = v
+			}
+		}
+		pad := margin * (b.Max[j] - b.Min[j])
+		if pad == 0.0 {
+			pad = 1.0
+		}
+		b.Min[j], b.Max[j] = b.Min[j]-pad, b.Max[j]+pad
+	}
+	classes, err := classify(b.Grid())
+	if err != nil {
+		return nil, err
+	}
+	if len(classes) != steps*steps {
+		return nil, fmt.Errorf("Classes count mismatch. Grid: %d, Classes: %d\n", steps*steps, len(classes))
+	}
+	b.Classes = classes
+	return b, nil
+}
+
+// at returns the value of feature j at grid step k
+func (b *Boundary) at(j, k int) float64 {
+	return b.Min[j] + float64(k)*(b.Max[j]-b.Min[j])/float64(b.Steps-1)
+}
+
+// Grid returns the grid points stored in rows in the same order as Classes
+func (b *Boundary) Grid() *mat64.Dense {
+	grid := mat64.NewDense(b.Steps*b.Steps, 2, nil)
+	for i := 0; i < b.Steps; i++ {
+		for j := 0; j < b.Steps; j++ {
+			grid.SetRow(i*b.Steps+j, []float64{b.at(0, j), b.at(1, i)})
+		}
+	}
+	return grid
+}
+
+// WriteCSV writes the grid points along with their classes to w in CSV format with x,y,class header
+func (b *Boundary) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"x", "y", "class"}); err != nil {
+		return err
+	}
+	grid := b.Grid()
+	for k, class := range b.Classes {
+		record := []string{
+			strconv.FormatFloat(grid.At(k, 0), 'f', -1, 64),
+			strconv.FormatFloat(grid.At(k, 1), 'f', -1, 64),
+			strconv.FormatFloat(class, 'f', -1, 64),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Image renders decision regions as an image in which every grid point is drawn as cell×cell pixels
+// square tinted by the color of its class. Samples stored in rows of points are overlaid as dots of
+// the color of their labels; points are drawn black if labels are nil. The second feature grows
+// upwards. It fails with error if cell is not positive or if points don't match the labels.
+func (b *Boundary) Image(cell int, points mat64.Matrix, labels []float64) (*image.RGBA, error) {
+	if cell <= 0 {
+		return nil, fmt.Errorf("Incorrect cell size: %d\n", cell)
+	}
+	var rows int
+	if points != nil {
+		var cols int
+		if rows, cols = points.Dims(); cols != 2 || (labels != nil && len(labels) != rows) {
+			return nil, fmt.Errorf("Incorrect points supplied: %d features, %d labels\n", cols, len(labels))
+		}
+	}
+	// classes are assigned colors in the order of their labels
+	colors := make(map[float64]color.RGBA)
+	var classes []float64
+	for _, class := range append(append([]float64(nil), b.Classes...), labels...) {
+		if _, ok := colors[class]; !ok {
+			colors[class] = color.RGBA{}
+			classes = append(classes, class)
+		}
+	}
+	sort.Float64s(classes)
+	for i, class := range classes {
+		colors[class] = palette[i%len(palette)]
+	}
+	size := b.Steps * cell
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for k, class := range b.Classes {
+		c := colors[class]
+		// regions are tinted so that the points stand out
+		tint := color.RGBA{R: c.R/3 + 170, G: c.G/3 + 170, B: c.B/3 + 170, A: 255}
+		fill(img, (k%b.Steps)*cell, size-(k/b.Steps+1)*cell, cell, tint)
+	}
+	for i := 0; i < rows; i++ {
+		c := color.RGBA{A: 255}
+		if labels != nil {
+			c = colors[labels[i]]
+		}
+		x := int((points.At(i, 0)-b.Min[0])/(b.Max[0]-b.Min[0])*float64(size-cell)) + cell/2
+		y := size - 1 - (int((points.At(i, 1)-b.Min[1])/(b.Max[1]-b.Min[1])*float64(size-cell)) + cell/2)
+		dot(img, x, y, c)
+	}
+	return img, nil
+}
+
+// dot draws a point of color c outlined in black centered at x, y
+func dot(img *image.RGBA, x, y int, c color.RGBA) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			p := image.Pt(x+dx, y+dy)
+			if !p.In(img.Bounds()) || dx*dx+dy*dy > 5 {
+				continue
+			}
+			if dx*dx+dy*dy > 2 {
+				img.SetRGBA(p.X, p.Y, color.RGBA{A: 255})
+				continue
+			}
+			img.SetRGBA(p.X, p.Y, c)
+		}
+	}
+}
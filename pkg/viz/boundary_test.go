@@ -0,0 +1,78 @@
+package viz
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoundary(t *testing.T) {
+	assert := assert.New(t)
+	points := mat64.NewDense(2, 2, []float64{
+		0.0, 0.0,
+		10.0, 10.0,
+	})
+	// the first feature larger than the second is class 2
+	classify := func(mx *mat64.Dense) ([]float64, error) {
+		rows, _ := mx.Dims()
+		classes := make([]float64, rows)
+		for i := range classes {
+			classes[i] = 1.0
+			if mx.At(i, 0) > mx.At(i, 1) {
+				classes[i] = 2.0
+			}
+		}
+		return classes, nil
+	}
+	b, err := NewBoundary(points, 3, classify)
+	assert.NoError(err)
+	assert.Equal([2]float64{-0.5, -0.5}, b.Min)
+	assert.Equal([2]float64{10.5, 10.5}, b.Max)
+	assert.Equal([]float64{1, 2, 2, 1, 1, 2, 1, 1, 1}, b.Classes)
+	grid := b.Grid()
+	assert.Equal([]float64{5.0, -0.5}, grid.RawRowView(1))
+	// CSV contains header and a record per grid point
+	var buf bytes.Buffer
+	assert.NoError(b.WriteCSV(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(lines, 10)
+	assert.Equal("x,y,class", lines[0])
+	assert.Equal("5,-0.5,2", lines[2])
+	// the second feature grows upwards and points are drawn in the colors of their labels
+	img, err := b.Image(10, points, []float64{1.0, 2.0})
+	assert.NoError(err)
+	assert.Equal(30, img.Bounds().Dx())
+	assert.Equal(30, img.Bounds().Dy())
+	assert.Equal(img.RGBAAt(0, 0), img.RGBAAt(29, 0))
+	assert.NotEqual(img.RGBAAt(0, 0), img.RGBAAt(29, 29))
+	assert.Equal(palette[0], img.RGBAAt(5, 24))
+	assert.Equal(palette[1], img.RGBAAt(24, 5))
+	// points are drawn black without labels
+	img, err = b.Image(10, points, nil)
+	assert.NoError(err)
+	assert.Equal(color.RGBA{A: 255}, img.RGBAAt(5, 24))
+	// incorrect parameters
+	_, err = b.Image(0, points, nil)
+	assert.Error(err)
+	_, err = b.Image(10, points, []float64{1.0})
+	assert.Error(err)
+	_, err = NewBoundary(nil, 3, classify)
+	assert.Error(err)
+	_, err = NewBoundary(mat64.NewDense(2, 3, nil), 3, classify)
+	assert.Error(err)
+	_, err = NewBoundary(points, 1, classify)
+	assert.Error(err)
+	_, err = NewBoundary(points, 3, func(mx *mat64.Dense) ([]float64, error) {
+		return nil, errors.New("failed")
+	})
+	assert.Error(err)
+	_, err = NewBoundary(points, 3, func(mx *mat64.Dense) ([]float64, error) {
+		return []float64{1.0}, nil
+	})
+	assert.Error(err)
+}
@@ -0,0 +1,121 @@
+package viz
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+)
+
+// border is the color of borders separating heatmap tiles
+var border = color.RGBA{R: 128, G: 128, B: 128, A: 255}
+
+// heat returns heatmap color of value v scaled by the maximum absolute value max:
+// positive values are red, negative values are blue and zero is white
+func heat(v, max float64) color.RGBA {
+	if max == 0.0 || math.IsNaN(v) {
+		return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	t := math.Min(math.Abs(v)/max, 1.0)
+	c := uint8(255 * (1 - t))
+	if v < 0 {
+		return color.RGBA{R: c, G: c, B: 255, A: 255}
+	}
+	return color.RGBA{R: 255, G: c, B: c, A: 255}
+}
+
+// maxAbs returns the maximum absolute value of vals ignoring NaN values
+func maxAbs(vals []float64) float64 {
+	max := 0.0
+	for _, v := range vals {
+		if a := math.Abs(v); a > max {
+			max = a
+		}
+	}
+	return max
+}
+
+// fill draws cell×cell square of color c with top left corner at x, y
+func fill(img *image.RGBA, x, y, cell int, c color.RGBA) {
+	for i := 0; i < cell; i++ {
+		for j := 0; j < cell; j++ {
+			img.SetRGBA(x+j, y+i, c)
+		}
+	}
+}
+
+// Heatmap renders matrix mx as heatmap image in which every matrix element is drawn as
+// a cell×cell pixels square. Positive values are red, negative values are blue and zero
+// is white: the color intensity is proportional to the absolute value relative to the
+// maximum absolute value of mx. It fails with error if mx is nil or cell is not positive.
+func Heatmap(mx mat64.Matrix, cell int) (*image.RGBA, error) {
+	if mx == nil {
+		return nil, fmt.Errorf("Incorrect matrix supplied: %v\n", mx)
+	}
+	if cell <= 0 {
+		return nil, fmt.Errorf("Incorrect cell size: %d\n", cell)
+	}
+	vals, err := matrix.Flatten(mx, matrix.RowMajor)
+	if err != nil {
+		return nil, err
+	}
+	rows, cols := mx.Dims()
+	max := maxAbs(vals)
+	img := image.NewRGBA(image.Rect(0, 0, cols*cell, rows*cell))
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			fill(img, j*cell, i*cell, cell, heat(vals[i*cols+j], max))
+		}
+	}
+	return img, nil
+}
+
+// Tiles renders every row of mx reshaped to width×height image as a separate heatmap tile.
+// Row elements are laid out in the image in order o, e.g. matrix.ColMajor for images stored
+// column by column. Tiles are arranged in a square grid, separated by a single cell border and
+// every tile is colored relative to its own maximum absolute value, so that the patterns learnt
+// by neurons with small weights are visible, too. It fails with error if mx is nil, if the
+// number of mx columns is not width×height, if cell is not positive or if o is not supported.
+func Tiles(mx mat64.Matrix, width, height, cell int, o matrix.Order) (*image.RGBA, error) {
+	if mx == nil {
+		return nil, fmt.Errorf("Incorrect matrix supplied: %v\n", mx)
+	}
+	rows, cols := mx.Dims()
+	if width <= 0 || height <= 0 || width*height != cols {
+		return nil, fmt.Errorf("Image dimensions %dx%d don't match matrix columns: %d\n", width, height, cols)
+	}
+	if cell <= 0 {
+		return nil, fmt.Errorf("Incorrect cell size: %d\n", cell)
+	}
+	if o != matrix.RowMajor && o != matrix.ColMajor {
+		return nil, fmt.Errorf("Unsupported order: %s\n", o)
+	}
+	gridCols := int(math.Ceil(math.Sqrt(float64(rows))))
+	gridRows := (rows + gridCols - 1) / gridCols
+	tileW, tileH := (width+1)*cell, (height+1)*cell
+	img := image.NewRGBA(image.Rect(0, 0, gridCols*tileW+cell, gridRows*tileH+cell))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			img.SetRGBA(x, y, border)
+		}
+	}
+	row := make([]float64, cols)
+	for k := 0; k < rows; k++ {
+		mat64.Row(row, k, mx)
+		max := maxAbs(row)
+		x0, y0 := (k%gridCols)*tileW+cell, (k/gridCols)*tileH+cell
+		for i := 0; i < height; i++ {
+			for j := 0; j < width; j++ {
+				v := row[i*width+j]
+				if o == matrix.ColMajor {
+					v = row[j*height+i]
+				}
+				fill(img, x0+j*cell, y0+i*cell, cell, heat(v, max))
+			}
+		}
+	}
+	return img, nil
+}
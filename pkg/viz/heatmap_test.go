@@ -0,0 +1,69 @@
+package viz
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeatmap(t *testing.T) {
+	assert := assert.New(t)
+	mx := mat64.NewDense(2, 3, []float64{
+		2.0, -2.0, 0.0,
+		1.0, -1.0, 0.5,
+	})
+	img, err := Heatmap(mx, 2)
+	assert.NoError(err)
+	assert.Equal(6, img.Bounds().Dx())
+	assert.Equal(4, img.Bounds().Dy())
+	// maximum values are fully saturated, zero is white
+	assert.Equal(color.RGBA{R: 255, A: 255}, img.RGBAAt(0, 0))
+	assert.Equal(color.RGBA{R: 255, A: 255}, img.RGBAAt(1, 1))
+	assert.Equal(color.RGBA{B: 255, A: 255}, img.RGBAAt(2, 0))
+	assert.Equal(color.RGBA{R: 255, G: 255, B: 255, A: 255}, img.RGBAAt(4, 0))
+	// colors are scaled by the maximum absolute value
+	assert.Equal(color.RGBA{R: 127, G: 127, B: 255, A: 255}, img.RGBAAt(2, 2))
+	// incorrect parameters
+	_, err = Heatmap(nil, 2)
+	assert.Error(err)
+	_, err = Heatmap(mx, 0)
+	assert.Error(err)
+}
+
+func TestTiles(t *testing.T) {
+	assert := assert.New(t)
+	// three 2x2 images stored row by row
+	mx := mat64.NewDense(3, 4, []float64{
+		1.0, 0.0, 0.0, 0.0,
+		0.0, 2.0, 0.0, 0.0,
+		0.0, 0.0, 0.0, -0.1,
+	})
+	img, err := Tiles(mx, 2, 2, 1, matrix.RowMajor)
+	assert.NoError(err)
+	// 2x2 grid of tiles: tiles are 2 pixels wide plus 1 pixel border
+	assert.Equal(7, img.Bounds().Dx())
+	assert.Equal(7, img.Bounds().Dy())
+	assert.Equal(border, img.RGBAAt(0, 0))
+	assert.Equal(border, img.RGBAAt(3, 1))
+	red := color.RGBA{R: 255, A: 255}
+	assert.Equal(red, img.RGBAAt(1, 1))
+	assert.Equal(red, img.RGBAAt(5, 1))
+	// tiles are scaled by their own maximum absolute value
+	assert.Equal(color.RGBA{B: 255, A: 255}, img.RGBAAt(2, 5))
+	// column major images are transposed
+	img, err = Tiles(mx, 2, 2, 1, matrix.ColMajor)
+	assert.NoError(err)
+	assert.Equal(red, img.RGBAAt(4, 2))
+	// incorrect parameters
+	_, err = Tiles(nil, 2, 2, 1, matrix.RowMajor)
+	assert.Error(err)
+	_, err = Tiles(mx, 3, 2, 1, matrix.RowMajor)
+	assert.Error(err)
+	_, err = Tiles(mx, 2, 2, 0, matrix.RowMajor)
+	assert.Error(err)
+	_, err = Tiles(mx, 2, 2, 1, matrix.Order(10))
+	assert.Error(err)
+}
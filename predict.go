@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+)
+
+// rowReader reads a single row of features. It returns io.EOF when there are no more rows.
+type rowReader func() ([]float64, error)
+
+// rowReaders maps stdin formats to functions which create row readers
+var rowReaders = map[string]func(io.Reader) rowReader{
+	"csv":  csvRowReader,
+	"json": jsonRowReader,
+}
+
+// csvRowReader returns reader which reads rows of features from CSV records
+func csvRowReader(r io.Reader) rowReader {
+	cr := csv.NewReader(r)
+	return func() ([]float64, error) {
+		record, err := cr.Read()
+		if err != nil {
+			return nil, err
+		}
+		row := make([]float64, len(record))
+		for i, field := range record {
+			if row[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+				return nil, err
+			}
+		}
+		return row, nil
+	}
+}
+
+// jsonRowReader returns reader which reads rows of features from JSON lines: each line must
+// contain JSON array of numbers. Empty lines are skipped.
+func jsonRowReader(r io.Reader) rowReader {
+	scanner := bufio.NewScanner(r)
+	// rows with many features can be very long
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	return func() ([]float64, error) {
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var row []float64
+			if err := json.Unmarshal([]byte(line), &row); err != nil {
+				return nil, err
+			}
+			return row, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+// runPredict loads saved neural network model and writes the labels predicted for
+// the samples stored in a data set file along with per-class probabilities in CSV
+// or JSON lines format.
+// If the data set path is "-", samples are read from stdin and each prediction is written
+// as soon as its sample has been read so that the command can be used in Unix pipelines.
+func runPredict(args []string) error {
+	fs := flag.NewFlagSet("predict", flag.ExitOnError)
+	modelPath := fs.String("model", "", "Path to a saved neural net model")
+	dataPath := fs.String("data", "", "Path to data set with features to classify or - to read samples from stdin")
+	labeled := fs.Bool("labeled", false, "Is the data set labeled")
+	scale := fs.Bool("scale", false, "Require data scaling unless the model contains scaler")
+	outPath := fs.String("out", "", "Path to output file (default: stdout)")
+	stdinFormat := fs.String("stdin-format", "csv", "Format of samples read from stdin: csv or json (JSON lines)")
+	format := fs.String("format", "csv", "Format of predictions: csv or jsonl (JSON lines)")
+	fs.Parse(args)
+	// path to model is mandatory
+	if *modelPath == "" {
+		return errors.New("You must specify path to model file")
+	}
+	// path to data is mandatory
+	if *dataPath == "" {
+		return errors.New("You must specify path to data set")
+	}
+	newRowReader, ok := rowReaders[*stdinFormat]
+	if !ok {
+		return fmt.Errorf("Unsupported stdin format: %s", *stdinFormat)
+	}
+	net, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	// scaling requires the whole data set unless the model contains scaler
+	if *dataPath == "-" && *scale && net.Scaler() == nil {
+		return errors.New("Samples read from stdin can't be scaled without model scaler")
+	}
+	// write predictions to stdout unless output file is specified
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	w, err := neural.NewPredictionWriter(out, net, *format)
+	if err != nil {
+		return err
+	}
+	if *dataPath == "-" {
+		return predictStream(net, newRowReader(os.Stdin), *labeled, w)
+	}
+	// load new data set from provided file
+	ds, err := dataset.NewDataSet(*dataPath, *labeled)
+	if err != nil {
+		return err
+	}
+	// scale features with the saved scaler or if scaling is required
+	features, err := scaleFeatures(net, ds.Features(), *scale)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(features); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// predictStream classifies samples read by rowReader one at a time and writes each prediction
+// to w as soon as it is available. If labeled is true, the last column of each row is ignored.
+func predictStream(net *neural.Network, next rowReader, labeled bool, w *neural.PredictionWriter) error {
+	for line := 1; ; line++ {
+		row, err := next()
+		if err == io.EOF {
+			return w.Flush()
+		}
+		if err != nil {
+			return fmt.Errorf("Error reading sample %d: %s", line, err)
+		}
+		if labeled {
+			if len(row) < 2 {
+				return fmt.Errorf("Labeled sample %d contains no features", line)
+			}
+			row = row[:len(row)-1]
+		}
+		features, err := scaleFeatures(net, mat64.NewDense(1, len(row), row), false)
+		if err != nil {
+			return fmt.Errorf("Error scaling sample %d: %s", line, err)
+		}
+		if err := w.Write(features); err != nil {
+			return fmt.Errorf("Error classifying sample %d: %s", line, err)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+}
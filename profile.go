@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+var (
+	// cpuProfile is a path to the file CPU profile is written to
+	cpuProfile string
+	// memProfile is a path to the file memory profile is written to
+	memProfile string
+	// cpuFile is the open CPU profile file
+	cpuFile *os.File
+)
+
+// startProfiling starts CPU profiling if it has been requested
+func startProfiling() error {
+	if cpuProfile == "" {
+		return nil
+	}
+	f, err := os.Create(cpuProfile)
+	if err != nil {
+		return err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	cpuFile = f
+	return nil
+}
+
+// stopProfiling stops CPU profiling and writes memory profile if they have been requested
+func stopProfiling() error {
+	if cpuFile != nil {
+		pprof.StopCPUProfile()
+		if err := cpuFile.Close(); err != nil {
+			return err
+		}
+		cpuFile = nil
+	}
+	if memProfile == "" {
+		return nil
+	}
+	f, err := os.Create(memProfile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// get up-to-date statistics of allocated memory
+	runtime.GC()
+	return pprof.WriteHeapProfile(f)
+}
+
+// exit writes the requested profiles, closes the metrics log and exits the program
+// with the given status code
+func exit(code int) {
+	closeMetrics()
+	stopProfiling()
+	os.Exit(code)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+)
+
+// progress displays neural network training progress updated in place on a single line
+type progress struct {
+	// w is a writer the progress is written to
+	w io.Writer
+	// iters is the maximum number of training iterations
+	iters int
+	// unit is the name of training iteration: Iteration, Epoch or Generation
+	unit string
+	// started is the time the training started
+	started time.Time
+	// pending is true if the progress line has not been finished yet
+	pending bool
+}
+
+// newProgress creates new training progress display which writes to w
+func newProgress(w io.Writer) *progress {
+	return &progress{w: w}
+}
+
+// start starts measuring the progress of the training configured by c.
+// Epoch based optimization methods report their progress in epochs rather than iterations,
+// evolutionary training reports its progress in generations.
+func (p *progress) start(c *config.TrainConfig) {
+	switch {
+	case c.Evolution != nil:
+		p.iters, p.unit = c.Evolution.Generations, "Generation"
+	case c.Optimize.Method == "sgd":
+		p.iters, p.unit = c.Optimize.Epochs, "Epoch"
+	default:
+		p.iters, p.unit = c.Optimize.Iterations, "Iteration"
+	}
+	p.started = time.Now()
+}
+
+// update updates the displayed progress with training metrics.
+// It implements neural.Callback function.
+func (p *progress) update(m *neural.Metrics) error {
+	elapsed := time.Since(p.started)
+	// estimate remaining time from the average iteration duration
+	eta := time.Duration(0)
+	if m.Iter > 0 && m.Iter < p.iters {
+		eta = elapsed / time.Duration(m.Iter) * time.Duration(p.iters-m.Iter)
+	}
+	p.pending = true
+	_, err := fmt.Fprintf(p.w, "\r%s: %d/%d Cost: %f Gradient norm: %f Elapsed: %s ETA: %s   ",
+		p.unit, m.Iter, p.iters, m.Cost, m.GradNorm, seconds(elapsed), seconds(eta))
+	return err
+}
+
+// done finishes the progress line so that the following output starts on a new line
+func (p *progress) done() {
+	if p.pending {
+		fmt.Fprintln(p.w)
+		p.pending = false
+	}
+}
+
+// lineWriter finishes the progress line before writing to the underlying writer
+type lineWriter struct {
+	p *progress
+	w io.Writer
+}
+
+// Write implements io.Writer interface
+func (lw lineWriter) Write(b []byte) (int, error) {
+	lw.p.done()
+	return lw.w.Write(b)
+}
+
+// seconds truncates duration to whole seconds
+func seconds(d time.Duration) time.Duration {
+	return d - d%time.Second
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/milosgajdos83/go-neural/neural"
+	"github.com/milosgajdos83/go-neural/pkg/config"
+	"github.com/milosgajdos83/go-neural/pkg/dataset"
+	"gopkg.in/yaml.v1"
+)
+
+// grid is a hyperparameter grid: it contains the values of manifest parameters to sweep.
+// Parameters without any values are not swept: their manifest values are used instead.
+type grid struct {
+	// Lambda contains values of regularization parameter
+	Lambda []float64 `yaml:"lambda"`
+	// Hidden contains hidden layers sizes
+	Hidden [][]int `yaml:"hidden"`
+	// Activation contains hidden layers activation functions
+	Activation []string `yaml:"activation"`
+	// Cost contains cost functions
+	Cost []string `yaml:"cost"`
+	// Iterations contains numbers of optimization iterations
+	Iterations []int `yaml:"iterations"`
+}
+
+// sweepParam is a swept manifest parameter
+type sweepParam struct {
+	// name is parameter name
+	name string
+	// values contains printable parameter values
+	values []string
+	// set contains functions which set the parameter values in manifest
+	set []func(*config.Manifest)
+}
+
+// params returns swept parameters of the grid
+func (g *grid) params() []sweepParam {
+	var params []sweepParam
+	if len(g.Lambda) > 0 {
+		p := sweepParam{name: "lambda"}
+		for _, v := range g.Lambda {
+			v := v
+			p.values = append(p.values, fmt.Sprintf("%g", v))
+			p.set = append(p.set, func(m *config.Manifest) { m.Training.Params.Lambda = v })
+		}
+		params = append(params, p)
+	}
+	if len(g.Hidden) > 0 {
+		p := sweepParam{name: "hidden"}
+		for _, v := range g.Hidden {
+			v := v
+			p.values = append(p.values, fmt.Sprint(v))
+			p.set = append(p.set, func(m *config.Manifest) { m.Network.Hidden.Size = v })
+		}
+		params = append(params, p)
+	}
+	if len(g.Activation) > 0 {
+		p := sweepParam{name: "activation"}
+		for _, v := range g.Activation {
+			v := v
+			p.values = append(p.values, v)
+			p.set = append(p.set, func(m *config.Manifest) { m.Network.Hidden.Activation = v })
+		}
+		params = append(params, p)
+	}
+	if len(g.Cost) > 0 {
+		p := sweepParam{name: "cost"}
+		for _, v := range g.Cost {
+			v := v
+			p.values = append(p.values, v)
+			p.set = append(p.set, func(m *config.Manifest) { m.Training.Cost = v })
+		}
+		params = append(params, p)
+	}
+	if len(g.Iterations) > 0 {
+		p := sweepParam{name: "iterations"}
+		for _, v := range g.Iterations {
+			v := v
+			p.values = append(p.values, fmt.Sprint(v))
+			p.set = append(p.set, func(m *config.Manifest) { m.Training.Optimize.Iterations = v })
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// loadGrid loads hyperparameter grid from the YAML file stored in path
+func loadGrid(path string) (*grid, error) {
+	gridData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	g := new(grid)
+	if err := yaml.Unmarshal(gridData, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// sweepResult is a result of training the network with a particular combination of parameters
+type sweepResult struct {
+	// values contains printable values of swept parameters
+	values []string
+	// accuracy is the percentage of successfully classified test samples
+	accuracy float64
+	// manifest is the manifest the network has been trained with
+	manifest config.Manifest
+}
+
+// byAccuracy sorts sweep results by accuracy in descending order
+type byAccuracy []*sweepResult
+
+func (r byAccuracy) Len() int           { return len(r) }
+func (r byAccuracy) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r byAccuracy) Less(i, j int) bool { return r[i].accuracy > r[j].accuracy }
+
+// runSweep trains the network defined in manifest with every combination of parameters
+// stored in hyperparameter grid file, evaluates each trained network on held-out samples,
+// prints the results ranked by accuracy and writes the best manifest to a file.
+func runSweep(args []string) error {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	manPath := fs.String("manifest", "", "Path to a neural net manifest file")
+	gridPath := fs.String("grid", "", "Path to a hyperparameter grid file")
+	dataPath := fs.String("data", "", "Path to labeled data set")
+	scale := fs.Bool("scale", false, "Require data scaling")
+	split := fs.Float64("split", 0.8, "Fraction of samples used for training, the rest is used for testing")
+	stratify := fs.Bool("stratify", false, "Preserve the proportions of labels when splitting data set")
+	seed := fs.Int64("seed", 55, "Seed of random numbers used in data set splitting and weights initialization")
+	outPath := fs.String("out", "best.yml", "Path to the file the best manifest is written to")
+	fs.Parse(args)
+	// path to manifest is mandatory
+	if *manPath == "" {
+		return errors.New("You must specify path to manifest file")
+	}
+	// path to grid is mandatory
+	if *gridPath == "" {
+		return errors.New("You must specify path to grid file")
+	}
+	// path to data is mandatory
+	if *dataPath == "" {
+		return errors.New("You must specify path to data set")
+	}
+	base, err := config.LoadManifest(*manPath)
+	if err != nil {
+		return err
+	}
+	g, err := loadGrid(*gridPath)
+	if err != nil {
+		return err
+	}
+	params := g.params()
+	if len(params) == 0 {
+		return errors.New("Grid does not contain any parameter values")
+	}
+	// sweep data set must be labeled
+	ds, err := dataset.NewDataSet(*dataPath, true)
+	if err != nil {
+		return err
+	}
+	// network labels start at 1 the same way as in the training
+	labels := ds.Labels().(*mat64.Vector)
+	netLabels, err := dataset.NewLabelMap(labels).Encode(labels)
+	if err != nil {
+		return err
+	}
+	// all networks are trained and tested on the same samples
+	rand.Seed(*seed)
+	trainInMx, trainLabels, testInMx, testLabels, err := dataset.Split(ds.Features().(*mat64.Dense),
+		netLabels, *split, *stratify)
+	if err != nil {
+		return err
+	}
+	// if we require features scaling, scale data by scaler fitted on the training samples
+	if *scale {
+		if _, trainInMx, testInMx, err = scaleSplit(trainInMx, testInMx); err != nil {
+			return err
+		}
+	}
+	var results []*sweepResult
+	// idx contains indices of parameter values of the current combination
+	idx := make([]int, len(params))
+	for done := false; !done; {
+		res := &sweepResult{manifest: *base}
+		for i, p := range params {
+			p.set[idx[i]](&res.manifest)
+			res.values = append(res.values, p.values[idx[i]])
+		}
+		fmt.Fprintf(os.Stderr, "Training network: %s\n", strings.Join(res.values, " "))
+		// all networks start with the same random weights
+		rnd := rand.New(rand.NewSource(*seed))
+		if res.accuracy, err = sweepRun(&res.manifest, rnd, trainInMx, trainLabels, testInMx, testLabels); err != nil {
+			fmt.Fprintf(os.Stderr, "Training failed: %s\n", err)
+		} else {
+			results = append(results, res)
+		}
+		// move to the next combination of parameter values
+		done = true
+		for i := len(idx) - 1; i >= 0; i-- {
+			idx[i]++
+			if idx[i] < len(params[i].values) {
+				done = false
+				break
+			}
+			idx[i] = 0
+		}
+	}
+	if len(results) == 0 {
+		return errors.New("All training runs failed")
+	}
+	sort.Stable(byAccuracy(results))
+	// print ranked results table
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprint(tw, "rank\taccuracy\t")
+	for _, p := range params {
+		fmt.Fprintf(tw, "%s\t", p.name)
+	}
+	fmt.Fprintln(tw)
+	for i, res := range results {
+		fmt.Fprintf(tw, "%d\t%f\t%s\t\n", i+1, res.accuracy, strings.Join(res.values, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	// write the best manifest
+	f, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return config.WriteManifest(f, &results[0].manifest)
+}
+
+// sweepRun trains the network defined in manifest with initial weights drawn from rnd
+// and returns its accuracy on test samples
+func sweepRun(m *config.Manifest, rnd *rand.Rand, trainInMx *mat64.Dense, trainLabels *mat64.Vector,
+	testInMx *mat64.Dense, testLabels *mat64.Vector) (float64, error) {
+	c, err := config.ParseManifest(m)
+	if err != nil {
+		return 0.0, err
+	}
+	net, err := neural.NewNetworkWithRand(c.Network, rnd)
+	if err != nil {
+		return 0.0, err
+	}
+	if err := net.Train(c.Training, trainInMx, trainLabels); err != nil {
+		return 0.0, err
+	}
+	return net.Validate(testInMx, testLabels)
+}
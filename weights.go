@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/milosgajdos83/go-neural/pkg/matrix"
+	"github.com/milosgajdos83/go-neural/pkg/viz"
+)
+
+// runWeights renders weights of every layer of saved neural network model as PNG heatmaps.
+// If image dimensions are specified, weights of every first layer neuron are also rendered
+// as an image tile, so that the features learnt from image data can be seen.
+func runWeights(args []string) error {
+	fs := flag.NewFlagSet("weights", flag.ExitOnError)
+	modelPath := fs.String("model", "", "Path to a saved neural net model")
+	outDir := fs.String("out", "", "Path to a directory heatmaps are written to")
+	cell := fs.Int("cell", 4, "Size of a single weight in pixels")
+	width := fs.Int("width", 0, "Width of input images: renders first layer neurons as image tiles")
+	height := fs.Int("height", 0, "Height of input images: renders first layer neurons as image tiles")
+	colMajor := fs.Bool("col-major", false, "Input images are stored column by column")
+	fs.Parse(args)
+	// path to model is mandatory
+	if *modelPath == "" {
+		return errors.New("You must specify path to model file")
+	}
+	// path to output is mandatory
+	if *outDir == "" {
+		return errors.New("You must specify output directory")
+	}
+	// both image dimensions are required to render tiles
+	if (*width == 0) != (*height == 0) {
+		return errors.New("You must specify both width and height of input images")
+	}
+	net, err := loadModel(*modelPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		return err
+	}
+	layers := net.Layers()
+	for i, layer := range layers[1:] {
+		img, err := viz.Heatmap(layer.WeightsView(), *cell)
+		if err != nil {
+			return err
+		}
+		if err := writePNG(filepath.Join(*outDir, fmt.Sprintf("layer_%d.png", i+1)), img); err != nil {
+			return err
+		}
+	}
+	if *width == 0 {
+		return nil
+	}
+	// bias weights are not part of the image
+	weights := layers[1].Weights()
+	rows, cols := weights.Dims()
+	order := matrix.RowMajor
+	if *colMajor {
+		order = matrix.ColMajor
+	}
+	img, err := viz.Tiles(weights.View(0, 1, rows, cols-1), *width, *height, *cell, order)
+	if err != nil {
+		return err
+	}
+	return writePNG(filepath.Join(*outDir, "layer_1_tiles.png"), img)
+}
+
+// writePNG writes image img to the file stored in path in PNG format
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}